@@ -0,0 +1,92 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// RoundTripper returns a http.RoundTripper adapter, which sends the request
+// through the client like Do, so the hooks, middlewares, retries, metrics
+// and the OnResponse callback of the client are applied.
+//
+// The headers of the request override the default headers of the client,
+// and the default queries of the client are merged into the url.
+// But the response handlers are not applied, and the response is returned
+// as it is whatever the status code, and its body is not decoded.
+func (c *Client) RoundTripper() http.RoundTripper { return clientTransport{c} }
+
+// AsHTTPClient returns a new *http.Client whose transport is the adapter
+// returned by RoundTripper, which is used for the libraries that only accept
+// *http.Client.
+//
+// Its Timeout mirrors the timeout set by SetTimeout. And the redirects are
+// not followed by it, but by the doer of the client, such as *http.Client
+// with its CheckRedirect, to avoid following them twice.
+func (c *Client) AsHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: c.RoundTripper(),
+		Timeout:   c.timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+type clientTransport struct{ client *Client }
+
+func (t clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := t.client.Request(req.Method, req.URL.String()).AddHeaders(req.Header)
+	if req.Body != nil && req.Body != http.NoBody {
+		r.SetBody(&requestBodyProvider{req: req})
+	} else if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	resp := r.Do(req.Context(), func(*http.Response) error { return nil })
+	if err := resp.Result(); err != nil {
+		resp.Close()
+		return nil, err
+	}
+	return resp.Response(), nil
+}
+
+// requestBodyProvider provides the body of the adapted http request,
+// which is replayable only if GetBody of the request is set.
+type requestBodyProvider struct {
+	req  *http.Request
+	used bool
+}
+
+func (p *requestBodyProvider) String() string      { return "the body of the adapted http request" }
+func (p *requestBodyProvider) ContentType() string { return "" }
+func (p *requestBodyProvider) Body() (io.ReadCloser, int64, error) {
+	length := p.req.ContentLength
+	if length == 0 {
+		length = -1
+	}
+
+	if !p.used {
+		p.used = true
+		return p.req.Body, length, nil
+	} else if p.req.GetBody == nil {
+		return nil, 0, errors.New("the body of the adapted http request cannot be replayed")
+	}
+
+	body, err := p.req.GetBody()
+	return body, length, err
+}
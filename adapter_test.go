@@ -0,0 +1,106 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientAsHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Hook", r.Header.Get("X-Hook"))
+		w.Header().Set("X-Length", strconv.FormatInt(r.ContentLength, 10))
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(404)
+		}
+		_, _ = w.Write([]byte(r.Method + ":" + r.URL.RawQuery + ":" + string(body)))
+	}))
+	defer server.Close()
+
+	var responses int32
+	client := NewClient(http.DefaultClient).SetTimeout(time.Minute).
+		SetLatencyRecorder(NewLatencyRecorder()).AddQuery("k", "v").
+		OnResponse(func(*Response) { atomic.AddInt32(&responses, 1) }).
+		AddHook(HookFunc(func(r *http.Request) *http.Request {
+			r.Header = cloneHeader(r.Header)
+			r.Header.Set("X-Hook", "1")
+			return r
+		}))
+
+	hc := client.AsHTTPClient()
+	if hc.Timeout != time.Minute {
+		t.Errorf("expect timeout %s, but got %s", time.Minute, hc.Timeout)
+	}
+
+	// The third-party-style consumer
+	get := func(url string) (code int, body string, header http.Header) {
+		resp, err := hc.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, string(data), resp.Header
+	}
+
+	if code, body, header := get(server.URL + "/users"); code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, code)
+	} else if body != "GET:k=v:" {
+		t.Errorf("expect body '%s', but got '%s'", "GET:k=v:", body)
+	} else if v := header.Get("X-Hook"); v != "1" {
+		t.Errorf("expect the hook to be applied, but got '%s'", v)
+	}
+
+	// The non-2xx response is returned as it is.
+	if code, body, _ := get(server.URL + "/missing"); code != 404 {
+		t.Errorf("expect status code %d, but got %d", 404, code)
+	} else if body != "GET:k=v:" {
+		t.Errorf("expect body '%s', but got '%s'", "GET:k=v:", body)
+	}
+
+	resp, err := hc.Post(server.URL, "text/plain", strings.NewReader("abc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != "POST:k=v:abc" {
+		t.Errorf("expect body '%s', but got '%s'", "POST:k=v:abc", data)
+	} else if length := resp.Header.Get("X-Length"); length != "3" {
+		t.Errorf("expect the content length %s, but got %s", "3", length)
+	}
+
+	if n := atomic.LoadInt32(&responses); n != 3 {
+		t.Errorf("expect %d responses, but got %d", 3, n)
+	}
+	if snapshot := client.LatencySnapshot(); snapshot.All.Count != 3 {
+		t.Errorf("expect %d recorded latencies, but got %d", 3, snapshot.All.Count)
+	}
+	if n := client.InFlight(); n != 0 {
+		t.Errorf("expect no in-flight requests, but got %d", n)
+	}
+}
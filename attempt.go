@@ -0,0 +1,77 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// OnAttempt sets a callback function to be called after each attempt
+// to send the request, such as the retries set by SetRetry, the invalid
+// CSRF token or the rejected compressed body, which is passed a non-final
+// response of the attempt.
+//
+// Notice: the callback must not read or close the response body.
+//
+// Unlike OnAttempt, OnResponse is called only once with the final response
+// of the logical request. So a callback shared by both can use
+// Response.IsFinal to distinguish them.
+func (c *Client) OnAttempt(f func(*Response)) *Client {
+	c.onattempt = f
+	return c
+}
+
+// OnAttempt overrides the attempt callback of the client.
+//
+// See Client.OnAttempt.
+func (r *Request) OnAttempt(f func(*Response)) *Request {
+	r.onattempt = f
+	return r
+}
+
+// IsFinal reports whether the response is the final outcome
+// of the logical request, which is passed to OnResponse,
+// rather than that of an attempt passed to OnAttempt.
+func (r *Response) IsFinal() bool { return r.final }
+
+// Attempts returns the number of the attempts to send the request,
+// which is 0 if the request is not sent, such as failing to build it.
+//
+// For the response of an attempt, it is the sequence number
+// of the attempt starting with 1.
+func (r *Response) Attempts() int { return r.attempts }
+
+// attempt records an attempt to send the request.
+func (r *Request) attempt(req *http.Request, resp *http.Response, err error, cost time.Duration) {
+	r.attempts++
+	if r.onattempt == nil {
+		return
+	}
+
+	r.onattempt(&Response{
+		ctx:      req.Context(),
+		err:      err,
+		url:      r.url,
+		mhd:      r.method,
+		req:      req,
+		resp:     resp,
+		cost:     cost,
+		rbody:    r.body,
+		closed:   true, // The response body is owned by the final response.
+		attempts: r.attempts,
+		redactor: r.redactor,
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOnAttempt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderContentEncoding) != "" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	var finals, attempts []*Response
+	shared := func(r *Response) {
+		if r.IsFinal() {
+			finals = append(finals, r)
+		} else {
+			attempts = append(attempts, r)
+		}
+	}
+
+	client := NewClient(http.DefaultClient).OnResponse(shared).OnAttempt(shared).
+		CompressBody(true).EnableAdaptiveRequestCompression()
+
+	resp := client.Post(server.URL).SetBody("abc").Do(context.Background(), nil)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(finals) != 1 || finals[0] != resp {
+		t.Fatalf("expect 1 final response, but got %d", len(finals))
+	} else if len(attempts) != 2 {
+		t.Fatalf("expect 2 attempts, but got %d", len(attempts))
+	}
+
+	if n := resp.Attempts(); n != 2 {
+		t.Errorf("expect %d attempts, but got %d", 2, n)
+	}
+	for i, code := range []int{415, 204} {
+		if n := attempts[i].Attempts(); n != i+1 {
+			t.Errorf("%d: expect attempt %d, but got %d", i, i+1, n)
+		}
+		if c := attempts[i].StatusCode(); c != code {
+			t.Errorf("%d: expect status code %d, but got %d", i, code, c)
+		}
+	}
+
+	// No attempt
+	finals, attempts = nil, nil
+	resp = client.Get("/path").Do(context.Background(), nil)
+	if resp.Unwrap() == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if len(finals) != 1 || len(attempts) != 0 || resp.Attempts() != 0 {
+		t.Errorf("expect no attempts, but got finals=%d, attempts=%d", len(finals), len(attempts))
+	}
+}
+
+func TestOnAttemptRetry(t *testing.T) {
+	var calls int
+	client := NewClient(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		if calls++; calls < 3 {
+			return &http.Response{StatusCode: 503, Header: http.Header{}, Body: http.NoBody}, nil
+		}
+		return &http.Response{StatusCode: 204, Header: http.Header{}, Body: http.NoBody}, nil
+	}))
+
+	var finals, attempts []*Response
+	client.OnResponse(func(r *Response) { finals = append(finals, r) })
+	client.OnAttempt(func(r *Response) { attempts = append(attempts, r) })
+	client.SetRetry(3, func(int) time.Duration { return time.Millisecond })
+
+	// Fail twice, then succeed.
+	resp := client.Get("http://127.0.0.1").Do(context.Background(), nil)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(finals) != 1 || finals[0] != resp {
+		t.Fatalf("expect 1 final response, but got %d", len(finals))
+	} else if !resp.IsFinal() || resp.Attempts() != 3 {
+		t.Errorf("expect the final response with %d attempts, but got final=%v, attempts=%d",
+			3, resp.IsFinal(), resp.Attempts())
+	}
+
+	if len(attempts) != 3 {
+		t.Fatalf("expect 3 attempts, but got %d", len(attempts))
+	}
+	for i, code := range []int{503, 503, 204} {
+		if n := attempts[i].Attempts(); n != i+1 {
+			t.Errorf("%d: expect attempt %d, but got %d", i, i+1, n)
+		}
+		if c := attempts[i].StatusCode(); c != code {
+			t.Errorf("%d: expect status code %d, but got %d", i, code, c)
+		}
+		if attempts[i].IsFinal() {
+			t.Errorf("%d: unexpected the final attempt", i)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// basicAuth returns the value of the header Authorization for basic auth.
+func basicAuth(username, password string) string {
+	auth := username + ":" + password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
+}
+
+// SetBasicAuth sets the default header "Authorization" to use the basic auth
+// with the username and password, which is inherited by the requests.
+func (c *Client) SetBasicAuth(username, password string) *Client {
+	return c.SetHeader(HeaderAuthorization, basicAuth(username, password))
+}
+
+// SetBasicAuth sets the header "Authorization" to use the basic auth
+// with the username and password, which overrides the client setting.
+func (r *Request) SetBasicAuth(username, password string) *Request {
+	return r.SetHeader(HeaderAuthorization, basicAuth(username, password))
+}
+
+// SetBearerToken sets the header "Authorization" to use the bearer token.
+//
+// If token is empty, it does nothing.
+func (r *Request) SetBearerToken(token string) *Request {
+	if token == "" {
+		return r
+	}
+	return r.SetHeader(HeaderAuthorization, "Bearer "+token)
+}
+
+// SetBearerTokenFunc sets the provider of the bearer token, which is called
+// with the request context each time the request is built to be sent,
+// so the token can be fetched or refreshed lazily.
+//
+// If the provider returns an error, the request is aborted with it.
+// If the token is empty, the header "Authorization" is not set.
+// And the provider is not called if the header "Authorization" has been
+// set on the request, such as Request.SetBasicAuth, but the token overrides
+// the default header "Authorization" of the client.
+//
+// If provider is nil, clear it.
+//
+// Default: nil
+func (c *Client) SetBearerTokenFunc(provider func(ctx context.Context) (string, error)) *Client {
+	c.tokenf = provider
+	return c
+}
+
+func (r *Request) setBearerToken(c context.Context, req *http.Request) error {
+	if r.tokenf == nil || r.isRequestHeader(HeaderAuthorization) {
+		return nil
+	}
+
+	token, err := r.tokenf(c)
+	if err != nil || token == "" {
+		return err
+	}
+
+	cloneHeaderForWrite(req).Set(HeaderAuthorization, "Bearer "+token)
+	return nil
+}
+
+// setBaseAuth sets the header Authorization converted from the userinfo
+// of the base url only if the request is sent to the host of the base url,
+// so the credentials are not leaked to the other hosts.
+func (r *Request) setBaseAuth(req *http.Request) {
+	if r.baseauth == nil || req.Header.Get(HeaderAuthorization) != "" {
+		return
+	}
+
+	host, auth := r.baseauth()
+	if auth != "" && strings.EqualFold(req.URL.Host, host) {
+		cloneHeaderForWrite(req).Set(HeaderAuthorization, auth)
+	}
+}
+
+// urlAuthority returns the start and end indexes of the authority of rawurl,
+// which returns (-1, -1) if no authority.
+func urlAuthority(rawurl string) (start, end int) {
+	start = strings.Index(rawurl, "://")
+	if start < 0 {
+		return -1, -1
+	}
+
+	start += 3
+	if end = strings.IndexAny(rawurl[start:], "/?#"); end < 0 {
+		end = len(rawurl)
+	} else {
+		end += start
+	}
+	return
+}
+
+// splitUserinfo strips the userinfo from rawurl and returns the value
+// of the header Authorization for basic auth converted from the userinfo.
+//
+// If rawurl does not contain the userinfo, return it unchanged and an empty auth.
+func splitUserinfo(rawurl string) (newurl, auth string) {
+	start, end := urlAuthority(rawurl)
+	if start < 0 {
+		return rawurl, ""
+	}
+
+	at := strings.LastIndexByte(rawurl[start:end], '@')
+	if at < 0 {
+		return rawurl, ""
+	}
+
+	at += start
+	newurl = rawurl[:start] + rawurl[at+1:]
+	if u, err := url.Parse(rawurl); err == nil && u.User != nil {
+		password, _ := u.User.Password()
+		auth = basicAuth(u.User.Username(), password)
+	}
+	return
+}
+
+// redactUserinfo returns the url with the password in the userinfo redacted.
+func redactUserinfo(rawurl string) string {
+	start, end := urlAuthority(rawurl)
+	if start < 0 {
+		return rawurl
+	}
+
+	at := strings.LastIndexByte(rawurl[start:end], '@')
+	if at < 0 {
+		return rawurl
+	}
+
+	at += start
+	colon := strings.IndexByte(rawurl[start:at], ':')
+	if colon < 0 {
+		return rawurl
+	}
+
+	colon += start
+	return rawurl[:colon+1] + RedactedValue + rawurl[at:]
+}
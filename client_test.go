@@ -19,8 +19,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -48,6 +52,55 @@ func TestUrlMerge(t *testing.T) {
 	if url := mergeurl(baseurl, "path"); url != expect {
 		t.Errorf("expect url '%s', but got '%s'", expect, url)
 	}
+
+	for _, c := range []struct {
+		baseurl string
+		requrl  string
+		expect  string
+	}{
+		{"http://127.0.0.1:8080", "/users", "http://127.0.0.1:8080/users"},
+		{"http://127.0.0.1:8080", "/", "http://127.0.0.1:8080/"},
+		{"http://127.0.0.1:8080/v1", "/", "http://127.0.0.1:8080/v1/"},
+		{"http://127.0.0.1:8080/v1/", "users/", "http://127.0.0.1:8080/v1/users/"},
+		{"http://127.0.0.1:8080/v1//", "//users//1", "http://127.0.0.1:8080/v1/users/1"},
+		{"http://127.0.0.1//v1", "users", "http://127.0.0.1/v1/users"},
+		{"http://127.0.0.1/v1?apikey=abc", "", "http://127.0.0.1/v1?apikey=abc"},
+		{"http://127.0.0.1/v1?apikey=abc", "/users", "http://127.0.0.1/v1/users?apikey=abc"},
+		{"http://127.0.0.1/v1/?apikey=abc", "/", "http://127.0.0.1/v1/?apikey=abc"},
+		{"http://127.0.0.1?apikey=abc", "/users", "http://127.0.0.1/users?apikey=abc"},
+		{"http://127.0.0.1/v1?apikey=abc", "/users?page=2", "http://127.0.0.1/v1/users?apikey=abc&page=2"},
+		{"http://127.0.0.1/v1", "/users?page=2", "http://127.0.0.1/v1/users?page=2"},
+		{"http://127.0.0.1/v1", "?page=2", "http://127.0.0.1/v1?page=2"},
+		{"http://127.0.0.1/v1#top", "/users", "http://127.0.0.1/v1/users#top"},
+		{"http://127.0.0.1/v1?apikey=abc#top", "/users#bottom", "http://127.0.0.1/v1/users?apikey=abc#bottom"},
+	} {
+		if url := mergeurl(c.baseurl, c.requrl); url != c.expect {
+			t.Errorf("%s + %s: expect url '%s', but got '%s'", c.baseurl, c.requrl, c.expect, url)
+		}
+	}
+}
+
+func TestBaseURLQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path + "?" + r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	var result string
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		SetBaseURL(server.URL + "/v1?apikey=abc").
+		SetResponseHandler2xx(func(dst interface{}, resp *http.Response) error {
+			data, err := ioutil.ReadAll(resp.Body)
+			result = string(data)
+			return err
+		})
+
+	err := client.Get("/users").AddQuery("page", "2").Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	} else if expect := "/v1/users?apikey=abc&page=2"; result != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, result)
+	}
 }
 
 func hookAddQuery(key, value string) HookFunc {
@@ -213,3 +266,389 @@ func (b closedBuffer) Close() error { return nil }
 
 func startserver(server *http.Server) { _ = server.ListenAndServe() }
 func stopserver(server *http.Server)  { _ = server.Shutdown(context.TODO()) }
+
+func TestClientBodyTransformer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		body["length"] = r.ContentLength
+		w.Header().Set("Content-Type", MIMEApplicationJSON)
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	addField := func(key, value string) BodyTransformer {
+		return func(ct string, body []byte) ([]byte, error) {
+			if ct != MIMEApplicationJSON {
+				return body, nil
+			}
+
+			var m map[string]interface{}
+			if err := json.Unmarshal(body, &m); err != nil {
+				return nil, err
+			}
+			m[key] = value
+			return json.Marshal(m)
+		}
+	}
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		AddBodyTransformer(addField("audit", "a1")).
+		AddBodyTransformer(addField("audit", "a2")).
+		AddBodyTransformer(addField("trace", "t1"))
+
+	var result map[string]interface{}
+	err := client.Post(server.URL).SetBody(map[string]string{"name": "xgfone"}).
+		Do(context.Background(), &result).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{"audit":"a2","name":"xgfone","trace":"t1"}`
+	if result["name"] != "xgfone" || result["audit"] != "a2" || result["trace"] != "t1" {
+		t.Errorf("unexpected result: %v", result)
+	} else if length := result["length"].(float64); int(length) != len(expect) {
+		t.Errorf("expect content length %d, but got %v", len(expect), length)
+	}
+
+	result = nil
+	err = client.Post(server.URL).SetBody(strings.NewReader(`{"name":"xgfone"}`)).
+		Do(context.Background(), &result).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	} else if _, ok := result["audit"]; ok {
+		t.Errorf("unexpected transformed streaming body: %v", result)
+	}
+}
+
+func TestResponseFinalURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(500)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).AddQuery("a", "1")
+	req := client.Get(server.URL + "/path?b=2").AddHook(HookFunc(func(r *http.Request) *http.Request {
+		query := r.URL.Query()
+		query.Set("c", "3")
+		r.URL.RawQuery = query.Encode()
+		return r
+	}))
+
+	if method := req.Method(); method != http.MethodGet {
+		t.Errorf("expect method '%s', but got '%s'", http.MethodGet, method)
+	}
+	if u := req.URLString(); u != server.URL+"/path?a=1&b=2" {
+		t.Errorf("expect url '%s', but got '%s'", server.URL+"/path?a=1&b=2", u)
+	}
+
+	resp := req.Do(context.Background(), nil)
+	if u := resp.Url(); u != server.URL+"/path?b=2" {
+		t.Errorf("expect url '%s', but got '%s'", server.URL+"/path?b=2", u)
+	}
+	if u := resp.FinalURL(); u != server.URL+"/path?a=1&b=2&c=3" {
+		t.Errorf("expect final url '%s', but got '%s'", server.URL+"/path?a=1&b=2&c=3", u)
+	}
+
+	if err := resp.Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if u := err.(Error).URL; u != server.URL+"/path?a=1&b=2&c=3" {
+		t.Errorf("expect error url '%s', but got '%s'", server.URL+"/path?a=1&b=2&c=3", u)
+	}
+}
+
+func TestResponseHandlerWhen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/html" {
+			w.Header().Set(HeaderContentType, "text/html")
+			w.WriteHeader(502)
+			_, _ = w.Write([]byte("<html>Bad Gateway</html>"))
+			return
+		}
+
+		if r.URL.Path == "/maintenance" {
+			w.Header().Set("X-Maintenance", "1")
+		}
+		w.WriteHeader(503)
+	}))
+	defer server.Close()
+
+	isHTML := func(resp *http.Response) bool { return GetContentType(resp.Header) == "text/html" }
+	isMaintenance := func(resp *http.Response) bool { return resp.Header.Get("X-Maintenance") != "" }
+	errHTML := fmt.Errorf("html error page")
+	errMaintenance := fmt.Errorf("maintenance")
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		SetResponseHandlerWhen(isHTML, func(interface{}, *http.Response) error { return errHTML })
+
+	// The request predicate handlers do not affect the client.
+	err := client.Get(server.URL+"/maintenance").
+		SetResponseHandlerWhen(isMaintenance, func(interface{}, *http.Response) error { return errMaintenance }).
+		Do(context.Background(), nil).Unwrap()
+	if err == nil || err.(Error).Err != errMaintenance {
+		t.Errorf("expect error '%v', but got '%v'", errMaintenance, err)
+	}
+	if n := len(client.handler.When); n != 1 {
+		t.Errorf("expect %d predicate handlers, but got %d", 1, n)
+	}
+
+	err = client.Get(server.URL+"/maintenance").Do(context.Background(), nil).Unwrap()
+	if err == nil || err.(Error).Err == errMaintenance {
+		t.Errorf("expect the default error, but got '%v'", err)
+	}
+
+	// The predicate handlers take precedence over SetResponseHandler.
+	err = client.Get(server.URL+"/html").
+		SetResponseHandler(func(interface{}, *http.Response) error { return nil }).
+		Do(context.Background(), nil).Unwrap()
+	if err == nil || err.(Error).Err != errHTML {
+		t.Errorf("expect error '%v', but got '%v'", errHTML, err)
+	}
+
+	// Fall back to the status code handlers if no matches.
+	err = client.Get(server.URL+"/other").
+		SetResponseHandler5xx(func(interface{}, *http.Response) error { return nil }).
+		Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// The first matched one wins.
+	clone := client.Clone().SetResponseHandlerWhen(isHTML, func(interface{}, *http.Response) error { return nil })
+	err = clone.Get(server.URL+"/html").Do(context.Background(), nil).Unwrap()
+	if err == nil || err.(Error).Err != errHTML {
+		t.Errorf("expect error '%v', but got '%v'", errHTML, err)
+	}
+	if n := len(client.handler.When); n != 1 {
+		t.Errorf("expect %d predicate handlers, but got %d", 1, n)
+	}
+}
+
+func TestMisbehavingHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	var responses []*Response
+	client := NewClient(http.DefaultClient).OnResponse(func(r *Response) {
+		if r.Method() == "" || r.Url() == "" {
+			t.Errorf("expect the method and url, but got '%s' and '%s'", r.Method(), r.Url())
+		}
+		responses = append(responses, r)
+	})
+
+	var called bool
+	nilhook := HookFunc(func(*http.Request) *http.Request { return nil })
+	nexthook := HookFunc(func(r *http.Request) *http.Request { called = true; return r })
+
+	resp := client.Post(server.URL).SetBody("abc").AddHook(nilhook).AddHook(nexthook).Do(context.Background(), nil)
+	if err := resp.Unwrap(); err == nil || err.(Error).Err != ErrNilHookRequest {
+		t.Errorf("expect error ErrNilHookRequest, but got %v", err)
+	} else if resp.Request() != nil {
+		t.Errorf("expect no request, but got one")
+	} else if called {
+		t.Errorf("unexpect to call the next hook")
+	}
+
+	type ctxkey string
+	ctx := context.WithValue(context.Background(), ctxkey("key"), "value")
+	ctxhook := HookFunc(func(r *http.Request) *http.Request { return r.WithContext(ctx) })
+	resp = client.Get(server.URL).AddHook(ctxhook).Do(context.Background(), nil)
+	if err := resp.Unwrap(); err != nil {
+		t.Error(err)
+	} else if v, _ := resp.Request().Context().Value(ctxkey("key")).(string); v != "value" {
+		t.Errorf("expect the request replaced by the hook")
+	}
+
+	if len(responses) != 2 {
+		t.Errorf("expect %d responses, but got %d", 2, len(responses))
+	}
+}
+
+func TestResponseContext(t *testing.T) {
+	type ctxkey string
+	ctx := context.WithValue(context.Background(), ctxkey("key"), "value")
+
+	var value string
+	client := NewClient(http.DefaultClient).OnResponse(func(r *Response) {
+		value, _ = r.Context().Value(ctxkey("key")).(string)
+	})
+
+	// Fail to build the request.
+	resp := client.Get("/invalid").Do(ctx, nil)
+	if resp.Request() != nil {
+		t.Errorf("expect no request, but got one")
+	} else if resp.Result() == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if value != "value" {
+		t.Errorf("expect the context value '%s', but got '%s'", "value", value)
+	}
+
+	if c := NewTestResponse(ResponseOptions{}).Context(); c == nil {
+		t.Errorf("expect a non-nil context")
+	}
+}
+
+func TestClientCloneConcurrently(t *testing.T) {
+	hook := HookFunc(func(r *http.Request) *http.Request { return r })
+	client := NewClient(nil)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			key := "X-Key-" + strconv.Itoa(i%16)
+			client.SetHeader(key, strconv.Itoa(i)).AddQuery(key, "v").AddHook(hook)
+			if i%64 == 0 {
+				client.SetHook(nil)
+			}
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		clone := client.Clone()
+		if clone.header.Get(HeaderContentType) == "" {
+			t.Fatalf("expect the header '%s' in the clone", HeaderContentType)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestClientRequestConcurrently(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		for key := range r.Header {
+			_ = r.Header.Get(key)
+		}
+		return &http.Response{StatusCode: 204, Header: http.Header{}, Body: http.NoBody}, nil
+	}))
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			key := "X-Key-" + strconv.Itoa(i%16)
+			client.SetHeader(key, strconv.Itoa(i)).SetQuery(key, "v")
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		if err := client.Get("http://127.0.0.1").Do(context.Background(), nil).Unwrap(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestClientCloneDoer(t *testing.T) {
+	newDoer := func(code int) Doer {
+		return DoFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: code, Header: http.Header{}, Body: http.NoBody, Request: r}, nil
+		})
+	}
+
+	client := NewClient(nil).OnResponse(nil).SetDoer(newDoer(201))
+	clone := client.Clone()
+	client.SetDoer(newDoer(202))
+
+	if code := clone.Get("http://localhost").Do(context.Background(), nil).StatusCode(); code != 201 {
+		t.Errorf("expect the clone to keep the doer with status code %d, but got %d", 201, code)
+	}
+	if code := client.Get("http://localhost").Do(context.Background(), nil).StatusCode(); code != 202 {
+		t.Errorf("expect status code %d, but got %d", 202, code)
+	}
+
+	clone.SetHTTPClient(http.DefaultClient)
+	if code := client.Get("http://localhost").Do(context.Background(), nil).StatusCode(); code != 202 {
+		t.Errorf("expect status code %d, but got %d", 202, code)
+	}
+}
+
+func TestRequestBuild(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		SetBaseURL(server.URL).SetHeader("X-Default", "abc").AddQuery("k1", "v1").
+		AddHook(HookFunc(func(r *http.Request) *http.Request {
+			r.Header.Set("X-Hook", "xyz")
+			return r
+		}))
+
+	r := client.Post("/path").AddQuery("k2", "v2").SetBody(map[string]int{"a": 1})
+	req, err := r.Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := server.URL + "/path?k1=v1&k2=v2"; req.URL.String() != expect {
+		t.Errorf("expect url '%s', but got '%s'", expect, req.URL.String())
+	}
+	if v := req.Header.Get("X-Default"); v != "abc" {
+		t.Errorf("expect header X-Default '%s', but got '%s'", "abc", v)
+	}
+	if v := req.Header.Get("X-Hook"); v != "xyz" {
+		t.Errorf("expect header X-Hook '%s', but got '%s'", "xyz", v)
+	}
+
+	const expect = "{\"a\":1}\n"
+	if req.ContentLength != int64(len(expect)) {
+		t.Errorf("expect content length %d, but got %d", len(expect), req.ContentLength)
+	}
+	if req.GetBody == nil {
+		t.Errorf("expect GetBody, but got nil")
+	} else if body, err := req.GetBody(); err != nil {
+		t.Error(err)
+	} else if data, _ := ioutil.ReadAll(body); string(data) != expect {
+		t.Errorf("expect body '%s', but got '%s'", expect, data)
+	}
+
+	// Send the built request by the other one.
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else {
+		resp.Body.Close()
+	}
+
+	// Build does not consume the request body.
+	if err := r.Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bodies) != 2 || bodies[0] != expect || bodies[1] != expect {
+		t.Errorf("expect the bodies %q, but got %q", []string{expect, expect}, bodies)
+	}
+
+	_, err = client.Get("http://127.0.0.1:port/path").Build(context.Background())
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %T", err)
+	} else if e.Method != http.MethodGet {
+		t.Errorf("expect method '%s', but got '%s'", http.MethodGet, e.Method)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCompressionTTL is the default duration to skip compressing
+// the request body for the host that does not support it.
+var DefaultCompressionTTL = time.Minute * 10
+
+// CompressionCache caches the hosts that don't support
+// the gzip-compressed request body.
+type CompressionCache struct {
+	ttl      time.Duration
+	statuses []int
+
+	lock  sync.RWMutex
+	hosts map[string]time.Time
+}
+
+// NewCompressionCache returns a new compression cache, which records
+// the host as unsupported for ttl when the server responds with one of
+// the status codes to the compressed request body.
+//
+// If ttl is equal to or less than 0, use DefaultCompressionTTL instead.
+// If statuses is empty, use 415 (Unsupported Media Type) instead.
+func NewCompressionCache(ttl time.Duration, statuses ...int) *CompressionCache {
+	if ttl <= 0 {
+		ttl = DefaultCompressionTTL
+	}
+	if len(statuses) == 0 {
+		statuses = []int{http.StatusUnsupportedMediaType}
+	}
+
+	return &CompressionCache{
+		ttl:      ttl,
+		statuses: append([]int(nil), statuses...),
+		hosts:    make(map[string]time.Time, 4),
+	}
+}
+
+// Supported reports whether the host supports the compressed request body,
+// that's, it has not been recorded as unsupported or has expired.
+func (c *CompressionCache) Supported(host string) bool {
+	c.lock.RLock()
+	expire, ok := c.hosts[strings.ToLower(host)]
+	c.lock.RUnlock()
+	return !ok || !time.Now().Before(expire)
+}
+
+// MarkUnsupported records that the host does not support
+// the compressed request body during the ttl.
+func (c *CompressionCache) MarkUnsupported(host string) {
+	expire := time.Now().Add(c.ttl)
+	c.lock.Lock()
+	for h, t := range c.hosts { // Clean the expired hosts.
+		if !t.After(time.Now()) {
+			delete(c.hosts, h)
+		}
+	}
+	c.hosts[strings.ToLower(host)] = expire
+	c.lock.Unlock()
+}
+
+// Unsupported returns the hosts not supporting the compressed request body
+// and their expiration time, which is used to debug.
+func (c *CompressionCache) Unsupported() map[string]time.Time {
+	now := time.Now()
+	c.lock.RLock()
+	hosts := make(map[string]time.Time, len(c.hosts))
+	for h, t := range c.hosts {
+		if t.After(now) {
+			hosts[h] = t
+		}
+	}
+	c.lock.RUnlock()
+	return hosts
+}
+
+// Reset clears all the recorded hosts.
+func (c *CompressionCache) Reset() {
+	c.lock.Lock()
+	c.hosts = make(map[string]time.Time, 4)
+	c.lock.Unlock()
+}
+
+func (c *CompressionCache) rejected(status int) bool {
+	for _, s := range c.statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CompressBody sets whether to compress the request body by gzip,
+// and set the header "Content-Encoding" to "gzip".
+//
+// The buffered body is compressed into memory before sending, but
+// the body provider and the streaming body are compressed while being
+// sent with the unknown content length. And it is not compressed
+// if the header "Content-Encoding" has been set.
+//
+// Default: false
+func (c *Client) CompressBody(compress bool) *Client {
+	c.compress = compress
+	return c
+}
+
+// CompressBody overrides the setting of the client.
+//
+// See Client.CompressBody.
+func (r *Request) CompressBody(compress bool) *Request {
+	r.compress = compress
+	return r
+}
+
+// EnableAdaptiveRequestCompression enables the adaptive request compression
+// with the default compression cache if not set.
+//
+// See SetCompressionCache.
+func (c *Client) EnableAdaptiveRequestCompression() *Client {
+	if c.compression == nil {
+		c.compression = NewCompressionCache(0)
+	}
+	return c
+}
+
+// SetCompressionCache sets the compression cache to enable the adaptive
+// request compression, which is shared by the cloned clients.
+//
+// When the server responds with the rejected status code to the compressed
+// request body, the host is recorded as unsupported, and the request
+// is retried once uncompressed if the body is replayable. Then,
+// the request body to the host is not compressed until the record expires.
+//
+// If cache is nil, disable the adaptive request compression.
+//
+// Default: nil
+func (c *Client) SetCompressionCache(cache *CompressionCache) *Client {
+	c.compression = cache
+	return c
+}
+
+// CompressionCache returns the compression cache, which may be nil.
+func (c *Client) CompressionCache() *CompressionCache {
+	return c.compression
+}
+
+// compressBody compresses the request body by gzip.
+func (r *Request) compressBody(req *http.Request) (err error) {
+	if !r.compress || req.Body == nil || req.Body == http.NoBody ||
+		req.Header.Get(HeaderContentEncoding) != "" ||
+		(r.compression != nil && !r.compression.Supported(req.URL.Host)) {
+		return
+	}
+
+	// Compress the provider and streaming body through a pipe
+	// to keep it streamed instead of buffering it into memory.
+	if r.bodybuf == nil {
+		if getBody := req.GetBody; getBody != nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return gzipPipe(body), nil
+			}
+		}
+
+		req.Body = gzipPipe(req.Body)
+		req.ContentLength = -1
+		cloneHeaderForWrite(req).Set(HeaderContentEncoding, "gzip")
+		return
+	}
+
+	// Use a fresh body to keep the original body replayable.
+	body := req.Body
+	if req.GetBody != nil {
+		_ = req.Body.Close()
+		if body, err = req.GetBody(); err != nil {
+			return
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	w := gzip.NewWriter(buf)
+	_, err = io.Copy(w, body)
+	if _err := w.Close(); err == nil {
+		err = _err
+	}
+	_ = body.Close()
+	if err != nil {
+		return
+	}
+
+	data := buf.Bytes()
+	req.ContentLength = int64(len(data))
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	cloneHeaderForWrite(req).Set(HeaderContentEncoding, "gzip")
+	return
+}
+
+// gzipPipe returns a reader to read the body compressed by gzip,
+// which is compressed by a goroutine while being read.
+func gzipPipe(body io.ReadCloser) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		w := gzip.NewWriter(pw)
+		_, err := io.Copy(w, body)
+		if _err := w.Close(); err == nil {
+			err = _err
+		}
+		_ = body.Close()
+		_ = pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// isCompressionRejected reports whether the server rejects
+// the compressed request body.
+func (r *Request) isCompressionRejected(req *http.Request, resp *http.Response) bool {
+	return r.compress && r.compression != nil &&
+		r.header.Get(HeaderContentEncoding) == "" && // Not compressed by the user
+		req.Header.Get(HeaderContentEncoding) == "gzip" &&
+		r.compression.rejected(resp.StatusCode)
+}
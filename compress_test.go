@@ -0,0 +1,161 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompressBody(t *testing.T) {
+	var encodings []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := r.Header.Get(HeaderContentEncoding)
+		encodings = append(encodings, encoding)
+		if encoding != "" {
+			if r.URL.Path == "/legacy" {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+
+			gr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(400)
+				return
+			}
+			r.Body = gr
+		}
+		_, _ = io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).CompressBody(true)
+	do := func(path string) string {
+		encodings = encodings[:0]
+		var body string
+		err := client.Post(server.URL+path).SetBody("abc").Do(context.Background(), func(resp *http.Response) error {
+			if resp.StatusCode != 200 {
+				return ReadResponseBodyAsError(nil, resp)
+			}
+			data, err := ioutil.ReadAll(resp.Body)
+			body = string(data)
+			return err
+		}).Unwrap()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", path, err)
+		}
+		return body
+	}
+
+	if body := do("/"); body != "abc" {
+		t.Errorf("expect body '%s', but got '%s'", "abc", body)
+	} else if len(encodings) != 1 || encodings[0] != "gzip" {
+		t.Errorf("expect the gzip body, but got %v", encodings)
+	}
+
+	// No adaptive compression
+	err := client.Post(server.URL+"/legacy").SetBody("abc").Do(context.Background(), nil).Unwrap()
+	if e, ok := err.(Error); !ok || e.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expect status code 415, but got %v", err)
+	}
+
+	client.EnableAdaptiveRequestCompression()
+	if body := do("/legacy"); body != "abc" {
+		t.Errorf("expect body '%s', but got '%s'", "abc", body)
+	} else if len(encodings) != 2 || encodings[0] != "gzip" || encodings[1] != "" {
+		t.Errorf("expect retrying uncompressed, but got %v", encodings)
+	}
+
+	hosts := client.CompressionCache().Unsupported()
+	if _, ok := hosts[server.Listener.Addr().String()]; !ok || len(hosts) != 1 {
+		t.Errorf("unexpected unsupported hosts: %v", hosts)
+	}
+
+	if body := do("/"); body != "abc" {
+		t.Errorf("expect body '%s', but got '%s'", "abc", body)
+	} else if len(encodings) != 1 || encodings[0] != "" {
+		t.Errorf("expect the uncompressed body, but got %v", encodings)
+	}
+
+	client.CompressionCache().Reset()
+	if do("/"); len(encodings) != 1 || encodings[0] != "gzip" {
+		t.Errorf("expect the gzip body, but got %v", encodings)
+	}
+}
+
+func TestCompressionCache(t *testing.T) {
+	cache := NewCompressionCache(-1, 400, 415)
+	if !cache.rejected(400) || !cache.rejected(415) || cache.rejected(500) {
+		t.Errorf("unexpected rejected status codes: %v", cache.statuses)
+	}
+
+	cache.MarkUnsupported("Example.com")
+	if cache.Supported("example.com") {
+		t.Errorf("expect unsupported host, but got supported")
+	}
+
+	cache.ttl = 0
+	cache.MarkUnsupported("example.com")
+	if !cache.Supported("example.com") {
+		t.Errorf("expect supported host after expired, but got unsupported")
+	} else if hosts := cache.Unsupported(); len(hosts) != 0 {
+		t.Errorf("expect no unsupported hosts, but got %v", hosts)
+	}
+}
+
+func TestCompressStreamingBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderContentEncoding) != "gzip" || r.ContentLength != -1 {
+			w.WriteHeader(400)
+			return
+		}
+
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		_, _ = io.Copy(w, gr)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).CompressBody(true)
+	bodies := []interface{}{
+		strings.NewReader("abc"),
+		BytesBodyProvider{Data: []byte("abc")},
+	}
+
+	for _, body := range bodies {
+		var data []byte
+		err := client.Post(server.URL).SetBody(body).Do(context.Background(), func(resp *http.Response) (err error) {
+			if resp.StatusCode != 200 {
+				return ReadResponseBodyAsError(nil, resp)
+			}
+			data, err = ioutil.ReadAll(resp.Body)
+			return
+		}).Unwrap()
+		if err != nil {
+			t.Errorf("%T: unexpected error: %v", body, err)
+		} else if string(data) != "abc" {
+			t.Errorf("%T: expect body '%s', but got '%s'", body, "abc", data)
+		}
+	}
+}
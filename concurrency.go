@@ -0,0 +1,298 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultPriorityAging is the default waiting duration to raise
+// the priority of a waiter of ConcurrencyLimiter by one.
+const DefaultPriorityAging = time.Second
+
+// ConcurrencyLimiter limits the number of the concurrent requests.
+//
+// When the limiter is saturated, the waiter with the higher priority
+// acquires the released slot first, and the waiters with the same priority
+// are FIFO. To avoid starving the waiters with the lower priority,
+// the priority of a waiter is raised by one per Aging while waiting.
+//
+// A slot is held until the response body is closed, or the request fails.
+type ConcurrencyLimiter struct {
+	// Aging is the waiting duration to raise the priority of a waiter by one.
+	// If negative, disable the aging.
+	//
+	// Default: DefaultPriorityAging
+	Aging time.Duration
+
+	max int
+
+	lock    sync.Mutex
+	active  int
+	seq     uint64
+	waiters []*concurrencyWaiter
+	waits   map[int]*QueueWaitStats
+}
+
+type concurrencyWaiter struct {
+	ready    chan struct{}
+	start    time.Time
+	priority int
+	seq      uint64
+}
+
+// QueueWaitStats is the statistics of the durations
+// waiting for the slots of ConcurrencyLimiter.
+type QueueWaitStats struct {
+	Count uint64        `json:"count"` // The number of the acquired slots
+	Sum   time.Duration `json:"sum"`
+	Max   time.Duration `json:"max"`
+}
+
+// Mean returns the mean waiting duration.
+func (s QueueWaitStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// NewConcurrencyLimiter returns a new concurrency limiter,
+// which allows max requests to be sent concurrently at most.
+func NewConcurrencyLimiter(max int) *ConcurrencyLimiter {
+	if max <= 0 {
+		panic("NewConcurrencyLimiter: max must be greater than 0")
+	}
+	return &ConcurrencyLimiter{
+		Aging: DefaultPriorityAging,
+		max:   max,
+		waits: make(map[int]*QueueWaitStats),
+	}
+}
+
+// Max returns the maximum number of the concurrent requests.
+func (l *ConcurrencyLimiter) Max() int { return l.max }
+
+// Active returns the number of the acquired slots.
+func (l *ConcurrencyLimiter) Active() (n int) {
+	l.lock.Lock()
+	n = l.active
+	l.lock.Unlock()
+	return
+}
+
+// Waiting returns the number of the waiters.
+func (l *ConcurrencyLimiter) Waiting() (n int) {
+	l.lock.Lock()
+	n = len(l.waiters)
+	l.lock.Unlock()
+	return
+}
+
+// QueueWaits returns the statistics of the waiting durations
+// per the priority that the request is acquired with.
+func (l *ConcurrencyLimiter) QueueWaits() map[int]QueueWaitStats {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	waits := make(map[int]QueueWaitStats, len(l.waits))
+	for priority, stats := range l.waits {
+		waits[priority] = *stats
+	}
+	return waits
+}
+
+// Acquire blocks until a slot is acquired with the priority or ctx is done.
+//
+// If successfully, the returned release function must be called
+// to release the slot, which may be called more than once.
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, priority int) (release func(), err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	l.lock.Lock()
+	if l.active < l.max && len(l.waiters) == 0 {
+		l.active++
+		l.record(priority, 0)
+		l.lock.Unlock()
+		return l.releaser(), nil
+	}
+
+	l.seq++
+	w := &concurrencyWaiter{
+		ready:    make(chan struct{}),
+		start:    time.Now(),
+		priority: priority,
+		seq:      l.seq,
+	}
+	l.waiters = append(l.waiters, w)
+	l.lock.Unlock()
+
+	select {
+	case <-w.ready:
+		return l.releaser(), nil
+
+	case <-ctx.Done():
+		l.lock.Lock()
+		removed := l.remove(w)
+		l.lock.Unlock()
+
+		// The slot has been handed over to the waiter, so pass it on.
+		if !removed {
+			l.release()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (l *ConcurrencyLimiter) releaser() func() {
+	var once sync.Once
+	return func() { once.Do(l.release) }
+}
+
+func (l *ConcurrencyLimiter) release() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if len(l.waiters) == 0 {
+		l.active--
+		return
+	}
+
+	// Hand over the slot to the next waiter directly.
+	now := time.Now()
+	w := l.next(now)
+	l.remove(w)
+	l.record(w.priority, now.Sub(w.start))
+	close(w.ready)
+}
+
+// next returns the waiter with the highest aged priority,
+// and the earliest one if the priorities are the same.
+func (l *ConcurrencyLimiter) next(now time.Time) (next *concurrencyWaiter) {
+	var nextp int
+	for _, w := range l.waiters {
+		p := w.priority
+		if l.Aging > 0 {
+			p += int(now.Sub(w.start) / l.Aging)
+		}
+
+		if next == nil || p > nextp || (p == nextp && w.seq < next.seq) {
+			next, nextp = w, p
+		}
+	}
+	return
+}
+
+func (l *ConcurrencyLimiter) remove(w *concurrencyWaiter) bool {
+	for i, _w := range l.waiters {
+		if _w == w {
+			copy(l.waiters[i:], l.waiters[i+1:])
+			l.waiters[len(l.waiters)-1] = nil
+			l.waiters = l.waiters[:len(l.waiters)-1]
+			return true
+		}
+	}
+	return false
+}
+
+func (l *ConcurrencyLimiter) record(priority int, wait time.Duration) {
+	stats, ok := l.waits[priority]
+	if !ok {
+		stats = new(QueueWaitStats)
+		l.waits[priority] = stats
+	}
+
+	stats.Count++
+	stats.Sum += wait
+	if wait > stats.Max {
+		stats.Max = wait
+	}
+}
+
+// concurrencyBody is used to release the slot of the concurrency limiter
+// when the response body is closed.
+type concurrencyBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *concurrencyBody) Close() (err error) {
+	err = b.ReadCloser.Close()
+	b.release()
+	return
+}
+
+// acquireConcurrency acquires a slot of the concurrency limiter if set,
+// and returns the function to hold the slot until the response is done.
+func (r *Request) acquireConcurrency(c context.Context) (done func(*http.Response, error), err error) {
+	if r.conclimit == nil {
+		return func(*http.Response, error) {}, nil
+	}
+
+	release, err := r.conclimit.Acquire(c, r.priority)
+	if err != nil {
+		return
+	}
+
+	return func(resp *http.Response, err error) {
+		if err != nil || resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+			release()
+		} else {
+			resp.Body = &concurrencyBody{ReadCloser: resp.Body, release: release}
+		}
+	}, nil
+}
+
+// SetMaxConcurrency limits the number of the concurrent requests to max,
+// which waits for the released slots by the priority of the requests
+// when saturated. See ConcurrencyLimiter.
+//
+// If max is equal to or less than 0, no limit.
+func (c *Client) SetMaxConcurrency(max int) *Client {
+	if max > 0 {
+		c.conclimit = NewConcurrencyLimiter(max)
+	} else {
+		c.conclimit = nil
+	}
+	return c
+}
+
+// SetConcurrencyLimiter sets the concurrency limiter of the requests,
+// which may be shared by more than one client.
+//
+// If limiter is nil, no limit.
+func (c *Client) SetConcurrencyLimiter(limiter *ConcurrencyLimiter) *Client {
+	c.conclimit = limiter
+	return c
+}
+
+// ConcurrencyLimiter returns the concurrency limiter, which may be nil.
+func (c *Client) ConcurrencyLimiter() *ConcurrencyLimiter { return c.conclimit }
+
+// SetPriority sets the priority of the request to acquire the slot
+// of the concurrency limiter, which is only used when the limiter is set
+// and saturated. The higher the priority, the earlier the request is sent.
+//
+// Default: 0
+func (r *Request) SetPriority(priority int) *Request {
+	r.priority = priority
+	return r
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func waitConcurrencyWaiters(t *testing.T, limiter *ConcurrencyLimiter, n int) {
+	for i := 0; i < 1000; i++ {
+		if limiter.Waiting() == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expect %d waiters, but got %d", n, limiter.Waiting())
+}
+
+func newPriorityTestClient(limiter *ConcurrencyLimiter) (client *Client, block chan struct{}, order func() []string) {
+	var lock sync.Mutex
+	var names []string
+	block = make(chan struct{})
+	order = func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), names...)
+	}
+
+	client = NewClient(nil).OnResponse(nil).SetConcurrencyLimiter(limiter)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		name := r.URL.Query().Get("name")
+		if name == "block" {
+			<-block
+		}
+
+		lock.Lock()
+		names = append(names, name)
+		lock.Unlock()
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+	return
+}
+
+func TestConcurrencyLimiterPriority(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1)
+	limiter.Aging = time.Hour
+	client, block, order := newPriorityTestClient(limiter)
+
+	var wg sync.WaitGroup
+	send := func(name string, priority int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := client.Get("http://127.0.0.1").AddQuery("name", name).
+				SetPriority(priority).Do(context.Background(), nil).Unwrap()
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	send("block", 0)
+	for limiter.Active() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	requests := []struct {
+		Name     string
+		Priority int
+	}{
+		{Name: "low1", Priority: 0},
+		{Name: "mid1", Priority: 5},
+		{Name: "normal", Priority: 1},
+		{Name: "mid2", Priority: 5},
+		{Name: "low2", Priority: 0},
+		{Name: "high", Priority: 10},
+	}
+	for i, req := range requests {
+		send(req.Name, req.Priority)
+		waitConcurrencyWaiters(t, limiter, i+1)
+	}
+
+	close(block)
+	wg.Wait()
+
+	expects := []string{"block", "high", "mid1", "mid2", "normal", "low1", "low2"}
+	if names := order(); strings.Join(names, ",") != strings.Join(expects, ",") {
+		t.Errorf("expect the order %v, but got %v", expects, names)
+	}
+
+	if n := limiter.Active(); n != 0 {
+		t.Errorf("expect no active slots, but got %d", n)
+	}
+
+	waits := limiter.QueueWaits()
+	if len(waits) != 4 {
+		t.Errorf("expect the waits of 4 priorities, but got %d", len(waits))
+	}
+	for priority, count := range map[int]uint64{0: 3, 1: 1, 5: 2, 10: 1} {
+		if stats := waits[priority]; stats.Count != count {
+			t.Errorf("priority %d: expect %d acquisitions, but got %d", priority, count, stats.Count)
+		} else if priority != 0 && (stats.Max <= 0 || stats.Mean() <= 0) {
+			t.Errorf("priority %d: expect the positive waits, but got %+v", priority, stats)
+		}
+	}
+}
+
+func TestConcurrencyLimiterAging(t *testing.T) {
+	limiter := NewConcurrencyLimiter(1)
+	limiter.Aging = time.Millisecond
+	client, block, order := newPriorityTestClient(limiter)
+
+	var wg sync.WaitGroup
+	send := func(name string, priority int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = client.Get("http://127.0.0.1").AddQuery("name", name).
+				SetPriority(priority).Do(context.Background(), nil).Unwrap()
+		}()
+	}
+
+	send("block", 0)
+	for limiter.Active() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	send("old", 0)
+	waitConcurrencyWaiters(t, limiter, 1)
+	time.Sleep(100 * time.Millisecond)
+
+	send("new", 10)
+	waitConcurrencyWaiters(t, limiter, 2)
+
+	close(block)
+	wg.Wait()
+
+	expects := []string{"block", "old", "new"}
+	if names := order(); strings.Join(names, ",") != strings.Join(expects, ",") {
+		t.Errorf("expect the order %v, but got %v", expects, names)
+	}
+}
+
+func TestConcurrencyLimiterCancel(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil).SetMaxConcurrency(1)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		body := ioutil.NopCloser(strings.NewReader("ok"))
+		return &http.Response{StatusCode: 200, Body: body, Request: r}, nil
+	}))
+	limiter := client.ConcurrencyLimiter()
+
+	// The slot is held until the response body is closed.
+	resp := client.Get("http://127.0.0.1").Do(context.Background(), nil)
+	if err := resp.Result(); err != nil {
+		t.Fatal(err)
+	} else if n := limiter.Active(); n != 1 {
+		t.Fatalf("expect 1 active slot, but got %d", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := client.Get("http://127.0.0.1").Do(ctx, nil).Unwrap()
+	if err == nil {
+		t.Fatal("expect an error, but got nil")
+	} else if kind := Kind(err); kind != KindTimeout {
+		t.Errorf("expect the error kind '%s', but got '%s'", KindTimeout, kind)
+	}
+	if n := limiter.Waiting(); n != 0 {
+		t.Errorf("expect no waiters, but got %d", n)
+	}
+
+	resp.Close()
+	if n := limiter.Active(); n != 0 {
+		t.Errorf("expect no active slots, but got %d", n)
+	}
+
+	if client.SetMaxConcurrency(0).ConcurrencyLimiter() != nil {
+		t.Errorf("expect no concurrency limiter")
+	}
+}
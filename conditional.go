@@ -0,0 +1,94 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Pre-define some headers about the conditional requests.
+const (
+	HeaderLastModified      = "Last-Modified"
+	HeaderIfModifiedSince   = "If-Modified-Since"
+	HeaderIfUnmodifiedSince = "If-Unmodified-Since"
+	HeaderIfNoneMatch       = "If-None-Match"
+	HeaderIfMatch           = "If-Match"
+)
+
+// Pre-define some errors of the failed preconditions.
+var (
+	// ErrAlreadyExists is returned when the request with IfNotExists
+	// gets the response 412 Precondition Failed.
+	ErrAlreadyExists = errors.New("the resource already exists")
+
+	// ErrNotExists is returned when the request with IfExists
+	// gets the response 412 Precondition Failed.
+	ErrNotExists = errors.New("the resource does not exist")
+)
+
+// IfNotExists sets the header "If-None-Match: *" to create the resource
+// only if it is absent, which is used by PUT or POST.
+//
+// If the resource has existed, the server responds 412 Precondition Failed,
+// and the error returned by ReadResponseBodyAsError wraps ErrAlreadyExists.
+func (r *Request) IfNotExists() *Request {
+	return r.SetHeader(HeaderIfNoneMatch, "*")
+}
+
+// IfExists sets the header "If-Match: *" to update the resource
+// only if it is present, which is used by PUT, PATCH or DELETE.
+//
+// If the resource does not exist, the server responds 412 Precondition Failed,
+// and the error returned by ReadResponseBodyAsError wraps ErrNotExists.
+func (r *Request) IfExists() *Request {
+	return r.SetHeader(HeaderIfMatch, "*")
+}
+
+// getPreconditionError returns the error of the failed precondition
+// set by IfNotExists or IfExists, or nil for other preconditions.
+func getPreconditionError(resp *http.Response) error {
+	if resp.StatusCode != http.StatusPreconditionFailed || resp.Request == nil {
+		return nil
+	}
+
+	switch {
+	case resp.Request.Header.Get(HeaderIfNoneMatch) == "*":
+		return ErrAlreadyExists
+	case resp.Request.Header.Get(HeaderIfMatch) == "*":
+		return ErrNotExists
+	default:
+		return nil
+	}
+}
+
+// SetIfModifiedSince sets the header "If-Modified-Since",
+// which formats t as the HTTP date in GMT.
+func (r *Request) SetIfModifiedSince(t time.Time) *Request {
+	return r.SetHeader(HeaderIfModifiedSince, t.UTC().Format(http.TimeFormat))
+}
+
+// SetIfUnmodifiedSince sets the header "If-Unmodified-Since",
+// which formats t as the HTTP date in GMT.
+func (r *Request) SetIfUnmodifiedSince(t time.Time) *Request {
+	return r.SetHeader(HeaderIfUnmodifiedSince, t.UTC().Format(http.TimeFormat))
+}
+
+// LastModified parses the response header "Last-Modified"
+// and reports whether it exists and is valid.
+func (r *Response) LastModified() (time.Time, bool) {
+	return r.headerTime(HeaderLastModified)
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConditionalRequest(t *testing.T) {
+	modtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v := r.Header.Get(HeaderIfUnmodifiedSince); v != "" && v != "Tue, 02 Jan 2024 03:04:05 GMT" {
+			t.Errorf("unexpected If-Unmodified-Since '%s'", v)
+		}
+		http.ServeContent(w, r, "feed", modtime, strings.NewReader("feed"))
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		SetResponseHandler(func(interface{}, *http.Response) error { return nil })
+
+	resp := client.Get(server.URL).Do(context.Background(), nil)
+	last, ok := resp.LastModified()
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if !ok || !last.Equal(modtime) {
+		t.Errorf("expect Last-Modified '%s', but got '%s'", modtime, last)
+	}
+
+	// Use the local time.
+	local := last.In(time.FixedZone("UTC+8", 8*3600))
+	resp = client.Get(server.URL).SetIfModifiedSince(local).Do(context.Background(), nil)
+	if code := resp.StatusCode(); code != 304 {
+		t.Errorf("expect status code %d, but got %d", 304, code)
+	}
+
+	resp = client.Get(server.URL).SetIfModifiedSince(local.Add(-time.Hour)).Do(context.Background(), nil)
+	if code := resp.StatusCode(); code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, code)
+	}
+
+	resp = client.Get(server.URL).SetIfUnmodifiedSince(local).Do(context.Background(), nil)
+	if code := resp.StatusCode(); code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, code)
+	}
+
+	if _, ok := NewTestResponse(ResponseOptions{StatusCode: 200}).LastModified(); ok {
+		t.Errorf("unexpect Last-Modified")
+	}
+}
+
+func TestConditionalExistence(t *testing.T) {
+	var lock sync.Mutex
+	resources := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		exists := resources[r.URL.Path]
+		if (r.Header.Get(HeaderIfNoneMatch) == "*" && exists) ||
+			(r.Header.Get(HeaderIfMatch) == "*" && !exists) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+
+		resources[r.URL.Path] = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	expectErr := func(err, expect error) {
+		t.Helper()
+		if expect == nil {
+			if err != nil {
+				t.Errorf("expect no error, but got %v", err)
+			}
+		} else if e, ok := err.(Error); !ok {
+			t.Errorf("expect an Error, but got %T", err)
+		} else if e.Err != expect {
+			t.Errorf("expect the error '%v', but got '%v'", expect, e.Err)
+		} else if e.Code != http.StatusPreconditionFailed {
+			t.Errorf("expect status code %d, but got %d", http.StatusPreconditionFailed, e.Code)
+		}
+	}
+
+	expectErr(client.Put(server.URL+"/a").IfExists().Do(context.Background(), nil).Unwrap(), ErrNotExists)
+	expectErr(client.Put(server.URL+"/a").IfNotExists().Do(context.Background(), nil).Unwrap(), nil)
+	expectErr(client.Put(server.URL+"/a").IfNotExists().Do(context.Background(), nil).Unwrap(), ErrAlreadyExists)
+	expectErr(client.Put(server.URL+"/a").IfExists().Do(context.Background(), nil).Unwrap(), nil)
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http/httptrace"
+	"time"
+)
+
+func (r *Response) gotConn(info httptrace.GotConnInfo) {
+	r.reused = info.Reused
+	if info.Conn != nil {
+		if addr := info.Conn.RemoteAddr(); addr != nil {
+			r.raddr = addr.String()
+		}
+	}
+}
+
+// ConnectionReused reports whether the connection, which the request
+// is sent on, is reused from the connection pool.
+//
+// If following the redirects, it is the connection of the last hop.
+// Return false if the transport does not provide the connection information.
+func (r *Response) ConnectionReused() bool { return r.reused }
+
+// RemoteAddr returns the remote address of the connection,
+// which the request is sent on.
+//
+// If following the redirects, it is the connection of the last hop.
+// Return "" if the transport does not provide the connection information.
+func (r *Response) RemoteAddr() string { return r.raddr }
+
+// ConnWait returns the duration waiting for the connection to send
+// the request, which includes the time queued for the idle connection
+// when the connection pool is exhausted, such as MaxConnsPerHost of
+// http.Transport, and dialing the new connection.
+//
+// If following the redirects, it is the sum of all the hops.
+// Return 0 if the transport does not provide the connection information.
+func (r *Response) ConnWait() time.Duration { return r.connwait }
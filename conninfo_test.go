@@ -0,0 +1,102 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResponseConnectionInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport := &http.Transport{}
+	defer transport.CloseIdleConnections()
+
+	client := NewClient(&http.Client{Transport: transport}).OnResponse(nil)
+	client.SetResponseHandler(func(interface{}, *http.Response) error { return nil })
+
+	addr := server.Listener.Addr().String()
+	for i, reused := range []bool{false, true} {
+		resp := client.Get(server.URL).Do(context.Background(), nil)
+		if err := resp.Unwrap(); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.ConnectionReused() != reused {
+			t.Errorf("%d: expect reused %v, but got %v", i, reused, resp.ConnectionReused())
+		}
+		if raddr := resp.RemoteAddr(); raddr != addr {
+			t.Errorf("%d: expect remote address '%s', but got '%s'", i, addr, raddr)
+		}
+	}
+
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+	resp := client.Get(server.URL).Do(context.Background(), nil)
+	if resp.ConnectionReused() || resp.RemoteAddr() != "" {
+		t.Errorf("expect no connection information, but got reused=%v, raddr=%s",
+			resp.ConnectionReused(), resp.RemoteAddr())
+	}
+}
+
+func TestResponseConnWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport := &http.Transport{MaxConnsPerHost: 1}
+	defer transport.CloseIdleConnections()
+
+	client := NewClient(&http.Client{Transport: transport}).OnResponse(nil)
+	client.SetResponseHandler(func(interface{}, *http.Response) error { return nil })
+
+	var wg sync.WaitGroup
+	waits := make([]time.Duration, 2)
+	for i := range waits {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp := client.Get(server.URL).Do(context.Background(), nil)
+			if err := resp.Unwrap(); err != nil {
+				t.Error(err)
+			}
+			waits[i] = resp.ConnWait()
+		}(i)
+	}
+	wg.Wait()
+
+	// The second request waits for the only connection used by the first.
+	min, max := waits[0], waits[1]
+	if min > max {
+		min, max = max, min
+	}
+	if max < 50*time.Millisecond {
+		t.Errorf("expect the connection wait of the second request to be at least 50ms, but got %s", max)
+	}
+	if min >= 50*time.Millisecond {
+		t.Errorf("expect the connection wait of the first request to be less than 50ms, but got %s", min)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// CSRFFetcher is used to fetch the CSRF token by the client,
+// such as getting a page or endpoint and extracting the token
+// from a cookie or a JSON field.
+type CSRFFetcher func(ctx context.Context, c *Client) (token string, err error)
+
+// DefaultCSRFInvalid is the default predicate to report whether
+// the response indicates that the CSRF token is invalid,
+// which checks whether the status code is 403 and the body prefix
+// contains "csrf" case-insensitively.
+func DefaultCSRFInvalid(resp *http.Response, bodyPrefix []byte) bool {
+	return resp.StatusCode == http.StatusForbidden &&
+		bytes.Contains(bytes.ToLower(bodyPrefix), []byte("csrf"))
+}
+
+// The maximum size of the body prefix peeked for the csrf predicate.
+const csrfPeekSize = 4096
+
+func isCSRFMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+type csrfState struct {
+	header  string
+	client  *Client
+	fetch   CSRFFetcher
+	invalid func(*http.Response, []byte) bool
+
+	lock  sync.Mutex
+	token string
+	call  *csrfCall // The in-flight fetch
+}
+
+// csrfCall is the in-flight fetch of the token shared by the callers.
+type csrfCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+type csrfFetchingKey struct{}
+
+// isFetching reports whether the request is sent by the fetcher,
+// which is sent without the token to avoid waiting for itself.
+func (s *csrfState) isFetching(ctx context.Context) bool {
+	return ctx.Value(csrfFetchingKey{}) == s
+}
+
+// Token returns the cached token, or fetches it if not cached.
+//
+// Only one fetch is in flight, and the concurrent callers wait for its result.
+// The lock is not held during fetching, so a slow fetch does not block
+// the callers with the cached token.
+func (s *csrfState) Token(ctx context.Context) (token string, err error) {
+	s.lock.Lock()
+	if token = s.token; token != "" {
+		s.lock.Unlock()
+		return
+	}
+
+	call := s.call
+	if call == nil {
+		call = &csrfCall{done: make(chan struct{})}
+		s.call = call
+		s.lock.Unlock()
+		s.doFetch(ctx, call)
+		return call.token, call.err
+	}
+	s.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (s *csrfState) doFetch(ctx context.Context, call *csrfCall) {
+	defer func() {
+		s.lock.Lock()
+		if call.err == nil && call.token != "" {
+			s.token = call.token
+		}
+		s.call = nil
+		s.lock.Unlock()
+		close(call.done)
+	}()
+
+	ctx = context.WithValue(ctx, csrfFetchingKey{}, s)
+	call.token, call.err = s.fetch(ctx, s.client)
+}
+
+// Invalidate clears the cached token only if it is still the given token,
+// so that the concurrent requests refresh the token only once.
+func (s *csrfState) Invalidate(token string) {
+	s.lock.Lock()
+	if s.token == token {
+		s.token = ""
+	}
+	s.lock.Unlock()
+}
+
+func (s *csrfState) setToken(ctx context.Context, req *http.Request) (token string, err error) {
+	if token, err = s.Token(ctx); err == nil {
+		cloneHeaderForWrite(req).Set(s.header, token)
+	}
+	return
+}
+
+// Send sends the request with the csrf token, and refreshes the token
+// and retries once if the server reports that the token is invalid.
+func (s *csrfState) Send(c context.Context, r *Request, req *http.Request) (
+	*http.Request, *http.Response, error) {
+	token, err := s.setToken(c, req)
+	if err != nil {
+		return req, nil, err
+	}
+
+	// The streaming body cannot be sent again, so don't retry.
+	body, replayable := r.replayBody()
+
+	resp, err := r.do(req)
+	if err != nil || !replayable || !s.isInvalid(resp) {
+		return req, resp, err
+	}
+
+	_ = CloseBody(resp.Body)
+	s.Invalidate(token)
+
+	if req, err = r.build(c, body); err != nil {
+		return req, nil, err
+	} else if _, err = s.setToken(c, req); err != nil {
+		return req, nil, err
+	}
+
+	resp, err = r.do(req)
+	return req, resp, err
+}
+
+func (s *csrfState) isInvalid(resp *http.Response) bool {
+	if resp.StatusCode < 400 {
+		return false
+	}
+
+	buf := make([]byte, csrfPeekSize)
+	n, err := io.ReadFull(resp.Body, buf)
+	buf = buf[:n]
+
+	invalid := s.invalid(resp, buf)
+	if !invalid { // Restore the body for the response handler.
+		var rest io.Reader = resp.Body
+		if err != nil { // EOF
+			rest = eofReader{}
+		}
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(buf), rest), resp.Body}
+	}
+	return invalid
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// EnableCSRF enables the automatic CSRF token acquisition and replay.
+//
+// The token is fetched lazily by fetch and cached, then set as the request
+// header for the requests with the method POST, PUT, PATCH or DELETE.
+// If the server responds that the token is invalid, which is checked by
+// DefaultCSRFInvalid for the default, the token is refreshed and the request
+// is retried once if its body is replayable.
+//
+// If fetch is nil, disable the CSRF support.
+func (c *Client) EnableCSRF(fetch CSRFFetcher, header string) *Client {
+	if fetch == nil {
+		c.csrf = nil
+	} else {
+		c.csrf = &csrfState{header: header, client: c, fetch: fetch, invalid: DefaultCSRFInvalid}
+	}
+	return c
+}
+
+// SetCSRFInvalid resets the predicate to report whether the response
+// indicates that the CSRF token is invalid, which is passed the response
+// and the body prefix, whose size is 4KB at most.
+//
+// Notice: it must be called after EnableCSRF.
+func (c *Client) SetCSRFInvalid(invalid func(resp *http.Response, bodyPrefix []byte) bool) *Client {
+	if c.csrf == nil {
+		panic("Client.SetCSRFInvalid: the csrf is not enabled")
+	}
+	c.csrf.invalid = invalid
+	return c
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type csrfServer struct {
+	lock    sync.Mutex
+	token   int
+	fetches int32
+}
+
+func (s *csrfServer) rotate() {
+	s.lock.Lock()
+	s.token++
+	s.lock.Unlock()
+}
+
+func (s *csrfServer) current() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return fmt.Sprintf("token%d", s.token)
+}
+
+func (s *csrfServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/csrf":
+		atomic.AddInt32(&s.fetches, 1)
+		w.Header().Set("Content-Type", MIMEApplicationJSON)
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": s.current()})
+
+	case "/action":
+		if r.Header.Get("X-Csrf-Token") != s.current() {
+			w.WriteHeader(403)
+			_, _ = w.Write([]byte("bad CSRF token"))
+			return
+		}
+
+		data, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(data)
+
+	case "/forbidden":
+		w.WriteHeader(403)
+		_, _ = w.Write([]byte("forbidden"))
+	}
+}
+
+func TestClientCSRF(t *testing.T) {
+	s := new(csrfServer)
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	fetch := func(ctx context.Context, c *Client) (string, error) {
+		var result struct {
+			Token string `json:"token"`
+		}
+		err := c.Get(server.URL+"/csrf").Do(ctx, &result).Unwrap()
+		return result.Token, err
+	}
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).EnableCSRF(fetch, "X-Csrf-Token")
+	client.SetResponseHandler4xx(ReadResponseBodyAsError)
+
+	if err := client.Get(server.URL+"/action").Do(context.Background(), nil).Unwrap(); err == nil {
+		t.Errorf("expect an error for GET, but got nil")
+	} else if n := atomic.LoadInt32(&s.fetches); n != 0 {
+		t.Errorf("expect no fetches for GET, but got %d", n)
+	}
+
+	post := func() (string, error) {
+		return client.Post(server.URL+"/action").SetBody("body").Do(context.Background(), nil).ReadBody()
+	}
+
+	if body, err := post(); err != nil {
+		t.Error(err)
+	} else if body != "body" {
+		t.Errorf("expect body '%s', but got '%s'", "body", body)
+	}
+
+	s.rotate()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := post(); err != nil {
+				t.Error(err)
+			} else if body != "body" {
+				t.Errorf("expect body '%s', but got '%s'", "body", body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&s.fetches); n != 2 {
+		t.Errorf("expect 2 fetches, but got %d", n)
+	}
+
+	// Not a CSRF failure
+	err := client.Post(server.URL+"/forbidden").Do(context.Background(), nil).Unwrap()
+	if err == nil || err.(Error).Data != "forbidden" {
+		t.Errorf("unexpected error: %v", err)
+	} else if n := atomic.LoadInt32(&s.fetches); n != 2 {
+		t.Errorf("expect 2 fetches, but got %d", n)
+	}
+}
+
+func TestClientCSRFFetchConcurrently(t *testing.T) {
+	s := new(csrfServer)
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fetch := func(ctx context.Context, c *Client) (string, error) {
+		close(started)
+		<-release
+
+		// The fetcher sends a mutating request through the same client.
+		var result struct {
+			Token string `json:"token"`
+		}
+		err := c.Post(server.URL+"/csrf").Do(ctx, &result).Unwrap()
+		return result.Token, err
+	}
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).EnableCSRF(fetch, "X-Csrf-Token")
+	post := func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		return client.Post(server.URL+"/action").SetBody("body").Do(ctx, nil).ReadBody()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if body, err := post(); err != nil {
+				t.Error(err)
+			} else if body != "body" {
+				t.Errorf("expect body '%s', but got '%s'", "body", body)
+			}
+		}()
+	}
+
+	<-started
+	time.Sleep(time.Millisecond * 10)
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&s.fetches); n != 1 {
+		t.Errorf("expect 1 fetch, but got %d", n)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// CurlString is the same as Curl with the hooks applied,
+// but returns "" if failing to build the request.
+func (r *Request) CurlString() string {
+	cmd, _ := r.Curl(context.Background(), true)
+	return cmd
+}
+
+// Curl builds the request like Do, but does not send it, and renders it
+// as an equivalent shell-quoted curl command, which contains the method,
+// the final url with the queries, the headers and the encoded body.
+//
+// If applyHooks is false, the request hooks are not applied.
+//
+// The headers in RedactedHeaders and the url are redacted like DryRun.
+// The streaming body, which is an io.Reader except for *bytes.Buffer,
+// is not read and rendered as "--data-binary @-" to read it from stdin.
+func (r *Request) Curl(c context.Context, applyHooks bool) (string, error) {
+	r.resolveURL(c)
+	if r.err != nil {
+		return "", r.newError(r.err)
+	}
+
+	var body io.Reader
+	var streaming bool
+	switch v := r.reqbody.(type) {
+	case nil:
+	case *bytes.Buffer:
+		body = bytes.NewReader(v.Bytes())
+	default:
+		streaming = true
+	}
+
+	if !applyHooks {
+		defer func(hook Hook) { r.hook = hook }(r.hook)
+		r.hook = nil
+	}
+
+	req, err := r.build(context.WithValue(c, urlRedactorKey{}, r.redactor), body)
+	if err != nil {
+		return "", r.newError(err)
+	}
+	defer func() {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+	}()
+
+	if streaming {
+		return renderCurl(req, nil, true), nil
+	}
+
+	var data []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		if data, err = ioutil.ReadAll(req.Body); err != nil {
+			return "", r.newError(err)
+		}
+	}
+	return renderCurl(req, data, false), nil
+}
+
+// NewCurlHook returns a new request hook to render the request
+// as the curl command and pass it to log, which does not modify the request.
+//
+// The body is rendered only if it can be got again by GetBody of the request,
+// or it is rendered as "--data-binary @-".
+func NewCurlHook(log func(req *http.Request, cmd string)) Hook {
+	if log == nil {
+		panic("NewCurlHook: the log function must not be nil")
+	}
+
+	return HookFunc(func(req *http.Request) *http.Request {
+		var data []byte
+		var streaming bool
+		if req.Body != nil && req.Body != http.NoBody {
+			streaming = true
+			if req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					data, err = ioutil.ReadAll(body)
+					_ = body.Close()
+					streaming = err != nil
+				}
+			}
+		}
+
+		log(req, renderCurl(req, data, streaming))
+		return req
+	})
+}
+
+func renderCurl(req *http.Request, body []byte, streaming bool) string {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.WriteString("curl")
+	switch {
+	case req.Method == http.MethodHead:
+		// "-X HEAD" makes curl wait for the response body.
+		buf.WriteString(" -I")
+	case req.Method != http.MethodGet || streaming || len(body) > 0:
+		buf.WriteString(" -X ")
+		buf.WriteString(shellQuote(req.Method))
+	}
+
+	redactor, _ := req.Context().Value(urlRedactorKey{}).(func(*url.URL) string)
+	buf.WriteByte(' ')
+	buf.WriteString(shellQuote(redactWith(redactor, req.URL)))
+
+	if req.Host != "" && req.Host != req.URL.Host {
+		buf.WriteString(" -H ")
+		buf.WriteString(shellQuote("Host: " + req.Host))
+	}
+
+	header := redactHeader(req.Header)
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			buf.WriteString(" -H ")
+			buf.WriteString(shellQuote(key + ": " + value))
+		}
+	}
+
+	switch {
+	case streaming:
+		buf.WriteString(" --data-binary @- # the streaming body is not rendered, read it from stdin")
+	case len(body) == 0:
+	case utf8.Valid(body) && bytes.IndexByte(body, 0) < 0:
+		buf.WriteString(" --data-binary ")
+		buf.WriteString(shellQuote(string(body)))
+	default:
+		buf.WriteString(" --data-binary @- # the binary body is not rendered, read it from stdin")
+	}
+
+	return buf.String()
+}
+
+// shellQuote quotes s by the single quotes for the POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
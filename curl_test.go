@@ -0,0 +1,109 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRequestCurl(t *testing.T) {
+	hook := HookFunc(func(r *http.Request) *http.Request {
+		r.Header = cloneHeader(r.Header)
+		r.Header.Set("X-Hook", "1")
+		return r
+	})
+
+	client := NewClient(nil).DelHeader(HeaderUserAgent).SetBaseURL("http://localhost/api").AddQuery("k", "v").
+		SetHeader("X-Client", "c").AddHook(hook)
+
+	req := client.Post("/users").AddQuery("q", "it's").
+		AddHeader("X-Multi", "a").AddHeader("X-Multi", "b").
+		SetHeader(HeaderAuthorization, "Bearer secret").
+		SetBody(map[string]string{"name": "O'Neil"})
+
+	expect := `curl -X 'POST' 'http://localhost/api/users?k=v&q=it%27s'` +
+		` -H 'Authorization: ***'` +
+		` -H 'Content-Type: application/json; charset=UTF-8'` +
+		` -H 'X-Client: c'` +
+		` -H 'X-Hook: 1'` +
+		` -H 'X-Multi: a' -H 'X-Multi: b'` +
+		` --data-binary '{"name":"O'\''Neil"}` + "\n'"
+	if cmd := req.CurlString(); cmd != expect {
+		t.Errorf("expect command\n%s\nbut got\n%s", expect, cmd)
+	}
+
+	// Without the hooks
+	cmd, err := req.Curl(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(cmd, "X-Hook") {
+		t.Errorf("unexpected the hook is applied: %s", cmd)
+	}
+
+	// The body is not consumed.
+	if cmd := req.CurlString(); cmd != expect {
+		t.Errorf("expect command\n%s\nbut got\n%s", expect, cmd)
+	}
+
+	// The streaming body
+	cmd = client.Put("/file").SetContentType("text/plain").
+		SetBody(strings.NewReader("abc")).CurlString()
+	if !strings.HasPrefix(cmd, "curl -X 'PUT' 'http://localhost/api/file?k=v'") ||
+		!strings.HasSuffix(cmd, " --data-binary @- # the streaming body is not rendered, read it from stdin") {
+		t.Errorf("unexpected command: %s", cmd)
+	}
+
+	// Without body
+	tests := []struct {
+		Method string
+		Expect string
+	}{
+		{Method: http.MethodGet, Expect: `curl 'http://localhost/'`},
+		{Method: http.MethodHead, Expect: `curl -I 'http://localhost/'`},
+		{Method: http.MethodDelete, Expect: `curl -X 'DELETE' 'http://localhost/'`},
+	}
+	for _, test := range tests {
+		expect := test.Expect + ` -H 'Content-Type: application/json; charset=UTF-8'`
+		cmd := NewClient(nil).DelHeader(HeaderUserAgent).Request(test.Method, "http://localhost/").CurlString()
+		if cmd != expect {
+			t.Errorf("%s: expect command '%s', but got '%s'", test.Method, expect, cmd)
+		}
+	}
+}
+
+func TestCurlHook(t *testing.T) {
+	var cmds []string
+	client := NewClient(nil).OnResponse(nil).DelHeader(HeaderUserAgent).AddHook(NewCurlHook(func(_ *http.Request, cmd string) {
+		cmds = append(cmds, cmd)
+	}))
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Header: http.Header{}, Body: http.NoBody, Request: r}, nil
+	}))
+
+	err := client.Post("http://localhost/users").SetBody(map[string]int{"id": 1}).
+		Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `curl -X 'POST' 'http://localhost/users'` +
+		` -H 'Content-Type: application/json; charset=UTF-8' --data-binary '{"id":1}` + "\n'"
+	if len(cmds) != 1 || cmds[0] != expect {
+		t.Errorf("expect command\n%s\nbut got\n%v", expect, cmds)
+	}
+}
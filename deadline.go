@@ -0,0 +1,202 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrNoDeadline is returned when the request context has no deadline
+// but the client requires it.
+var ErrNoDeadline = errors.New("the context has no deadline, which is required by Client.RequireDeadline")
+
+// DefaultDeadlineError is returned when the request fails because of
+// the default deadline applied by the client.
+type DefaultDeadlineError struct {
+	Deadline time.Duration
+	Err      error
+}
+
+func (e DefaultDeadlineError) Unwrap() error { return e.Err }
+func (e DefaultDeadlineError) Error() string {
+	return fmt.Sprintf("%s (the client default deadline %s is applied as the context has no deadline)",
+		e.Err.Error(), e.Deadline)
+}
+
+// SetDefaultDeadline sets the default deadline duration of the request,
+// which is applied only when the context passed to Do has no deadline.
+//
+// If the request fails due to the default deadline, the error is wrapped
+// into DefaultDeadlineError.
+//
+// Default: 0, that's, no default deadline
+func (c *Client) SetDefaultDeadline(d time.Duration) *Client {
+	c.deadline = d
+	return c
+}
+
+// RequireDeadline sets whether the context passed to Do must have a deadline.
+// If true, the request without the deadline fails fast with ErrNoDeadline
+// instead of applying the default deadline.
+//
+// Default: false
+func (c *Client) RequireDeadline(require bool) *Client {
+	c.reqdeadline = require
+	return c
+}
+
+// SetTimeout sets the default timeout of each request, which covers
+// the whole request including the retries and reading the response body.
+//
+// The request context is canceled only after the response body is read
+// and closed, such as the response handler or Response.Close, so the body
+// can still be streamed after Do returns but within the timeout.
+//
+// If set, the context passed to Do is regarded as having the deadline,
+// so the default deadline is not applied.
+//
+// Default: 0, that's, no timeout
+func (c *Client) SetTimeout(timeout time.Duration) *Client {
+	c.timeout = timeout
+	return c
+}
+
+// SetTimeout overrides the timeout of the client for the request.
+//
+// See Client.SetTimeout.
+func (r *Request) SetTimeout(timeout time.Duration) *Request {
+	r.timeout = timeout
+	return r
+}
+
+// checkDeadline checks whether the request has the deadline if required,
+// which is set by the context or the timeout of the request.
+func (r *Request) checkDeadline(c context.Context) error {
+	if !r.reqdeadline || r.timeout > 0 {
+		return nil
+	} else if _, ok := c.Deadline(); !ok {
+		return ErrNoDeadline
+	}
+	return nil
+}
+
+// withDeadline returns a new context with the default deadline
+// if c has no deadline.
+func (r *Request) withDeadline(c context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := c.Deadline(); ok || r.deadline <= 0 {
+		return c, nil
+	}
+	return context.WithTimeout(c, r.deadline)
+}
+
+// releaseDeadline wraps the error caused by the default deadline,
+// and releases the context when the response body is closed.
+func (r *Request) releaseDeadline(c context.Context, cancel context.CancelFunc, resp *Response) {
+	if resp.err != nil && c.Err() == context.DeadlineExceeded {
+		resp.err = DefaultDeadlineError{Deadline: r.deadline, Err: resp.err}
+	}
+	releaseContext(cancel, resp)
+}
+
+// releaseContext releases the context when the response body is closed,
+// or at once if no response body.
+func releaseContext(cancel context.CancelFunc, resp *Response) {
+	if resp.resp == nil || resp.resp.Body == nil {
+		cancel()
+	} else {
+		resp.resp.Body = &cancelBody{ReadCloser: resp.resp.Body, cancel: cancel}
+	}
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// DeadlineFormat is the format of the header value to propagate the deadline.
+type DeadlineFormat int
+
+// Pre-define some deadline formats.
+const (
+	// DeadlineRFC3339 formats the absolute deadline in UTC as RFC 3339
+	// with the millisecond precision, such as "2024-01-02T15:04:05.123Z".
+	DeadlineRFC3339 DeadlineFormat = iota
+
+	// DeadlineMilliseconds formats the remaining time in milliseconds,
+	// which is rounded down and at least 1, such as "1500".
+	DeadlineMilliseconds
+
+	// DeadlineSeconds formats the remaining time in seconds,
+	// which is rounded down and at least 1, such as "2".
+	DeadlineSeconds
+)
+
+const rfc3339Milli = "2006-01-02T15:04:05.000Z07:00"
+
+func formatDeadline(deadline, now time.Time, format DeadlineFormat) string {
+	if format == DeadlineRFC3339 {
+		return deadline.UTC().Format(rfc3339Milli)
+	}
+
+	unit := time.Millisecond
+	if format == DeadlineSeconds {
+		unit = time.Second
+	}
+
+	n := int64(deadline.Sub(now) / unit)
+	if n < 1 {
+		n = 1
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// NewDeadlinePropagationHook returns a new request hook to propagate
+// the deadline of the request context to the server by the header,
+// such as "X-Request-Deadline" with DeadlineRFC3339 or "X-Timeout-Ms"
+// with DeadlineMilliseconds, so the server can shed the doomed work.
+//
+// The remaining time is computed when building the request to be sent,
+// and the header is not set if the request context has no deadline.
+func NewDeadlinePropagationHook(header string, format DeadlineFormat) Hook {
+	if header == "" {
+		panic("NewDeadlinePropagationHook: the header must not be empty")
+	}
+	switch format {
+	case DeadlineRFC3339, DeadlineMilliseconds, DeadlineSeconds:
+	default:
+		panic(fmt.Errorf("NewDeadlinePropagationHook: unknown deadline format %d", format))
+	}
+
+	return HookFunc(func(r *http.Request) *http.Request {
+		deadline, ok := r.Context().Deadline()
+		if !ok {
+			return r
+		}
+
+		cloneHeaderForWrite(r).Set(header, formatDeadline(deadline, time.Now(), format))
+		return r
+	})
+}
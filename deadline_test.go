@@ -0,0 +1,209 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientDefaultDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(200 * time.Millisecond):
+			case <-r.Context().Done():
+			}
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).SetDefaultDeadline(50 * time.Millisecond)
+
+	// The context without the deadline.
+	err := client.Get(server.URL+"/slow").Do(context.Background(), nil).Unwrap()
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %T", err)
+	} else if _, ok := e.Err.(DefaultDeadlineError); !ok {
+		t.Errorf("expect a DefaultDeadlineError, but got %T", e.Err)
+	} else if !strings.Contains(err.Error(), "default deadline 50ms") {
+		t.Errorf("expect the error to contain the default deadline, but got '%s'", err.Error())
+	}
+
+	// The context with the deadline takes precedence.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Get(server.URL+"/slow").Do(ctx, nil).Unwrap(); err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = client.Get(server.URL+"/slow").Do(ctx, nil).Unwrap()
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if strings.Contains(err.Error(), "default deadline") {
+		t.Errorf("unexpected the default deadline error: %s", err.Error())
+	}
+
+	var body string
+	err = client.Get(server.URL).Do(context.Background(), func(resp *http.Response) error {
+		data, err := ioutil.ReadAll(resp.Body)
+		body = string(data)
+		return err
+	}).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if body != "ok" {
+		t.Errorf("expect body '%s', but got '%s'", "ok", body)
+	}
+}
+
+func TestClientRequireDeadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		SetDefaultDeadline(time.Second).RequireDeadline(true)
+
+	err := client.Get(server.URL).Do(context.Background(), nil).Unwrap()
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if e, ok := err.(Error); !ok || e.Err != ErrNoDeadline {
+		t.Errorf("expect ErrNoDeadline, but got %v", err)
+	} else if !strings.Contains(err.Error(), "Client.RequireDeadline") {
+		t.Errorf("expect the error to contain the option, but got '%s'", err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Get(server.URL).Do(ctx, nil).Unwrap(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil).SetTimeout(time.Second).RequireDeadline(true)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Errorf("expect the context to have the deadline")
+		}
+
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+			return nil, r.Context().Err()
+		}
+
+		return &http.Response{
+			StatusCode: 204,
+			Header:     http.Header{},
+			Body:       http.NoBody,
+			Request:    r,
+		}, nil
+	}))
+
+	if err := client.Get("http://localhost/slow").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Error(err)
+	}
+
+	err := client.Get("http://localhost/slow").SetTimeout(50*time.Millisecond).
+		Do(context.Background(), nil).Unwrap()
+	if err == nil {
+		t.Fatal("expect an error, but got nil")
+	}
+
+	e, ok := err.(Error)
+	if !ok {
+		t.Fatalf("expect an Error, but got %T", err)
+	} else if e.Err != context.DeadlineExceeded {
+		t.Errorf("expect the error '%v', but got '%v'", context.DeadlineExceeded, e.Err)
+	}
+
+	if e.Method != http.MethodGet {
+		t.Errorf("expect method '%s', but got '%s'", http.MethodGet, e.Method)
+	}
+	if e.URL != "http://localhost/slow" {
+		t.Errorf("expect url '%s', but got '%s'", "http://localhost/slow", e.URL)
+	}
+}
+
+func TestFormatDeadline(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		Deadline time.Time
+		Format   DeadlineFormat
+		Expect   string
+	}{
+		{now.Add(1500*time.Millisecond + 999*time.Microsecond), DeadlineMilliseconds, "1500"},
+		{now.Add(999 * time.Microsecond), DeadlineMilliseconds, "1"},
+		{now.Add(-time.Second), DeadlineMilliseconds, "1"},
+		{now.Add(2999 * time.Millisecond), DeadlineSeconds, "2"},
+		{now.Add(500 * time.Millisecond), DeadlineSeconds, "1"},
+		{now.Add(123456 * time.Microsecond), DeadlineRFC3339, "2024-01-02T15:04:05.123Z"},
+		{now.In(time.FixedZone("CST", 8*3600)), DeadlineRFC3339, "2024-01-02T15:04:05.000Z"},
+	}
+
+	for i, test := range tests {
+		if s := formatDeadline(test.Deadline, now, test.Format); s != test.Expect {
+			t.Errorf("%d: expect '%s', but got '%s'", i, test.Expect, s)
+		}
+	}
+}
+
+func TestDeadlinePropagationHook(t *testing.T) {
+	var timeout string
+	client := NewClient(nil).OnResponse(nil).SetHeader("X-Test", "test").
+		AddHook(NewDeadlinePropagationHook("X-Timeout-Ms", DeadlineMilliseconds))
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		timeout = r.Header.Get("X-Timeout-Ms")
+		return &http.Response{StatusCode: 204, Header: http.Header{}, Body: http.NoBody}, nil
+	}))
+
+	if err := client.Get("http://127.0.0.1").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Error(err)
+	} else if timeout != "" {
+		t.Errorf("expect no timeout header, but got '%s'", timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Get("http://127.0.0.1").Do(ctx, nil).Unwrap(); err != nil {
+		t.Error(err)
+	} else if n, err := strconv.Atoi(timeout); err != nil || n <= 900 || n >= 1000 {
+		t.Errorf("expect the timeout in (900, 1000), but got '%s'", timeout)
+	}
+
+	if _, ok := client.header["X-Timeout-Ms"]; ok {
+		t.Errorf("the client header is modified: %v", client.header)
+	}
+
+	// The default deadline is also propagated.
+	client.SetDefaultDeadline(time.Minute)
+	if err := client.Get("http://127.0.0.1").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Error(err)
+	} else if n, err := strconv.Atoi(timeout); err != nil || n <= 59000 || n > 60000 {
+		t.Errorf("expect the timeout in (59000, 60000], but got '%s'", timeout)
+	}
+}
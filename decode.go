@@ -0,0 +1,114 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var (
+	declock  sync.RWMutex
+	decoders = make(map[string]Decoder, 4)
+)
+
+// RegisterDecoder registers the decoder of the response body
+// for the content type, such as "text/csv", which is used by
+// DecodeFromReader and will override the existed one.
+func RegisterDecoder(contentType string, decoder Decoder) {
+	if decoder == nil {
+		panic("RegisterDecoder: the decoder must not be nil")
+	}
+
+	declock.Lock()
+	decoders[contentType] = decoder
+	declock.Unlock()
+}
+
+func getDecoder(contentType string) Decoder {
+	declock.RLock()
+	decoder := decoders[contentType]
+	declock.RUnlock()
+	return decoder
+}
+
+func hasDecoder(contentType string) bool {
+	switch contentType {
+	case MIMEApplicationJSON, MIMEApplicationXML:
+		return true
+	default:
+		return getDecoder(contentType) != nil
+	}
+}
+
+type acceptFallbackKey struct{}
+
+// EnableAcceptFallback sets whether to decode the response body
+// by the request header "Accept" when the response Content-Type
+// is ambiguous, such as "application/octet-stream" or missing.
+//
+// If enabled, DecodeResponseBody picks the first accepted content type
+// in order which has a decoder, such as the built-in json and xml decoders,
+// or the decoder registered by RegisterDecoder.
+//
+// Default: false
+func (c *Client) EnableAcceptFallback(enable bool) *Client {
+	c.fallback = enable
+	return c
+}
+
+// EnableAcceptFallback overrides the setting of the client.
+//
+// See Client.EnableAcceptFallback.
+func (r *Request) EnableAcceptFallback(enable bool) *Request {
+	r.fallback = enable
+	return r
+}
+
+func isAmbiguousContentType(ct string) bool {
+	switch ct {
+	case "", "application/octet-stream", "binary/octet-stream":
+		return true
+	default:
+		return false
+	}
+}
+
+// negotiateContentType returns the content type to decode the response body.
+func negotiateContentType(resp *http.Response) string {
+	ct := GetContentType(resp.Header)
+	if !isAmbiguousContentType(ct) || resp.Request == nil {
+		return ct
+	}
+
+	if enabled, _ := resp.Request.Context().Value(acceptFallbackKey{}).(bool); !enabled {
+		return ct
+	}
+
+	for _, accepts := range resp.Request.Header[HeaderAccept] {
+		for _, accept := range strings.Split(accepts, ",") {
+			if index := strings.IndexByte(accept, ';'); index > -1 {
+				accept = accept[:index]
+			}
+
+			if accept = strings.TrimSpace(accept); hasDecoder(accept) {
+				return accept
+			}
+		}
+	}
+
+	return ct
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAcceptFallback(t *testing.T) {
+	RegisterDecoder("text/csv", func(dst interface{}, ct string, r io.Reader) (err error) {
+		records, ok := dst.(*[][]string)
+		if !ok {
+			return fmt.Errorf("unsupported to decode csv into %T", dst)
+		}
+		*records, err = csv.NewReader(r).ReadAll()
+		return
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+			_, _ = w.Write([]byte(`[["a","b"]]`))
+		case "/binary-json":
+			w.Header().Set(HeaderContentType, "application/octet-stream")
+			_, _ = w.Write([]byte(`[["a","b"]]`))
+		case "/binary-csv":
+			w.Header().Set(HeaderContentType, "application/octet-stream")
+			_, _ = w.Write([]byte("a,b\n"))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	do := func(req *Request) (records [][]string, err error) {
+		err = req.Do(context.Background(), &records).Unwrap()
+		return
+	}
+
+	// Disabled
+	if _, err := do(client.Get(server.URL + "/binary-json").SetAccepts(MIMEApplicationJSON)); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+
+	client.EnableAcceptFallback(true)
+
+	// The exact response Content-Type wins.
+	if records, err := do(client.Get(server.URL + "/json").SetAccepts("text/csv")); err != nil {
+		t.Error(err)
+	} else if len(records) != 1 || len(records[0]) != 2 {
+		t.Errorf("unexpected records: %v", records)
+	}
+
+	// In the order of Accept.
+	req := client.Get(server.URL+"/binary-json").SetAccepts("text/html", "application/json;q=0.9, text/csv")
+	if records, err := do(req); err != nil {
+		t.Error(err)
+	} else if len(records) != 1 || records[0][0] != "a" {
+		t.Errorf("unexpected records: %v", records)
+	}
+
+	req = client.Get(server.URL+"/binary-csv").SetAccepts("text/csv", MIMEApplicationJSON)
+	if records, err := do(req); err != nil {
+		t.Error(err)
+	} else if len(records) != 1 || records[0][1] != "b" {
+		t.Errorf("unexpected records: %v", records)
+	}
+
+	// Request override
+	req = client.Get(server.URL + "/binary-csv").SetAccepts("text/csv").EnableAcceptFallback(false)
+	if _, err := do(req); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
@@ -16,7 +16,9 @@ package httpclient
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 )
 
 // DefaultClient is the default global client.
@@ -46,6 +48,28 @@ func Delete(url string) *Request { return DefaultClient.Delete(url) }
 // Options is equal to DefaultClient.Options(url).
 func Options(url string) *Request { return DefaultClient.Options(url) }
 
+// Allowed is equal to DefaultClient.Allowed(ctx, url).
+func Allowed(ctx context.Context, url string) ([]string, error) {
+	return DefaultClient.Allowed(ctx, url)
+}
+
+// HeadContext is equal to DefaultClient.HeadContext(ctx, url).
+func HeadContext(ctx context.Context, url string) (http.Header, error) {
+	return DefaultClient.HeadContext(ctx, url)
+}
+
+// Download is equal to DefaultClient.Download(ctx, url, destPath, options...).
+func Download(ctx context.Context, url, destPath string, options ...DownloadOption) error {
+	return DefaultClient.Download(ctx, url, destPath, options...)
+}
+
+// UploadFile is equal to
+// DefaultClient.UploadFile(ctx, url, fieldName, filePath, extraFields, result).
+func UploadFile(ctx context.Context, url, fieldName, filePath string,
+	extraFields map[string]string, result interface{}) error {
+	return DefaultClient.UploadFile(ctx, url, fieldName, filePath, extraFields, result)
+}
+
 // GetJSON is a convenient function to get the JSON data from the remote server.
 func GetJSON(url string, respBody interface{}) error {
 	return GetJSONContext(context.Background(), url, respBody)
@@ -99,3 +123,31 @@ func DeleteJSONContext(c context.Context, url string, respBody interface{}, reqB
 func requestJSON(c context.Context, req *Request, respBody interface{}, reqBody interface{}) error {
 	return req.SetBody(reqBody).Do(c, respBody).Unwrap()
 }
+
+// GetText is a convenient function to get the response body as string
+// from the remote server regardless of the content type.
+func GetText(c context.Context, url string) (string, error) {
+	data, err := GetBytes(c, url)
+	return string(data), err
+}
+
+// GetBytes is a convenient function to get the response body as []byte
+// from the remote server regardless of the content type, which fails with
+// ResponseBodyTooLargeError if the body exceeds the maximum size
+// set by SetMaxResponseBodySize of DefaultClient.
+func GetBytes(c context.Context, url string) (data []byte, err error) {
+	err = Get(url).Do(c, func(resp *http.Response) (err error) {
+		if resp.StatusCode >= 300 {
+			return ReadResponseBodyAsError(nil, resp)
+		}
+		data, err = ioutil.ReadAll(resp.Body)
+		return
+	}).Unwrap()
+	return
+}
+
+// PostForm is a convenient function to send the form data with the method POST,
+// and decode the response body into respBody.
+func PostForm(c context.Context, url string, form url.Values, respBody interface{}) error {
+	return Post(url).SetContentType(MIMEApplicationForm).SetBody(form).Do(c, respBody).Unwrap()
+}
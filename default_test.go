@@ -0,0 +1,74 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestGetTextAndPostForm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/text":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<p>text</p>"))
+
+		case "/form":
+			if ct := r.Header.Get(HeaderContentType); ct != MIMEApplicationForm {
+				w.WriteHeader(400)
+				return
+			}
+			w.Header().Set("Content-Type", MIMEApplicationJSON)
+			_ = json.NewEncoder(w).Encode(map[string]string{"name": r.FormValue("name")})
+
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+
+	if text, err := GetText(context.Background(), server.URL+"/text"); err != nil {
+		t.Error(err)
+	} else if text != "<p>text</p>" {
+		t.Errorf("unexpected text '%s'", text)
+	}
+
+	// Respect the maximum size of the response body.
+	DefaultClient.SetMaxResponseBodySize(5)
+	_, err := GetBytes(context.Background(), server.URL+"/text")
+	DefaultClient.SetMaxResponseBodySize(0)
+	if _, ok := err.(Error).Err.(ResponseBodyTooLargeError); !ok {
+		t.Errorf("expect ResponseBodyTooLargeError, but got %v", err)
+	}
+
+	if _, err := GetBytes(context.Background(), server.URL+"/notfound"); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if code := err.(Error).StatusCode(); code != 404 {
+		t.Errorf("expect status code 404, but got %d", code)
+	}
+
+	var result map[string]string
+	form := url.Values{"name": []string{"xgfone"}}
+	if err := PostForm(context.Background(), server.URL+"/form", form, &result); err != nil {
+		t.Error(err)
+	} else if result["name"] != "xgfone" {
+		t.Errorf("unexpected result %v", result)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pre-define the headers to announce the deprecation of the endpoint.
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+)
+
+// deprecations is the set of the urls whose deprecation has been reported.
+var deprecations = deprecationSet{urls: make(map[string]struct{})}
+
+type deprecationSet struct {
+	lock sync.Mutex
+	urls map[string]struct{}
+}
+
+// Add adds the url into the set, and reports whether it is added newly.
+func (s *deprecationSet) Add(url string) (added bool) {
+	s.lock.Lock()
+	if _, ok := s.urls[url]; !ok {
+		s.urls[url] = struct{}{}
+		added = true
+	}
+	s.lock.Unlock()
+	return
+}
+
+// Deprecation parses the response header "Deprecation" and reports
+// whether the endpoint is deprecated.
+//
+// It supports the structured date like "@1688169599" (RFC 9745),
+// the HTTP-date, and the boolean form "true", for which the returned
+// time is zero as the deprecation date is unknown.
+func (r *Response) Deprecation() (t time.Time, ok bool) {
+	if r.resp == nil {
+		return
+	}
+
+	v := strings.TrimSpace(r.resp.Header.Get(HeaderDeprecation))
+	switch {
+	case v == "":
+	case strings.EqualFold(v, "true"):
+		ok = true
+	case v[0] == '@':
+		if n, err := strconv.ParseInt(v[1:], 10, 64); err == nil {
+			t, ok = time.Unix(n, 0).UTC(), true
+		}
+	default:
+		var err error
+		t, err = http.ParseTime(v)
+		ok = err == nil
+	}
+	return
+}
+
+// Sunset parses the response header "Sunset" (RFC 8594), which is the time
+// when the endpoint is expected to become unresponsive, and reports
+// whether it exists and is valid.
+func (r *Response) Sunset() (time.Time, bool) { return r.headerTime(HeaderSunset) }
+
+// OnDeprecation sets the callback function called when the response
+// has the header "Deprecation" or "Sunset", which is called only once
+// per method and url without the query in the process.
+//
+// If nil, disable it.
+//
+// Default: log a warning
+func (c *Client) OnDeprecation(f func(*Response)) *Client {
+	c.ondeprecation = f
+	return c
+}
+
+// checkDeprecation calls the deprecation callback if the response
+// announces the deprecation for the first time.
+func (r *Request) checkDeprecation(resp *Response) {
+	if r.ondeprecation == nil || resp.resp == nil || resp.resp.Request == nil {
+		return
+	}
+
+	header := resp.resp.Header
+	if header.Get(HeaderDeprecation) == "" && header.Get(HeaderSunset) == "" {
+		return
+	}
+
+	u := *resp.resp.Request.URL
+	u.User, u.RawQuery, u.ForceQuery, u.Fragment = nil, "", false, ""
+	if deprecations.Add(resp.resp.Request.Method + " " + u.String()) {
+		r.ondeprecation(resp)
+	}
+}
+
+func logDeprecation(resp *Response) {
+	msg := "the http endpoint is deprecated"
+	if t, ok := resp.Deprecation(); ok && !t.IsZero() {
+		msg += " since " + t.Format(time.RFC3339)
+	}
+	if t, ok := resp.Sunset(); ok {
+		msg += " and will be removed at " + t.Format(time.RFC3339)
+	}
+	logRequestWarning(resp.resp.Request, msg, nil)
+}
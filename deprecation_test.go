@@ -0,0 +1,99 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResponseDeprecation(t *testing.T) {
+	date := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		Value  string
+		Time   time.Time
+		Exists bool
+	}{
+		{Value: "", Exists: false},
+		{Value: "true", Exists: true},
+		{Value: "TRUE", Exists: true},
+		{Value: "Mon, 01 Jul 2024 00:00:00 GMT", Time: date, Exists: true},
+		{Value: "@1719792000", Time: date, Exists: true},
+		{Value: "@abc", Exists: false},
+		{Value: "false", Exists: false},
+	}
+
+	for _, test := range tests {
+		header := http.Header{}
+		if test.Value != "" {
+			header.Set(HeaderDeprecation, test.Value)
+		}
+
+		resp := NewTestResponse(ResponseOptions{StatusCode: 200, Header: header})
+		if v, ok := resp.Deprecation(); ok != test.Exists {
+			t.Errorf("%q: expect exists %v, but got %v", test.Value, test.Exists, ok)
+		} else if !v.Equal(test.Time) {
+			t.Errorf("%q: expect time %s, but got %s", test.Value, test.Time, v)
+		}
+	}
+
+	header := http.Header{HeaderSunset: []string{"Mon, 01 Jul 2024 00:00:00 GMT"}}
+	resp := NewTestResponse(ResponseOptions{StatusCode: 200, Header: header})
+	if v, ok := resp.Sunset(); !ok || !v.Equal(date) {
+		t.Errorf("expect sunset %s, but got %s", date, v)
+	}
+
+	if _, ok := NewTestResponse(ResponseOptions{}).Sunset(); ok {
+		t.Errorf("expect no sunset")
+	}
+}
+
+func TestClientOnDeprecation(t *testing.T) {
+	var calls []string
+	client := NewClient(nil).OnResponse(nil).OnDeprecation(func(r *Response) {
+		calls = append(calls, r.Method()+" "+r.Response().Request.URL.Path)
+	})
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		switch r.URL.Path {
+		case "/deprecation/v1":
+			header.Set(HeaderDeprecation, "true")
+		case "/deprecation/v2":
+			header.Set(HeaderSunset, "Mon, 01 Jul 2024 00:00:00 GMT")
+		}
+		return &http.Response{StatusCode: 204, Header: header, Body: http.NoBody, Request: r}, nil
+	}))
+
+	for _, path := range []string{"/deprecation/v1?a=1", "/deprecation/v1?a=2", "/deprecation/v2", "/deprecation/v3"} {
+		if err := client.Get("http://localhost"+path).Do(context.Background(), nil).Unwrap(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.Delete("http://localhost/deprecation/v1").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	expects := []string{"GET /deprecation/v1", "GET /deprecation/v2", "DELETE /deprecation/v1"}
+	if len(calls) != len(expects) {
+		t.Fatalf("expect calls %v, but got %v", expects, calls)
+	}
+	for i, call := range calls {
+		if call != expects[i] {
+			t.Errorf("%d: expect call '%s', but got '%s'", i, expects[i], call)
+		}
+	}
+}
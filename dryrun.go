@@ -0,0 +1,138 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// RedactedHeaders is the list of the request headers whose values
+// will be redacted when rendering the request for humans, such as DryRun.
+var RedactedHeaders = []string{
+	HeaderAuthorization,
+	"Proxy-Authorization",
+	"Cookie",
+}
+
+// RedactedValue is the value to replace the redacted header values.
+const RedactedValue = "***"
+
+// MaxBodyPreviewSize is the maximum size of the body preview of RequestSummary.
+var MaxBodyPreviewSize = 1024
+
+func redactHeader(header http.Header) http.Header {
+	header = cloneHeader(header)
+	for _, key := range RedactedHeaders {
+		key = http.CanonicalHeaderKey(key)
+		if vs, ok := header[key]; ok {
+			values := make([]string, len(vs))
+			for i := range values {
+				values[i] = RedactedValue
+			}
+			header[key] = values
+		}
+	}
+	return header
+}
+
+// RequestSummary is the summary of the request that would be sent.
+type RequestSummary struct {
+	Method string
+	URL    string
+	Header http.Header // A redacted copy of the request header
+
+	// BodyLength is the length of the request body, which is -1 if unknown.
+	BodyLength int64
+
+	// BodyPreview is the first MaxBodyPreviewSize bytes of the request body.
+	BodyPreview   string
+	BodyTruncated bool
+}
+
+// DryRun builds the request like Do, but does not send it,
+// and returns the summary of the request that would be sent.
+//
+// Notice: the streaming body, which is an io.Reader except for *bytes.Buffer,
+// won't be read, so its length is -1 and there is no body preview.
+// But a fresh body will be got from the BodyProvider for the preview.
+func (r *Request) DryRun(c context.Context) (*RequestSummary, error) {
+	var streaming bool
+	switch r.reqbody.(type) {
+	case nil, *bytes.Buffer:
+	default:
+		streaming = true
+	}
+
+	var req *http.Request
+	var err error
+	if streaming {
+		// Build the request like Build, but without the streaming body.
+		if err = r.prepare(c); err == nil {
+			req, err = r.build(c, nil)
+		}
+		if err != nil {
+			return nil, r.newError(err)
+		}
+	} else if req, err = r.Build(c); err != nil {
+		return nil, err
+	}
+
+	summary := &RequestSummary{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Header:     redactHeader(req.Header),
+		BodyLength: req.ContentLength,
+	}
+
+	switch {
+	case streaming:
+		summary.BodyLength = -1
+
+	case req.Body != nil && req.Body != http.NoBody:
+		if summary.BodyLength == 0 {
+			summary.BodyLength = -1
+		}
+		summary.BodyPreview, summary.BodyTruncated = previewBody(req.Body)
+	}
+
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	return summary, nil
+}
+
+func (r *Request) newError(err error) Error {
+	return Error{
+		Method: r.method,
+		URL:    redactRawURL(r.redactor, r.url),
+		Err:    err,
+		Kind:   classifyError(err, KindBuild),
+	}
+}
+
+func previewBody(r io.Reader) (preview string, truncated bool) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	n, _ := io.CopyN(buf, r, int64(MaxBodyPreviewSize)+1)
+	if truncated = n > int64(MaxBodyPreviewSize); truncated {
+		buf.Truncate(MaxBodyPreviewSize)
+	}
+	return buf.String(), truncated
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestDryRun(t *testing.T) {
+	client := NewClient(nil).SetBaseURL("http://127.0.0.1/base").
+		SetHeader(HeaderAuthorization, "Bearer token").
+		AddQuery("k1", "v1")
+
+	req := client.Post("/path").
+		AddQuery("k2", "v2").
+		AddHook(hookAddQuery("k3", "v3")).
+		SetBody(map[string]string{"name": "xgfone"})
+
+	summary, err := req.DryRun(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Method != "POST" {
+		t.Errorf("expect method '%s', but got '%s'", "POST", summary.Method)
+	}
+
+	expect := "http://127.0.0.1/base/path?k1=v1&k2=v2&k3=v3"
+	if summary.URL != expect {
+		t.Errorf("expect url '%s', but got '%s'", expect, summary.URL)
+	}
+
+	if v := summary.Header.Get(HeaderAuthorization); v != RedactedValue {
+		t.Errorf("expect redacted Authorization, but got '%s'", v)
+	}
+	if v := client.header.Get(HeaderAuthorization); v != "Bearer token" {
+		t.Errorf("the client header is modified: %s", v)
+	}
+
+	body := `{"name":"xgfone"}` + "\n"
+	if summary.BodyLength != int64(len(body)) {
+		t.Errorf("expect body length %d, but got %d", len(body), summary.BodyLength)
+	}
+	if summary.BodyPreview != body || summary.BodyTruncated {
+		t.Errorf("unexpected body preview '%s'", summary.BodyPreview)
+	}
+
+	// The request can still be built again.
+	if summary2, err := req.DryRun(context.Background()); err != nil {
+		t.Error(err)
+	} else if summary2.BodyPreview != body {
+		t.Errorf("unexpected body preview '%s'", summary2.BodyPreview)
+	}
+
+	summary, err = client.Put("/path").SetBody(strings.NewReader("abc")).DryRun(context.Background())
+	if err != nil {
+		t.Error(err)
+	} else if summary.BodyLength != -1 || summary.BodyPreview != "" {
+		t.Errorf("unexpected streaming body summary: %+v", summary)
+	}
+
+	if _, err := NewClient(nil).Get("/path").DryRun(context.Background()); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
+
+func TestRequestDryRunCheck(t *testing.T) {
+	// The same checks as Do and Build.
+	client := NewClient(nil).StrictMethodBody(true)
+	if _, err := client.Get("http://127.0.0.1").SetBody("body").DryRun(context.Background()); err == nil {
+		t.Errorf("expect a method body error, but got nil")
+	}
+
+	client = NewClient(nil).RequireDeadline(true)
+	if _, err := client.Get("http://127.0.0.1").DryRun(context.Background()); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if e, ok := err.(Error); !ok || e.Err != ErrNoDeadline {
+		t.Errorf("expect ErrNoDeadline, but got %v", err)
+	}
+
+	req := client.Get("http://127.0.0.1").SetTimeout(time.Second)
+	if _, err := req.DryRun(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := client.Get("http://127.0.0.1").DryRun(ctx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := client.Get("http://127.0.0.1").Build(context.Background()); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HeaderContentEncoding is the header "Content-Encoding".
+const HeaderContentEncoding = "Content-Encoding"
+
+// Decompressor is used to decompress the response body
+// encoded by a content coding, such as gzip.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+var (
+	dlock         sync.RWMutex
+	decompressors = map[string]Decompressor{
+		"gzip":    func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		"x-gzip":  func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		"deflate": func(r io.Reader) (io.ReadCloser, error) { return zlib.NewReader(r) },
+	}
+)
+
+// RegisterDecompressor registers the decompressor for the content coding,
+// such as "br" or "zstd", which will override the existed one.
+//
+// The built-in content codings are "gzip", "x-gzip" and "deflate".
+func RegisterDecompressor(coding string, d Decompressor) {
+	if d == nil {
+		panic("RegisterDecompressor: the decompressor must not be nil")
+	}
+
+	dlock.Lock()
+	decompressors[strings.ToLower(coding)] = d
+	dlock.Unlock()
+}
+
+// GetDecompressor returns the decompressor of the content coding.
+//
+// Return nil if the content coding has not been registered.
+func GetDecompressor(coding string) Decompressor {
+	dlock.RLock()
+	d := decompressors[strings.ToLower(coding)]
+	dlock.RUnlock()
+	return d
+}
+
+// AcceptEncoding is a content coding with the quality value
+// of the header "Accept-Encoding".
+type AcceptEncoding struct {
+	Coding  string
+	Quality float64 // If 0, it is equal to 1.
+}
+
+func (e AcceptEncoding) String() string {
+	if e.Quality <= 0 || e.Quality >= 1 {
+		return e.Coding
+	}
+	return e.Coding + ";q=" + strconv.FormatFloat(e.Quality, 'f', -1, 64)
+}
+
+// SetAcceptEncoding sets the header "Accept-Encoding" with the content codings
+// in the order of preference, which is equal to SetWeightedAcceptEncoding
+// with the equal quality values.
+//
+// See SetWeightedAcceptEncoding.
+func (r *Request) SetAcceptEncoding(codings ...string) *Request {
+	encodings := make([]AcceptEncoding, len(codings))
+	for i, coding := range codings {
+		encodings[i] = AcceptEncoding{Coding: coding}
+	}
+	return r.SetWeightedAcceptEncoding(encodings...)
+}
+
+// SetWeightedAcceptEncoding sets the header "Accept-Encoding"
+// with the content codings and their quality values,
+// such as "zstd, br;q=0.8, gzip;q=0.5, identity;q=0.1".
+//
+// Because setting the header "Accept-Encoding" disables the transparent
+// gzip decompression of http.Transport, the response body is decompressed
+// by the decompressor of the response content coding instead.
+// So each content coding except "identity" and "*" must have been registered
+// by RegisterDecompressor, or the request fails to be sent.
+func (r *Request) SetWeightedAcceptEncoding(encodings ...AcceptEncoding) *Request {
+	if len(encodings) == 0 {
+		return r
+	}
+
+	values := make([]string, len(encodings))
+	for i, e := range encodings {
+		if e.Quality < 0 || e.Quality > 1 {
+			r.err = fmt.Errorf("invalid quality value %v of the content coding '%s'", e.Quality, e.Coding)
+			return r
+		}
+
+		switch coding := strings.ToLower(e.Coding); coding {
+		case "identity", "*":
+		default:
+			if GetDecompressor(coding) == nil {
+				r.err = fmt.Errorf("no decompressor for the content coding '%s'", e.Coding)
+				return r
+			}
+		}
+
+		values[i] = e.String()
+	}
+
+	r.decompress = true
+	return r.SetHeader(HeaderAcceptEncoding, strings.Join(values, ", "))
+}
+
+// decompressResponse decompresses the response body by the decompressor
+// of the response content coding.
+func decompressResponse(resp *http.Response) (err error) {
+	coding := strings.ToLower(strings.TrimSpace(resp.Header.Get(HeaderContentEncoding)))
+	if coding == "" || coding == "identity" || !hasResponseBody(resp) {
+		return
+	}
+
+	decompress := GetDecompressor(coding)
+	if decompress == nil {
+		return fmt.Errorf("no decompressor for the content coding '%s'", coding)
+	}
+
+	reader, err := decompress(resp.Body)
+	if err != nil {
+		return
+	}
+
+	resp.Body = &decompressedBody{ReadCloser: reader, body: resp.Body}
+	resp.Header.Del(HeaderContentEncoding)
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return
+}
+
+// hasResponseBody reports whether the response may have the body,
+// which is false for the empty body, the response to HEAD, or 204 and 304.
+func hasResponseBody(resp *http.Response) bool {
+	switch {
+	case resp.Body == nil, resp.Body == http.NoBody, resp.ContentLength == 0:
+		return false
+	case resp.StatusCode == http.StatusNoContent, resp.StatusCode == http.StatusNotModified:
+		return false
+	case resp.Request != nil && resp.Request.Method == http.MethodHead:
+		return false
+	default:
+		return true
+	}
+}
+
+type decompressedBody struct {
+	io.ReadCloser
+	body io.ReadCloser
+}
+
+func (b *decompressedBody) Close() error {
+	_ = b.ReadCloser.Close()
+	return b.body.Close()
+}
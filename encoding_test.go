@@ -0,0 +1,125 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestSetAcceptEncoding(t *testing.T) {
+	RegisterDecompressor("x-upper", func(r io.Reader) (io.ReadCloser, error) {
+		data, err := ioutil.ReadAll(r)
+		return ioutil.NopCloser(strings.NewReader(strings.ToLower(string(data)))), err
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Accept-Encoding", r.Header.Get(HeaderAcceptEncoding))
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		switch {
+		case strings.HasPrefix(r.Header.Get(HeaderAcceptEncoding), "gzip"):
+			w.Header().Set(HeaderContentEncoding, "gzip")
+			gw := gzip.NewWriter(w)
+			_, _ = gw.Write([]byte(`"abc"`))
+			_ = gw.Close()
+
+		case strings.HasPrefix(r.Header.Get(HeaderAcceptEncoding), "x-upper"):
+			w.Header().Set(HeaderContentEncoding, "x-upper")
+			_, _ = w.Write([]byte(`"ABC"`))
+
+		default:
+			_, _ = w.Write([]byte(`"abc"`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+
+	var result string
+	resp := client.Get(server.URL).
+		SetWeightedAcceptEncoding(
+			AcceptEncoding{Coding: "gzip"},
+			AcceptEncoding{Coding: "deflate", Quality: 0.5},
+			AcceptEncoding{Coding: "identity", Quality: 0.1},
+		).
+		Do(context.Background(), &result)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if result != "abc" {
+		t.Errorf("expect result '%s', but got '%s'", "abc", result)
+	}
+
+	const expect = "gzip, deflate;q=0.5, identity;q=0.1"
+	if v := resp.Response().Header.Get("X-Accept-Encoding"); v != expect {
+		t.Errorf("expect Accept-Encoding '%s', but got '%s'", expect, v)
+	}
+
+	result = ""
+	err := client.Get(server.URL).SetAcceptEncoding("x-upper", "gzip").Do(context.Background(), &result).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if result != "abc" {
+		t.Errorf("expect result '%s', but got '%s'", "abc", result)
+	}
+
+	err = client.Get(server.URL).SetAcceptEncoding("unknown").Do(context.Background(), nil).Unwrap()
+	if err == nil || !strings.Contains(err.Error(), "no decompressor") {
+		t.Errorf("expect a decompressor error, but got %v", err)
+	}
+
+	err = client.Get(server.URL).SetWeightedAcceptEncoding(AcceptEncoding{Coding: "gzip", Quality: 2}).
+		Do(context.Background(), nil).Unwrap()
+	if err == nil || !strings.Contains(err.Error(), "invalid quality") {
+		t.Errorf("expect a quality error, but got %v", err)
+	}
+}
+
+func TestDecompressEmptyResponse(t *testing.T) {
+	var code int
+	var length int64
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		header := http.Header{HeaderContentEncoding: {"gzip"}}
+		body := ioutil.NopCloser(strings.NewReader(""))
+		return &http.Response{StatusCode: code, Header: header, Body: body, ContentLength: length, Request: r}, nil
+	}))
+
+	tests := []struct {
+		Method string
+		Code   int
+		Length int64
+	}{
+		{Method: http.MethodHead, Code: 200, Length: -1},
+		{Method: http.MethodGet, Code: 204, Length: -1},
+		{Method: http.MethodGet, Code: 304, Length: -1},
+		{Method: http.MethodGet, Code: 200, Length: 0},
+	}
+
+	client.SetResponseHandler(func(interface{}, *http.Response) error { return nil })
+	for _, test := range tests {
+		code, length = test.Code, test.Length
+		err := client.Request(test.Method, "http://127.0.0.1").SetAcceptEncoding("gzip").
+			Do(context.Background(), nil).Unwrap()
+		if err != nil {
+			t.Errorf("%s %d: unexpected error: %v", test.Method, test.Code, err)
+		}
+	}
+}
@@ -14,7 +14,11 @@
 
 package httpclient
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
 
 // Error represents an response error from the http client.
 type Error struct {
@@ -23,11 +27,38 @@ type Error struct {
 	URL    string `json:"url" xml:"url"`
 	Data   string `json:"data" xml:"data"`
 	Err    error  `json:"err" xml:"err"`
+
+	// Phase is the phase in progress when the request fails.
+	Phase FailurePhase `json:"phase,omitempty" xml:"phase,omitempty"`
+
+	// RequestID is the request id assigned by the server. See ServerRequestIDHeaders.
+	RequestID string `json:"requestid,omitempty" xml:"requestid,omitempty"`
+
+	// Kind is the kind of the failure, such as KindTimeout or KindStatus.
+	Kind ErrorKind `json:"kind,omitempty" xml:"kind,omitempty"`
+
+	// DataReduced reports whether Data is reduced from the response body,
+	// such as the title and the first heading of the html error page.
+	//
+	// RawData is the original response body if DataReduced is true,
+	// which is truncated to MaxRawErrorDataSize.
+	DataReduced bool   `json:"datareduced,omitempty" xml:"datareduced,omitempty"`
+	RawData     string `json:"rawdata,omitempty" xml:"rawdata,omitempty"`
 }
 
-// NewError returns a new Error.
+// NewError returns a new Error, whose kind is classified by err,
+// or is KindStatus if code is set but err is not classified.
+//
+// The url will be redacted by DefaultURLRedactor.
 func NewError(code int, method, url string, err error) Error {
-	return Error{Code: code, Method: method, URL: url, Err: err}
+	kind := KindUnknown
+	if code > 0 {
+		kind = KindStatus
+	}
+	if err != nil {
+		kind = classifyError(err, kind)
+	}
+	return Error{Code: code, Method: method, URL: redactURL(url), Err: err, Kind: kind}
 }
 
 func (e Error) Unwrap() error { return e.Err }
@@ -41,6 +72,9 @@ func (e Error) String() string {
 	var data string
 	if e.Data != "" {
 		data = ", data=" + e.Data
+		if e.DataReduced {
+			data += " (reduced from html)"
+		}
 	}
 
 	var code string
@@ -48,7 +82,17 @@ func (e Error) String() string {
 		code = fmt.Sprintf(", code=%d", e.Code)
 	}
 
-	return fmt.Sprintf("method=%s, url=%s%s%s%s", e.Method, e.URL, code, data, err)
+	var phase string
+	if e.Phase != "" {
+		phase = ", phase=" + string(e.Phase)
+	}
+
+	var reqid string
+	if e.RequestID != "" {
+		reqid = ", requestid=" + e.RequestID
+	}
+
+	return fmt.Sprintf("method=%s, url=%s%s%s%s%s%s", e.Method, e.URL, code, reqid, phase, data, err)
 }
 
 // StatusCode returns the status code.
@@ -62,3 +106,78 @@ func (e Error) WithData(data string) Error { e.Data = data; return e }
 
 // WithErr returns the new Error with the given error.
 func (e Error) WithErr(err error) Error { e.Err = err; return e }
+
+// BodyReadError is the error when reading the response body,
+// such as the connection is closed halfway.
+type BodyReadError struct {
+	Read int64 // The number of the bytes read so far
+	Err  error
+}
+
+func (e BodyReadError) Unwrap() error { return e.Err }
+func (e BodyReadError) Error() string {
+	return fmt.Sprintf("failed to read the response body after %d bytes: %v", e.Read, e.Err)
+}
+
+// DecodeError is the error when decoding the response body,
+// such as the syntax error.
+type DecodeError struct {
+	ContentType string
+	Err         error
+}
+
+func (e DecodeError) Unwrap() error { return e.Err }
+func (e DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode the response body of '%s': %v", e.ContentType, e.Err)
+}
+
+// IsRetryable reports whether the request failed with err can be retried.
+//
+// Now, only the idempotent request whose response body is truncated,
+// that's, BodyReadError, is regarded as retryable.
+func IsRetryable(err error) bool {
+	e, ok := err.(Error)
+	if !ok || !isIdempotentMethod(e.Method) {
+		return false
+	}
+
+	for err = e.Err; err != nil; {
+		if _, ok := err.(BodyReadError); ok {
+			return true
+		}
+
+		if u, ok := err.(interface{ Unwrap() error }); ok {
+			err = u.Unwrap()
+		} else {
+			break
+		}
+	}
+	return false
+}
+
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// readErrorBody wraps the error when reading the body into BodyReadError.
+type readErrorBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (b *readErrorBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF {
+		if _, ok := err.(BodyReadError); !ok {
+			err = BodyReadError{Read: b.read, Err: err}
+		}
+	}
+	return
+}
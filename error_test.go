@@ -0,0 +1,86 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBodyReadError(t *testing.T) {
+	const body = `{"name":"xgfone","data":"0123456789"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/invalid" {
+			w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+			_, _ = w.Write([]byte(`{"name":`))
+			return
+		}
+
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			panic(err)
+		}
+		defer conn.Close()
+
+		// Close the connection after sending the half of the body.
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n%s",
+			MIMEApplicationJSON, len(body), body[:len(body)/2])
+		_ = buf.Flush()
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+
+	var result map[string]string
+	resp := client.Get(server.URL).Do(context.Background(), &result)
+	err := resp.Unwrap()
+	if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %T", err)
+	} else if re, ok := e.Err.(BodyReadError); !ok {
+		t.Errorf("expect a BodyReadError, but got %T: %v", e.Err, e.Err)
+	} else if re.Read != int64(len(body)/2) {
+		t.Errorf("expect to read %d bytes, but got %d", len(body)/2, re.Read)
+	}
+
+	if !IsRetryable(err) {
+		t.Errorf("expect retryable, but got not")
+	}
+	if phase := resp.FailurePhase(); phase != PhaseReadingBody {
+		t.Errorf("expect phase '%s', but got '%s'", PhaseReadingBody, phase)
+	}
+
+	err = client.Post(server.URL).Do(context.Background(), &result).Unwrap()
+	if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %T", err)
+	} else if _, ok := e.Err.(BodyReadError); !ok {
+		t.Errorf("expect a BodyReadError, but got %T: %v", e.Err, e.Err)
+	} else if IsRetryable(err) {
+		t.Errorf("expect not retryable for POST, but got retryable")
+	}
+
+	err = client.Get(server.URL+"/invalid").Do(context.Background(), &result).Unwrap()
+	if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %T", err)
+	} else if de, ok := e.Err.(DecodeError); !ok {
+		t.Errorf("expect a DecodeError, but got %T: %v", e.Err, e.Err)
+	} else if de.ContentType != MIMEApplicationJSON {
+		t.Errorf("expect content type '%s', but got '%s'", MIMEApplicationJSON, de.ContentType)
+	} else if IsRetryable(err) {
+		t.Errorf("expect not retryable for the decode error, but got retryable")
+	}
+}
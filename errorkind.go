@@ -0,0 +1,140 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net"
+)
+
+// ErrorKind is the stable and machine-readable kind of the request failure.
+type ErrorKind string
+
+// Pre-define some error kinds.
+const (
+	// KindUnknown is the failure not classified, such as the error
+	// returned by the custom response handler.
+	KindUnknown ErrorKind = "unknown"
+
+	// KindBuild is the failure before sending the request, such as
+	// the invalid url, the body encoding error or the disallowed host.
+	KindBuild ErrorKind = "build"
+
+	// KindTransport is the failure when sending the request or reading
+	// the response body, such as the connection refused or reset.
+	KindTransport ErrorKind = "transport"
+
+	// KindTimeout is the failure for the timeout or the deadline.
+	KindTimeout ErrorKind = "timeout"
+
+	// KindCanceled is the failure for the canceled context.
+	KindCanceled ErrorKind = "canceled"
+
+	// KindClosed is the failure for the closed client. See ErrClientClosed.
+	KindClosed ErrorKind = "closed"
+
+	// KindDecode is the failure when decoding or decompressing
+	// the response body.
+	KindDecode ErrorKind = "decode"
+
+	// KindStatus is the failure for the unexpected response status code,
+	// such as 4xx and 5xx.
+	KindStatus ErrorKind = "status"
+
+	// KindInvalidResponse is the failure for the invalid response,
+	// such as the malformed response or the bad signature.
+	KindInvalidResponse ErrorKind = "invalid_response"
+)
+
+// Kind returns the kind of err, which walks the wrapped chain of err.
+// If err contains an Error, return its Kind, or classify err
+// by the errors in the chain.
+//
+// Return "" if err is nil.
+func Kind(err error) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	for e := err; e != nil; e = unwrapError(e) {
+		if _e, ok := e.(Error); ok && _e.Kind != "" {
+			return _e.Kind
+		}
+	}
+	return classifyError(err, KindUnknown)
+}
+
+func unwrapError(err error) error {
+	if u, ok := err.(interface{ Unwrap() error }); ok {
+		return u.Unwrap()
+	}
+	return nil
+}
+
+// classifyError classifies err by the errors in its wrapped chain,
+// or returns fallback if it is not classified.
+func classifyError(err error, fallback ErrorKind) ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	// The timeout and cancellation take precedence over the wrapping errors,
+	// such as the transport error caused by the canceled context.
+	for e := err; e != nil; e = unwrapError(e) {
+		switch e {
+		case context.Canceled:
+			return KindCanceled
+		case context.DeadlineExceeded:
+			return KindTimeout
+		case ErrClientClosed:
+			return KindClosed
+		}
+
+		switch v := e.(type) {
+		case DefaultDeadlineError:
+			return KindTimeout
+		case net.Error:
+			if v.Timeout() {
+				return KindTimeout
+			}
+		}
+	}
+
+	for e := err; e != nil; e = unwrapError(e) {
+		switch e {
+		case ErrNilHookRequest, ErrNoDeadline:
+			return KindBuild
+		case ErrBadSignature, ErrMissingSignature:
+			return KindInvalidResponse
+		}
+
+		switch v := e.(type) {
+		case Error:
+			if v.Kind != "" {
+				return v.Kind
+			}
+		case DuplicateHeaderError, HostNotAllowedError, RequestBodyTooLargeError:
+			return KindBuild
+		case DecodeError:
+			return KindDecode
+		case MalformedResponseError, ResponseBodyTooLargeError:
+			return KindInvalidResponse
+		case BodyReadError, UploadError, *RedirectError, net.Error:
+			return KindTransport
+		}
+	}
+
+	return fallback
+}
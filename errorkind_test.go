@@ -0,0 +1,129 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type wrappedError struct{ err error }
+
+func (e wrappedError) Unwrap() error { return e.err }
+func (e wrappedError) Error() string { return "wrapped: " + e.err.Error() }
+
+func TestErrorKind(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(time.Millisecond * 100)
+		case "/status":
+			w.WriteHeader(500)
+			return
+		case "/json":
+			w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+			_, _ = w.Write([]byte(`{"invalid`))
+			return
+		}
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	// Get a closed port to refuse the connection.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + ln.Addr().String()
+	ln.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	closed := NewClient(http.DefaultClient).OnResponse(nil)
+	_ = closed.Shutdown(context.Background())
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	for _, c := range []struct {
+		name   string
+		do     func() error
+		expect ErrorKind
+	}{
+		{"ok", func() error {
+			return client.Get(server.URL).Do(context.Background(), nil).Unwrap()
+		}, ""},
+		{"build-url", func() error {
+			return client.Get("/path").Do(context.Background(), nil).Unwrap()
+		}, KindBuild},
+		{"build-body", func() error {
+			return client.Post(server.URL).SetBody(make(chan int)).Do(context.Background(), nil).Unwrap()
+		}, KindBuild},
+		{"build-host", func() error {
+			return client.Clone().SetAllowedHosts("example.com").Get(server.URL).Do(context.Background(), nil).Unwrap()
+		}, KindBuild},
+		{"transport", func() error {
+			return client.Get(refused).Do(context.Background(), nil).Unwrap()
+		}, KindTransport},
+		{"timeout", func() error {
+			return client.Get(server.URL+"/slow").SetTimeout(time.Millisecond*10).Do(context.Background(), nil).Unwrap()
+		}, KindTimeout},
+		{"canceled", func() error {
+			return client.Get(server.URL).Do(canceled, nil).Unwrap()
+		}, KindCanceled},
+		{"closed", func() error {
+			return closed.Get(server.URL).Do(context.Background(), nil).Unwrap()
+		}, KindClosed},
+		{"decode", func() error {
+			var v interface{}
+			return client.Get(server.URL+"/json").Do(context.Background(), &v).Unwrap()
+		}, KindDecode},
+		{"status", func() error {
+			return client.Get(server.URL+"/status").Do(context.Background(), nil).Unwrap()
+		}, KindStatus},
+		{"unknown", func() error {
+			return client.Get(server.URL).Do(context.Background(), func(*http.Response) error {
+				return errors.New("test")
+			}).Unwrap()
+		}, KindUnknown},
+	} {
+		err := c.do()
+		if kind := Kind(err); kind != c.expect {
+			t.Errorf("%s: expect kind '%s', but got '%s': %v", c.name, c.expect, kind, err)
+		}
+
+		if e, ok := err.(Error); ok && e.Kind != c.expect {
+			t.Errorf("%s: expect Error.Kind '%s', but got '%s'", c.name, c.expect, e.Kind)
+		}
+
+		// Walk the wrapped chain.
+		if err != nil {
+			if kind := Kind(wrappedError{err}); kind != c.expect {
+				t.Errorf("%s: expect the wrapped kind '%s', but got '%s'", c.name, c.expect, kind)
+			}
+		}
+	}
+
+	if kind := Kind(NewError(502, http.MethodGet, server.URL, nil)); kind != KindStatus {
+		t.Errorf("expect kind '%s', but got '%s'", KindStatus, kind)
+	}
+	if kind := Kind(context.DeadlineExceeded); kind != KindTimeout {
+		t.Errorf("expect kind '%s', but got '%s'", KindTimeout, kind)
+	}
+}
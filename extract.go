@@ -0,0 +1,153 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ExtractJSONFields returns a result function to extract the fields
+// by the paths from the json response body into dst, which is used
+// as the result of Request.Do, and the extracted field is stored
+// into dst with the path as the key.
+//
+// The path is the dot-separated keys of the json objects or the indexes
+// of the json arrays, such as "data.items.0.id". Each extracted value is
+// decoded as interface{}, that's, the json number is float64,
+// the json object is map[string]interface{}, etc.
+//
+// A path that cannot be resolved, such as the absent key, the index out
+// of range or the key of a non-object value, is missing and is not stored
+// into dst. But the invalid path, which contains an empty key, is an error.
+//
+// The body is read only once, and each json object or array on the paths
+// is parsed only once even if shared by the paths, such as "data.a" and
+// "data.b". But the unrequested fields are kept as the raw json and are
+// never decoded. For the non-2xx response, it returns the error like
+// ReadResponseBodyAsError.
+func ExtractJSONFields(dst map[string]interface{}, paths ...string) func(*http.Response) error {
+	if dst == nil {
+		panic("ExtractJSONFields: the destination map must not be nil")
+	}
+
+	keys := make([][]string, len(paths))
+	for i, path := range paths {
+		keys[i] = strings.Split(path, ".")
+	}
+
+	return func(resp *http.Response) (err error) {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return ReadResponseBodyAsError(nil, resp)
+		}
+
+		for i, path := range paths {
+			for _, key := range keys[i] {
+				if key == "" {
+					return fmt.Errorf("invalid json path '%s'", path)
+				}
+			}
+		}
+
+		var root jsonNode
+		if err = DecodeFromReader(&root.raw, MIMEApplicationJSON, resp.Body); err != nil {
+			return
+		}
+
+		for i, path := range paths {
+			node, ok := root.lookup(keys[i])
+			if !ok {
+				continue
+			}
+
+			var value interface{}
+			if err = json.Unmarshal(node.raw, &value); err != nil {
+				return wrapDecodeError(MIMEApplicationJSON, err)
+			}
+			dst[path] = value
+		}
+
+		return
+	}
+}
+
+// jsonNode is the raw json value, whose children are parsed lazily
+// only once when looking up the path.
+type jsonNode struct {
+	raw    json.RawMessage
+	parsed bool
+	object map[string]*jsonNode
+	array  []*jsonNode
+}
+
+// lookup looks up the json value by the keys, and reports whether it exists.
+func (n *jsonNode) lookup(keys []string) (*jsonNode, bool) {
+	for _, key := range keys {
+		var ok bool
+		if n, ok = n.child(key); !ok {
+			return nil, false
+		}
+	}
+	return n, true
+}
+
+func (n *jsonNode) child(key string) (*jsonNode, bool) {
+	if !n.parsed {
+		n.parse()
+	}
+
+	switch {
+	case n.object != nil:
+		child, ok := n.object[key]
+		return child, ok
+
+	case n.array != nil:
+		index, err := strconv.ParseUint(key, 10, 0)
+		if err != nil || index >= uint64(len(n.array)) {
+			return nil, false
+		}
+		return n.array[index], true
+
+	default:
+		return nil, false
+	}
+}
+
+func (n *jsonNode) parse() {
+	n.parsed = true
+	switch data := bytes.TrimSpace(n.raw); {
+	case len(data) > 0 && data[0] == '{':
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) == nil {
+			n.object = make(map[string]*jsonNode, len(object))
+			for key, raw := range object {
+				n.object[key] = &jsonNode{raw: raw}
+			}
+		}
+
+	case len(data) > 0 && data[0] == '[':
+		var array []json.RawMessage
+		if json.Unmarshal(data, &array) == nil {
+			n.array = make([]*jsonNode, len(array))
+			for i, raw := range array {
+				n.array[i] = &jsonNode{raw: raw}
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExtractJSONFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(500)
+			return
+		}
+
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		_, _ = w.Write([]byte(`{
+			"code": 0,
+			"data": {
+				"total": 2,
+				"items": [{"id": "a", "tags": ["x"]}, {"id": "b", "extra": null}]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+
+	dst := make(map[string]interface{})
+	err := client.Get(server.URL).Do(context.Background(), ExtractJSONFields(dst,
+		"code", "data.total", "data.items.1.id", "data.items.0.tags",
+		"data.items.1.extra", "data.items.2.id", "data.items.x", "data.total.x",
+		"missing")).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]interface{}{
+		"code":               float64(0),
+		"data.total":         float64(2),
+		"data.items.1.id":    "b",
+		"data.items.0.tags":  []interface{}{"x"},
+		"data.items.1.extra": nil,
+	}
+	if !reflect.DeepEqual(expect, dst) {
+		t.Errorf("expect %v, but got %v", expect, dst)
+	}
+
+	err = client.Get(server.URL).Do(context.Background(), ExtractJSONFields(dst, "data..id")).Unwrap()
+	if err == nil {
+		t.Errorf("expect an error for the invalid path, but got nil")
+	}
+
+	err = client.Get(server.URL+"/error").Do(context.Background(), ExtractJSONFields(dst, "code")).Unwrap()
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if code := err.(Error).Code; code != 500 {
+		t.Errorf("expect status code %d, but got %d", 500, code)
+	}
+
+	raws := make(map[string]json.RawMessage)
+	if err = client.Get(server.URL).Do(context.Background(), raws).Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if len(raws) != 2 || string(raws["code"]) != "0" {
+		t.Errorf("unexpected raw messages: %v", raws)
+	}
+}
+
+func TestJSONNodeParseOnce(t *testing.T) {
+	root := jsonNode{raw: json.RawMessage(`{"data": {"a": 1, "b": [2]}}`)}
+	if _, ok := root.lookup([]string{"data", "a"}); !ok {
+		t.Fatal("expect the path 'data.a'")
+	}
+
+	data := root.object["data"]
+	if b, ok := root.lookup([]string{"data", "b", "0"}); !ok || string(b.raw) != "2" {
+		t.Errorf("expect the path 'data.b.0'")
+	} else if root.object["data"] != data || !data.parsed {
+		t.Errorf("expect the shared object 'data' to be parsed only once")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// SetFallback sets the fallback provider, which is called with the error
+// when the request fails, to provide the fallback value of the result,
+// such as the last known good value or a static default.
+//
+// If it returns true, the value is assigned into the result passed to Do,
+// which is converted by the JSON round-trip if the type is not assignable.
+// Then, the response is marked as Response.UsedFallback, and Unwrap
+// returns nil, but Result still returns the original error for logging.
+//
+// If failing to assign the value, the fallback is not used.
+func (r *Request) SetFallback(fallback func(err error) (interface{}, bool)) *Request {
+	r.fallbackf = fallback
+	return r
+}
+
+// UsedFallback reports whether the result is provided by the fallback
+// instead of the response.
+func (r *Response) UsedFallback() bool { return r.fellback }
+
+func (r *Request) tryFallback(resp *Response, result interface{}) {
+	if r.fallbackf == nil || resp.err == nil || resp.staled {
+		return
+	}
+
+	value, ok := r.fallbackf(resp.getError())
+	if !ok {
+		return
+	}
+
+	if _, ok := result.(func(*http.Response) error); !ok {
+		if assignResult(result, value) != nil {
+			return
+		}
+	}
+
+	resp.fellback = true
+}
+
+// assignResult assigns the value into the pointer dst.
+func assignResult(dst, value interface{}) (err error) {
+	if dst == nil || value == nil {
+		return
+	}
+
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Ptr || dv.IsNil() {
+		return fmt.Errorf("the result must be a non-nil pointer, but got %T", dst)
+	}
+
+	vv := reflect.ValueOf(value)
+	if elem := dv.Elem(); vv.Type().AssignableTo(elem.Type()) {
+		elem.Set(vv)
+		return
+	} else if vv.Kind() == reflect.Ptr && !vv.IsNil() && vv.Elem().Type().AssignableTo(elem.Type()) {
+		elem.Set(vv.Elem())
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, dst)
+	}
+	return
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRequestFallback(t *testing.T) {
+	errFailed := errors.New("failed")
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) { return nil, errFailed }))
+
+	type Flags struct {
+		Enabled bool   `json:"enabled"`
+		Name    string `json:"name"`
+	}
+
+	var gotErr error
+	fallback := func(value interface{}) func(error) (interface{}, bool) {
+		return func(err error) (interface{}, bool) {
+			gotErr = err
+			return value, value != nil
+		}
+	}
+
+	var flags Flags
+	resp := client.Get("http://127.0.0.1").SetFallback(fallback(Flags{Name: "default"})).
+		Do(context.Background(), &flags)
+	if err := resp.Unwrap(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if !resp.UsedFallback() {
+		t.Errorf("expect to use the fallback, but got not")
+	} else if flags.Name != "default" {
+		t.Errorf("expect name '%s', but got '%s'", "default", flags.Name)
+	}
+
+	if e, ok := resp.Result().(Error); !ok || e.Err != errFailed {
+		t.Errorf("expect the original error, but got %v", resp.Result())
+	} else if e, ok := gotErr.(Error); !ok || e.Err != errFailed {
+		t.Errorf("expect the fallback to get the original error, but got %v", gotErr)
+	}
+
+	// JSON round-trip
+	flags = Flags{}
+	value := map[string]interface{}{"enabled": true, "name": "map"}
+	resp = client.Get("http://127.0.0.1").SetFallback(fallback(value)).Do(context.Background(), &flags)
+	if err := resp.Unwrap(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if !flags.Enabled || flags.Name != "map" {
+		t.Errorf("unexpected flags: %+v", flags)
+	}
+
+	// No fallback value
+	resp = client.Get("http://127.0.0.1").SetFallback(fallback(nil)).Do(context.Background(), &flags)
+	if err := resp.Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if resp.UsedFallback() {
+		t.Errorf("unexpected to use the fallback")
+	}
+
+	// Unassignable value
+	resp = client.Get("http://127.0.0.1").SetFallback(fallback("abc")).Do(context.Background(), &flags)
+	if err := resp.Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if resp.UsedFallback() {
+		t.Errorf("unexpected to use the fallback")
+	}
+
+	// No error
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+	resp = client.Get("http://127.0.0.1").SetFallback(fallback(Flags{})).Do(context.Background(), &flags)
+	if err := resp.Unwrap(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if resp.UsedFallback() || flags.Name != "map" {
+		t.Errorf("unexpected to use the fallback")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/url"
+)
+
+type formPair struct {
+	Key   string
+	Value string
+}
+
+// Form is an ordered form, which encodes the keys and values
+// in the order of insertion instead of sorting them like url.Values,
+// so the encoded body is byte-stable for the signature.
+//
+// It can be used as the request body with the Content-Type
+// "application/x-www-form-urlencoded".
+type Form struct {
+	pairs []formPair
+}
+
+// NewForm returns a new ordered form.
+func NewForm() *Form { return &Form{} }
+
+// Add appends the value of the key.
+func (f *Form) Add(key, value string) *Form {
+	f.pairs = append(f.pairs, formPair{Key: key, Value: value})
+	return f
+}
+
+// Set replaces the values of the key with the value,
+// which keeps the position of the first value of the key.
+func (f *Form) Set(key, value string) *Form {
+	index := -1
+	pairs := f.pairs[:0]
+	for _, p := range f.pairs {
+		if p.Key != key {
+			pairs = append(pairs, p)
+		} else if index < 0 {
+			index = len(pairs)
+			pairs = append(pairs, formPair{Key: key, Value: value})
+		}
+	}
+
+	if f.pairs = pairs; index < 0 {
+		f.pairs = append(f.pairs, formPair{Key: key, Value: value})
+	}
+	return f
+}
+
+// Get returns the first value of the key.
+//
+// Return "" if the key does not exist.
+func (f *Form) Get(key string) string {
+	for _, p := range f.pairs {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// Del deletes all the values of the key.
+func (f *Form) Del(key string) *Form {
+	pairs := f.pairs[:0]
+	for _, p := range f.pairs {
+		if p.Key != key {
+			pairs = append(pairs, p)
+		}
+	}
+	f.pairs = pairs
+	return f
+}
+
+// Len returns the number of the key-value pairs.
+func (f *Form) Len() int { return len(f.pairs) }
+
+// Values converts the form to url.Values, which loses the order of the keys.
+func (f *Form) Values() url.Values {
+	values := make(url.Values, len(f.pairs))
+	for _, p := range f.pairs {
+		values[p.Key] = append(values[p.Key], p.Value)
+	}
+	return values
+}
+
+// Encode encodes the form into "URL encoded" form in the order of insertion.
+func (f *Form) Encode() string {
+	b := getBuffer()
+	defer putBuffer(b)
+
+	for i, p := range f.pairs {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(p.Key))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(p.Value))
+	}
+	return b.String()
+}
+
+// String is equal to Encode.
+func (f *Form) String() string { return f.Encode() }
+
+// MarshalForm implements the interface { MarshalForm() ([]byte, error) }.
+func (f *Form) MarshalForm() ([]byte, error) { return []byte(f.Encode()), nil }
@@ -0,0 +1,75 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"testing"
+)
+
+func TestForm(t *testing.T) {
+	form := NewForm().Add("z", "1").Add("a", "2 3").Add("z", "4").Add("m", "&")
+	if s := form.Encode(); s != "z=1&a=2+3&z=4&m=%26" {
+		t.Errorf("expect '%s', but got '%s'", "z=1&a=2+3&z=4&m=%26", s)
+	}
+
+	form.Set("z", "5").Set("b", "6")
+	if s := form.Encode(); s != "z=5&a=2+3&m=%26&b=6" {
+		t.Errorf("expect '%s', but got '%s'", "z=5&a=2+3&m=%26&b=6", s)
+	} else if v := form.Get("a"); v != "2 3" {
+		t.Errorf("expect '%s', but got '%s'", "2 3", v)
+	}
+
+	form.Del("a")
+	if n := form.Len(); n != 3 {
+		t.Errorf("expect %d pairs, but got %d", 3, n)
+	} else if vs := form.Values(); len(vs) != 3 || vs.Get("b") != "6" {
+		t.Errorf("unexpected values: %v", vs)
+	}
+}
+
+func TestFormBodyStable(t *testing.T) {
+	hash := func(body interface{}) (sum [sha256.Size]byte) {
+		summary, err := NewClient(http.DefaultClient).Post("http://127.0.0.1").
+			SetContentType(MIMEApplicationForm).SetBody(body).DryRun(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sha256.Sum256([]byte(summary.BodyPreview))
+	}
+
+	newForm := func() *Form {
+		return NewForm().Add("timestamp", "1700000000").Add("nonce", "abc").Add("action", "pay")
+	}
+	newMap := func() map[string]interface{} {
+		return map[string]interface{}{"timestamp": 1700000000, "nonce": "abc", "action": "pay"}
+	}
+
+	formsum, mapsum := hash(newForm()), hash(newMap())
+	for i := 0; i < 100; i++ {
+		if sum := hash(newForm()); sum != formsum {
+			t.Fatalf("the form body is not stable")
+		}
+		if sum := hash(newMap()); sum != mapsum {
+			t.Fatalf("the map body is not stable")
+		}
+	}
+
+	if expect := sha256.Sum256([]byte("timestamp=1700000000&nonce=abc&action=pay")); formsum != expect {
+		t.Errorf("the form body is not in the order of insertion")
+	}
+}
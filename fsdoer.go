@@ -0,0 +1,146 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.16
+// +build go1.16
+
+package httpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// FSDoerOptions is the options of the filesystem-backed doer.
+type FSDoerOptions struct {
+	// Key returns the path of the fixture file in the filesystem
+	// for the request, whose extension is used to infer Content-Type.
+	//
+	// Default: DefaultFSDoerKey
+	Key func(*http.Request) string
+
+	// DefaultContentType is used when failing to infer Content-Type
+	// from the extension of the fixture file.
+	//
+	// Default: "application/octet-stream"
+	DefaultContentType string
+}
+
+// FSDoerMeta is the sidecar metadata of the fixture file,
+// whose file has the same path as the fixture file but the extension
+// is replaced with ".meta.json".
+//
+// For example, the sidecar metadata of "GET/v1/users.json"
+// is "GET/v1/users.meta.json".
+type FSDoerMeta struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"headers"`
+}
+
+// DefaultFSDoerKey is the default key function of the filesystem-backed doer,
+// which maps the request to the fixture file "METHOD/PATH[?QUERY].json",
+// and the query is sorted by key.
+//
+// For example, "GET /v1/users?page=2" is mapped to "GET/v1/users?page=2.json",
+// and "GET /" is mapped to "GET/index.json".
+func DefaultFSDoerKey(req *http.Request) string {
+	p := strings.Trim(path.Clean("/"+req.URL.Path), "/")
+	if p == "" {
+		p = "index"
+	}
+
+	key := req.Method + "/" + p
+	if req.URL.RawQuery != "" {
+		key += "?" + req.URL.Query().Encode()
+	}
+	return key + ".json"
+}
+
+// NewFSDoer returns a new doer which serves the responses from
+// the fixture files in the filesystem, such as os.DirFS("testdata").
+//
+// Content-Type of the response is inferred from the extension
+// of the fixture file, and the status code and headers can be set
+// by the sidecar metadata file. See FSDoerMeta.
+//
+// If the fixture file does not exist, the response is 404.
+func NewFSDoer(fsys fs.FS, opts FSDoerOptions) Doer {
+	if fsys == nil {
+		panic("NewFSDoer: the filesystem must not be nil")
+	}
+
+	if opts.Key == nil {
+		opts.Key = DefaultFSDoerKey
+	}
+	if opts.DefaultContentType == "" {
+		opts.DefaultContentType = "application/octet-stream"
+	}
+
+	return DoFunc(func(req *http.Request) (*http.Response, error) {
+		return serveFS(fsys, opts, req)
+	})
+}
+
+func serveFS(fsys fs.FS, opts FSDoerOptions, req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	name := opts.Key(req)
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return newStaticResponse(req, http.StatusNotFound, make(http.Header), nil), nil
+		}
+		return nil, err
+	}
+
+	ext := path.Ext(name)
+	header := make(http.Header)
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		header.Set(HeaderContentType, ct)
+	} else {
+		header.Set(HeaderContentType, opts.DefaultContentType)
+	}
+
+	code := http.StatusOK
+	metaname := strings.TrimSuffix(name, ext) + ".meta.json"
+	if metadata, err := fs.ReadFile(fsys, metaname); err == nil {
+		var meta FSDoerMeta
+		if err := json.Unmarshal(metadata, &meta); err != nil {
+			return nil, fmt.Errorf("invalid fixture metadata '%s': %w", metaname, err)
+		}
+
+		if meta.StatusCode > 0 {
+			code = meta.StatusCode
+		}
+		for key, values := range meta.Header {
+			header[http.CanonicalHeaderKey(key)] = values
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return newStaticResponse(req, code, header, data), nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.16
+// +build go1.16
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSDoer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"GET/v1/users?page=2.json":      {Data: []byte(`[{"name":"a"}]`)},
+		"POST/v1/users.json":            {Data: []byte(`{"id":1}`)},
+		"POST/v1/users.meta.json":       {Data: []byte(`{"status":201,"headers":{"x-id":["1"]}}`)},
+		"GET/index.json":                {Data: []byte(`"index"`)},
+		"GET/v1/users/1.json":           {Data: []byte(`{"name":"a"}`)},
+		"GET/v1/users/1.meta.json":      {Data: []byte(`invalid`)},
+		"GET/v1/users?a=1&page=2.json":  {Data: []byte(`[]`)},
+		"GET/v1/users?a=1&page=3.json":  {Data: []byte(`[]`)},
+		"GET/v1/users?page=4.json":      {Data: []byte(`[]`)},
+		"GET/v1/users?page=4.meta.json": {Data: []byte(`{"status":500}`)},
+	}
+
+	client := NewClient(nil).OnResponse(nil).SetDoer(NewFSDoer(fsys, FSDoerOptions{}))
+	client.SetBaseURL("http://127.0.0.1")
+
+	var users []map[string]string
+	resp := client.Get("/v1/users?page=2").Do(context.Background(), &users)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if len(users) != 1 || users[0]["name"] != "a" {
+		t.Errorf("unexpected users: %v", users)
+	} else if ct := resp.ContentType(); ct != "application/json" {
+		t.Errorf("expect Content-Type '%s', but got '%s'", "application/json", ct)
+	}
+
+	var result map[string]int
+	resp = client.Post("/v1/users").SetBody(map[string]string{"name": "a"}).Do(context.Background(), &result)
+	if err := resp.Unwrap(); err != nil {
+		t.Error(err)
+	} else if code := resp.StatusCode(); code != 201 {
+		t.Errorf("expect status code %d, but got %d", 201, code)
+	} else if id := resp.Response().Header.Get("X-Id"); id != "1" {
+		t.Errorf("expect header X-Id '%s', but got '%s'", "1", id)
+	} else if result["id"] != 1 {
+		t.Errorf("unexpected result: %v", result)
+	}
+
+	var index string
+	if err := client.Get("/").Do(context.Background(), &index).Unwrap(); err != nil {
+		t.Error(err)
+	} else if index != "index" {
+		t.Errorf("expect '%s', but got '%s'", "index", index)
+	}
+
+	if err := client.Get("/v1/users?page=3&a=1").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Error(err)
+	}
+
+	if code := client.Get("/v1/users?page=4").Do(context.Background(), nil).StatusCode(); code != 500 {
+		t.Errorf("expect status code %d, but got %d", 500, code)
+	}
+
+	if code := client.Get("/v1/missing").Do(context.Background(), nil).StatusCode(); code != 404 {
+		t.Errorf("expect status code %d, but got %d", 404, code)
+	}
+
+	if err := client.Get("/v1/users/1").Do(context.Background(), nil).Unwrap(); err == nil {
+		t.Errorf("expect an error for the invalid metadata, but got nil")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var users []map[string]string
+			err := client.Get("/v1/users?page=2").Do(context.Background(), &users).Unwrap()
+			if err != nil {
+				t.Error(err)
+			} else if len(users) != 1 {
+				t.Errorf("unexpected users: %v", users)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDefaultFSDoerKey(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodDelete, "http://localhost/../a//b/?z=1&a=2", nil)
+	if key := DefaultFSDoerKey(req); key != "DELETE/a/b?a=2&z=1.json" {
+		t.Errorf("expect key '%s', but got '%s'", "DELETE/a/b?a=2&z=1.json", key)
+	}
+}
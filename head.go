@@ -0,0 +1,80 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// HeadInfo is the metadata of the resource returned by the HEAD request.
+type HeadInfo struct {
+	Header http.Header
+
+	ContentLength int64 // -1 if unknown
+	ContentType   string
+	LastModified  time.Time // Zero if missing or invalid
+	AcceptRanges  string
+	ETag          string
+}
+
+func newHeadInfo(resp *http.Response) *HeadInfo {
+	info := &HeadInfo{
+		Header:        resp.Header,
+		ContentLength: resp.ContentLength,
+		ContentType:   GetContentType(resp.Header),
+		AcceptRanges:  resp.Header.Get("Accept-Ranges"),
+		ETag:          resp.Header.Get("Etag"),
+	}
+
+	if v := resp.Header.Get("Last-Modified"); v != "" {
+		info.LastModified, _ = http.ParseTime(v)
+	}
+
+	return info
+}
+
+// head sends the HEAD request without the response handlers.
+func (c *Client) head(ctx context.Context, url string) (resp *http.Response, err error) {
+	err = c.Head(url).Do(ctx, func(r *http.Response) error {
+		if r.StatusCode >= 400 {
+			return ReadResponseBodyAsError(nil, r)
+		}
+		resp = r
+		return nil
+	}).Unwrap()
+	return
+}
+
+// HeadInfo sends a HEAD request to the url and returns the metadata
+// of the resource, which does not run the response handlers.
+func (c *Client) HeadInfo(ctx context.Context, url string) (*HeadInfo, error) {
+	resp, err := c.head(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return newHeadInfo(resp), nil
+}
+
+// HeadContext sends a HEAD request to the url and returns the response header,
+// which does not run the response handlers.
+func (c *Client) HeadContext(ctx context.Context, url string) (http.Header, error) {
+	resp, err := c.head(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Header, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientHeadInfo(t *testing.T) {
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/notfound" {
+			w.WriteHeader(404)
+			return
+		}
+
+		w.Header().Set("Content-Type", MIMEApplicationJSONCharsetUTF8)
+		w.Header().Set("Content-Length", "123")
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Etag", `"abc"`)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	info, err := client.HeadInfo(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.ContentLength != 123 {
+		t.Errorf("expect content length 123, but got %d", info.ContentLength)
+	}
+	if info.ContentType != MIMEApplicationJSON {
+		t.Errorf("expect content type '%s', but got '%s'", MIMEApplicationJSON, info.ContentType)
+	}
+	if !info.LastModified.Equal(lastModified) {
+		t.Errorf("expect last modified '%s', but got '%s'", lastModified, info.LastModified)
+	}
+	if info.AcceptRanges != "bytes" {
+		t.Errorf("expect accept ranges '%s', but got '%s'", "bytes", info.AcceptRanges)
+	}
+	if info.ETag != `"abc"` {
+		t.Errorf("expect etag '%s', but got '%s'", `"abc"`, info.ETag)
+	}
+
+	if header, err := client.HeadContext(context.Background(), server.URL); err != nil {
+		t.Error(err)
+	} else if v := header.Get("Accept-Ranges"); v != "bytes" {
+		t.Errorf("expect accept ranges '%s', but got '%s'", "bytes", v)
+	}
+
+	if _, err := client.HeadInfo(context.Background(), server.URL+"/notfound"); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if code := err.(Error).StatusCode(); code != 404 {
+		t.Errorf("expect status code 404, but got %d", code)
+	}
+}
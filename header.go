@@ -0,0 +1,143 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// HeaderAuditMode is the mode to audit the request headers before sending,
+// which checks whether there are the duplicate keys differing only in case.
+type HeaderAuditMode int
+
+// Pre-define some header audit modes.
+const (
+	HeaderAuditOff  HeaderAuditMode = iota // Don't audit the headers.
+	HeaderAuditLog                         // Log the duplicate header keys.
+	HeaderAuditFail                        // Fail the request with DuplicateHeaderError.
+)
+
+// DuplicateHeaderError is returned when the request headers contain
+// the duplicate keys differing only in case.
+type DuplicateHeaderError struct {
+	Keys []string
+}
+
+func (e DuplicateHeaderError) Error() string {
+	return fmt.Sprintf("duplicate header keys differing only in case: %s", strings.Join(e.Keys, ", "))
+}
+
+// PreserveHeaderCase sets whether to preserve the case of the header keys
+// set by the builder methods, such as AddHeader, SetHeader, AddHeaders
+// and AddHeaderMap, which is used for the servers requiring the exact casing.
+//
+// If false, the header keys are canonicalized
+// by textproto.CanonicalMIMEHeaderKey.
+//
+// Default: false
+func (c *Client) PreserveHeaderCase(preserve bool) *Client {
+	c.rawheader = preserve
+	return c
+}
+
+// PreserveHeaderCase overrides the setting of the client.
+//
+// See Client.PreserveHeaderCase.
+func (r *Request) PreserveHeaderCase(preserve bool) *Request {
+	r.rawheader = preserve
+	return r
+}
+
+// SetHeaderAudit sets the audit mode of the request headers,
+// which is checked after applying the hooks.
+//
+// Default: HeaderAuditOff
+func (c *Client) SetHeaderAudit(mode HeaderAuditMode) *Client {
+	c.audit = mode
+	return c
+}
+
+func headerKey(key string, raw bool) string {
+	if raw {
+		return key
+	}
+	return textproto.CanonicalMIMEHeaderKey(key)
+}
+
+// cloneHeaderForWrite replaces the header of the built http request
+// with a copy and returns it, which must be called before modifying
+// the header of the built request, such as in the hooks.
+//
+// The header of the built request is shared with the Request, and with
+// the default header of the Client if the request sets no header, to avoid
+// copying the header for each sending. So modifying it in place leaks into
+// the others and races with the concurrent requests.
+func cloneHeaderForWrite(req *http.Request) http.Header {
+	if req.Header == nil {
+		req.Header = make(http.Header, 4)
+	} else {
+		req.Header = cloneHeader(req.Header)
+	}
+	return req.Header
+}
+
+func addHeader(header http.Header, key, value string, raw bool) {
+	key = headerKey(key, raw)
+	header[key] = append(header[key], value)
+}
+
+func setHeader(header http.Header, key, value string, raw bool) {
+	header[headerKey(key, raw)] = []string{value}
+}
+
+// auditHeader checks whether the request headers contain the duplicate keys
+// differing only in case.
+func (r *Request) auditHeader(req *http.Request) error {
+	if r.audit == HeaderAuditOff {
+		return nil
+	}
+
+	var keys []string
+	seen := make(map[string]string, len(req.Header))
+	for key := range req.Header {
+		lower := strings.ToLower(key)
+		if k, ok := seen[lower]; !ok {
+			seen[lower] = key
+		} else {
+			if k != "" {
+				keys = append(keys, k)
+				seen[lower] = ""
+			}
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	sort.Strings(keys)
+	err := DuplicateHeaderError{Keys: keys}
+	if r.audit == HeaderAuditFail {
+		return err
+	}
+
+	logRequestWarning(req, "found the duplicate request header keys", err)
+	return nil
+}
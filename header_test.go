@@ -0,0 +1,111 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderCanonicalization(t *testing.T) {
+	var header http.Header
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		header = r.Header
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	client.AddHeaders(http.Header{"x-api-key": []string{"a"}})
+	client.AddHeaderMap(map[string]string{"x-trace-id": "1"})
+	err := client.Get("http://127.0.0.1").SetHeader("X-Api-Key", "b").AddHeader("x-trace-id", "2").
+		Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := header["x-api-key"]; ok {
+		t.Errorf("unexpected the non-canonical header key")
+	}
+	if vs := header["X-Api-Key"]; len(vs) != 1 || vs[0] != "b" {
+		t.Errorf("expect header values %v, but got %v", []string{"b"}, vs)
+	}
+	if vs := header["X-Trace-Id"]; len(vs) != 2 || vs[0] != "1" || vs[1] != "2" {
+		t.Errorf("expect header values %v, but got %v", []string{"1", "2"}, vs)
+	}
+
+	// Preserve the case of the header keys.
+	client = client.Clone().PreserveHeaderCase(true).SetHeaderAudit(HeaderAuditFail)
+	err = client.Get("http://127.0.0.1").AddHeader("x-api-key", "c").Do(context.Background(), nil).Unwrap()
+	if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %T", err)
+	} else if de, ok := e.Err.(DuplicateHeaderError); !ok {
+		t.Errorf("expect a DuplicateHeaderError, but got %T: %v", e.Err, e.Err)
+	} else if len(de.Keys) != 2 || de.Keys[0] != "X-Api-Key" || de.Keys[1] != "x-api-key" {
+		t.Errorf("unexpected duplicate header keys: %v", de.Keys)
+	}
+
+	err = client.Get("http://127.0.0.1").PreserveHeaderCase(false).AddHeader("x-api-key", "c").
+		Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if vs := header["X-Api-Key"]; len(vs) != 2 {
+		t.Errorf("expect 2 header values, but got %v", vs)
+	}
+
+	client.SetHeaderAudit(HeaderAuditLog)
+	err = client.Get("http://127.0.0.1").AddHeader("x-api-key", "c").Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if vs := header["x-api-key"]; len(vs) != 1 || vs[0] != "c" {
+		t.Errorf("expect header values %v, but got %v", []string{"c"}, vs)
+	}
+}
+
+func TestAcceptRemoval(t *testing.T) {
+	// The server honors Accept strictly.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accept := r.Header.Get(HeaderAccept); accept != "" && accept != "application/octet-stream" {
+			w.WriteHeader(406)
+			return
+		}
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).SetAccepts(MIMEApplicationJSON)
+	if err := client.Get(server.URL).Do(context.Background(), nil).Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if code := err.(Error).Code; code != 406 {
+		t.Errorf("expect status code %d, but got %d", 406, code)
+	}
+
+	if err := client.Get(server.URL).DelHeader(HeaderAccept).Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Errorf("DelHeader: unexpected error: %v", err)
+	}
+
+	if err := client.Get(server.URL).SetAccepts("*/*").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Errorf("SetAccepts: unexpected error: %v", err)
+	}
+
+	if err := client.Clone().ClearAccept().Get(server.URL).Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Errorf("ClearAccept: unexpected error: %v", err)
+	}
+
+	if accepts := client.Get(server.URL).header[HeaderAccept]; len(accepts) != 1 {
+		t.Errorf("expect the client to keep the default Accept, but got %v", accepts)
+	}
+}
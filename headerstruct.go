@@ -0,0 +1,78 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import "net/http"
+
+func encodeHeaderStruct(header http.Header, v interface{}, raw bool) ([]string, error) {
+	return encodeStruct(header, v, structTags{
+		kind:  "header",
+		tags:  []string{"header"},
+		canon: func(key string) string { return headerKey(key, raw) },
+	})
+}
+
+// SetHeaderStruct encodes the struct v into the default headers,
+// which replaces the existing values of the same keys.
+//
+// See Request.SetHeaderStruct for the encoding rules.
+// But it panics if failing to encode v.
+func (c *Client) SetHeaderStruct(v interface{}) *Client {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	header := make(http.Header, 8)
+	keys, err := encodeHeaderStruct(header, v, c.rawheader)
+	if err != nil {
+		panic("Client.SetHeaderStruct: " + err.Error())
+	}
+
+	for _, key := range keys {
+		c.header[key] = header[key]
+	}
+	return c
+}
+
+// SetHeaderStruct encodes the struct v into the request headers,
+// which replaces the existing values of the same keys, including
+// the ones inherited from the client, such as Content-Type.
+// So it should be called before SetBody to select the body encoder
+// by the Content-Type from v.
+//
+// The field is named by the tag "header", such as `header:"X-Trace-Id"`,
+// which is the field name by default, and canonicalized like SetHeader.
+// The other rules are the same as SetQueryStruct, such as the option
+// "omitempty", the tag "layout" and the supported field types.
+// And the element of the slice or array is encoded as a value
+// of the repeated header.
+//
+// If failing to encode v, such as the unsupported field type,
+// the error is stored and returned when calling Do.
+func (r *Request) SetHeaderStruct(v interface{}) *Request {
+	header := make(http.Header, 8)
+	keys, err := encodeHeaderStruct(header, v, r.rawheader)
+	if err != nil {
+		r.err = err
+		return r
+	} else if len(keys) == 0 {
+		return r
+	}
+
+	r.cloneHeader()
+	for _, key := range keys {
+		r.header[key] = header[key]
+	}
+	return r
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type tenantHeaders struct {
+	ContentType string    `header:"content-type"`
+	Tenant      string    `header:"x-tenant-id"`
+	TraceID     string    `header:"X-Trace-Id,omitempty"`
+	Locales     []string  `header:"accept-language"`
+	Retries     int       `header:"x-retries"`
+	Debug       *bool     `header:"x-debug"`
+	Since       time.Time `header:"if-modified-since,omitempty" layout:"Mon, 02 Jan 2006 15:04:05 GMT"`
+	Ignored     string    `header:"-"`
+}
+
+func TestRequestSetHeaderStruct(t *testing.T) {
+	var header http.Header
+	var body string
+	client := NewClient(nil).OnResponse(nil).SetHeader("X-Tenant-Id", "default")
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		header, body = r.Header, string(data)
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	headers := tenantHeaders{
+		ContentType: MIMEApplicationForm,
+		Tenant:      "tenant",
+		Locales:     []string{"en-US", "zh-CN"},
+		Since:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Ignored:     "ignored",
+	}
+
+	// The Content-Type from the struct selects the form encoder.
+	err := client.Post("http://127.0.0.1").SetHeaderStruct(headers).
+		SetBody(url.Values{"k": []string{"v"}}).Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body != "k=v" {
+		t.Errorf("expect the form body '%s', but got '%s'", "k=v", body)
+	}
+
+	expects := map[string][]string{
+		"Content-Type":      {MIMEApplicationForm},
+		"X-Tenant-Id":       {"tenant"},
+		"Accept-Language":   {"en-US", "zh-CN"},
+		"X-Retries":         {"0"},
+		"If-Modified-Since": {"Tue, 02 Jan 2024 03:04:05 GMT"},
+	}
+	for key, values := range expects {
+		if !reflect.DeepEqual(header[key], values) {
+			t.Errorf("%s: expect %v, but got %v", key, values, header[key])
+		}
+	}
+	for _, key := range []string{"X-Trace-Id", "X-Debug", "Ignored", "content-type"} {
+		if values, ok := header[key]; ok {
+			t.Errorf("unexpect the header '%s': %v", key, values)
+		}
+	}
+
+	// The default headers of the client are untouched.
+	if ct := client.header.Get(HeaderContentType); ct != MIMEApplicationJSONCharsetUTF8 {
+		t.Errorf("expect the client Content-Type '%s', but got '%s'", MIMEApplicationJSONCharsetUTF8, ct)
+	}
+	if tenant := client.header.Get("X-Tenant-Id"); tenant != "default" {
+		t.Errorf("expect the client tenant '%s', but got '%s'", "default", tenant)
+	}
+
+	// The nil pointer is ignored.
+	req := client.Get("http://127.0.0.1").SetHeaderStruct((*tenantHeaders)(nil))
+	if req.err != nil {
+		t.Error(req.err)
+	} else if !req.hclone {
+		t.Errorf("expect not to clone the header")
+	}
+}
+
+func TestSetHeaderStructError(t *testing.T) {
+	type duplicate struct {
+		A string `header:"x-key"`
+		B string `header:"X-Key"`
+	}
+
+	client := NewClient(nil)
+	for _, v := range []interface{}{"string", duplicate{}, struct{ M map[string]string }{}} {
+		if req := client.Get("http://127.0.0.1").SetHeaderStruct(v); req.err == nil {
+			t.Errorf("%T: expect an error, but got nil", v)
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect a panic, but got nil")
+			}
+		}()
+		client.SetHeaderStruct(duplicate{})
+	}()
+
+	client.SetHeaderStruct(struct {
+		Trace string `header:"x-trace-id"`
+	}{Trace: "abc"})
+	if trace := client.header.Get("X-Trace-Id"); trace != "abc" {
+		t.Errorf("expect the client header '%s', but got '%s'", "abc", trace)
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+// SetHost sets the default Host of the requests, which is sent as the header
+// "Host" instead of the host of the request url, such as the virtual host
+// of the service accessed by the ip address or through a local proxy.
+//
+// Notice: the header "Host" set by SetHeader is ignored by net/http,
+// so use SetHost instead. And it does not change the server name of TLS.
+//
+// If host is empty, use the host of the request url.
+//
+// Default: ""
+func (c *Client) SetHost(host string) *Client {
+	c.host = host
+	return c
+}
+
+// SetHost overrides the Host of the client, which is set to the built
+// http.Request before applying the hooks, so the hooks see and may change it.
+//
+// See Client.SetHost.
+func (r *Request) SetHost(host string) *Request {
+	r.host = host
+	return r
+}
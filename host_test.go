@@ -0,0 +1,71 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSetHost(t *testing.T) {
+	var req *http.Request
+	var hookHost string
+	client := NewClient(nil).OnResponse(nil).SetHost("api.example.com").
+		SetHook(HookFunc(func(r *http.Request) *http.Request {
+			hookHost = r.Host
+			return r
+		}))
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		req = r
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	do := func(r *Request) {
+		if err := r.Do(context.Background(), nil).Unwrap(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	do(client.Get("http://127.0.0.1:8080/path"))
+	if req.Host != "api.example.com" {
+		t.Errorf("expect the host '%s', but got '%s'", "api.example.com", req.Host)
+	}
+	if req.URL.Host != "127.0.0.1:8080" {
+		t.Errorf("expect the url host '%s', but got '%s'", "127.0.0.1:8080", req.URL.Host)
+	}
+	if hookHost != "api.example.com" {
+		t.Errorf("expect the hook to see the host '%s', but got '%s'", "api.example.com", hookHost)
+	}
+
+	do(client.Get("http://127.0.0.1:8080/path").SetHost("other.example.com"))
+	if req.Host != "other.example.com" {
+		t.Errorf("expect the host '%s', but got '%s'", "other.example.com", req.Host)
+	}
+
+	// The empty host uses the host of the url.
+	do(client.Get("http://127.0.0.1:8080/path").SetHost(""))
+	if req.Host != "127.0.0.1:8080" {
+		t.Errorf("expect the host '%s', but got '%s'", "127.0.0.1:8080", req.Host)
+	}
+
+	cmd, err := client.Get("http://127.0.0.1:8080/path").Curl(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(cmd, `-H 'Host: api.example.com'`) {
+		t.Errorf("expect the curl command to contain the host header, but got: %s", cmd)
+	}
+}
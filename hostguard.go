@@ -0,0 +1,219 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HostResolver is used to resolve the host to the ip addresses,
+// which is implemented by *net.Resolver.
+type HostResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// HostNotAllowedError is the error when the destination host of the request
+// is not allowed, which is returned before any connection.
+type HostNotAllowedError struct {
+	Host string
+	IP   net.IP // The private ip that the host is or resolves to
+}
+
+func (e HostNotAllowedError) Error() string {
+	if e.IP == nil {
+		return fmt.Sprintf("the host '%s' is not allowed", e.Host)
+	}
+	return fmt.Sprintf("the host '%s' is not allowed for the private ip '%s'", e.Host, e.IP.String())
+}
+
+// SetAllowedHosts resets the allowlist of the destination hosts,
+// which is used to guard against SSRF when the url is built from
+// the user input.
+//
+// The pattern is either the exact host, such as "api.example.com"
+// or "10.0.0.1", or the wildcard host, such as "*.example.com",
+// which matches the subdomains but not "example.com" itself.
+// The pattern and the host are compared case-insensitively without the port.
+//
+// The disallowed request fails with HostNotAllowedError before any connection.
+// If the doer is *http.Client, the redirects are also checked per hop.
+// But it is not for the other doers, which should check the redirects
+// by themselves.
+//
+// If patterns is empty, clear the allowlist and allow all the hosts.
+//
+// Default: empty
+func (c *Client) SetAllowedHosts(patterns ...string) *Client {
+	hosts := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = normalizeHost(pattern); pattern != "" {
+			hosts = append(hosts, pattern)
+		}
+	}
+
+	return c.updateHostGuard(func(g *hostGuard) { g.hosts = hosts })
+}
+
+// BlockPrivateHosts sets whether to block the destination hosts which are
+// the loopback, private (RFC 1918 and RFC 4193), link-local or unspecified ip
+// literals, or the hostnames resolving to them.
+//
+// Notice: the resolution is checked before the connection, so it does not
+// defend against DNS rebinding, which needs a dialer checking the ip
+// addresses connected.
+//
+// Default: false
+func (c *Client) BlockPrivateHosts(block bool) *Client {
+	return c.updateHostGuard(func(g *hostGuard) { g.private = block })
+}
+
+// SetHostResolver sets the resolver to resolve the hostnames
+// for BlockPrivateHosts.
+//
+// If resolver is nil, use net.DefaultResolver.
+//
+// Default: nil
+func (c *Client) SetHostResolver(resolver HostResolver) *Client {
+	return c.updateHostGuard(func(g *hostGuard) { g.resolver = resolver })
+}
+
+func (c *Client) updateHostGuard(update func(*hostGuard)) *Client {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Copy on write since the guard is shared by the clones and requests.
+	var guard hostGuard
+	if c.hostguard != nil {
+		guard = *c.hostguard
+	}
+	update(&guard)
+	c.hostguard = &guard
+	c.updateChain()
+	return c
+}
+
+type hostGuard struct {
+	hosts    []string
+	private  bool
+	resolver HostResolver
+}
+
+func (g *hostGuard) enabled() bool { return g != nil && (len(g.hosts) > 0 || g.private) }
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+func (g *hostGuard) allowed(host string) bool {
+	if len(g.hosts) == 0 {
+		return true
+	}
+
+	for _, pattern := range g.hosts {
+		if pattern == host {
+			return true
+		} else if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *hostGuard) check(c context.Context, u *url.URL) error {
+	host := normalizeHost(u.Hostname())
+	if !g.allowed(host) {
+		return HostNotAllowedError{Host: host}
+	} else if !g.private {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if isPrivateIP(ip) {
+			return HostNotAllowedError{Host: host, IP: ip}
+		}
+		return nil
+	}
+
+	resolver := g.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs, err := resolver.LookupIPAddr(c, host)
+	if err != nil {
+		return err
+	}
+
+	for _, addr := range addrs {
+		if isPrivateIP(addr.IP) {
+			return HostNotAllowedError{Host: host, IP: addr.IP}
+		}
+	}
+	return nil
+}
+
+var privateIPNets = []*net.IPNet{
+	mustParseCIDR("10.0.0.0/8"),
+	mustParseCIDR("172.16.0.0/12"),
+	mustParseCIDR("192.168.0.0/16"),
+	mustParseCIDR("fc00::/7"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipnet
+}
+
+func isPrivateIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+
+	for _, ipnet := range privateIPNets {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// guardRedirect returns a copy of the http client, which checks
+// the destination host of each redirect hop by the guard.
+func (g *hostGuard) guardRedirect(client *http.Client) *http.Client {
+	checkRedirect := client.CheckRedirect
+	guarded := *client
+	guarded.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := g.check(req.Context(), req.URL); err != nil {
+			return err
+		}
+
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		} else if len(via) >= 10 { // The same as the default policy of http.Client
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &guarded
+}
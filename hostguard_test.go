@@ -0,0 +1,154 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testHostResolver map[string]string
+
+func (r testHostResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if ip, ok := r[host]; ok {
+		return []net.IPAddr{{IP: net.ParseIP(ip)}}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestClientSetAllowedHosts(t *testing.T) {
+	var sent int
+	client := NewClient(nil).OnResponse(nil).SetAllowedHosts("api.example.com", "*.Example.ORG")
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	for _, c := range []struct {
+		url     string
+		allowed bool
+	}{
+		{"http://api.example.com/path", true},
+		{"http://API.example.com:8080/path", true},
+		{"http://www.example.com/path", false},
+		{"http://example.org/path", false},
+		{"http://a.example.org/path", true},
+		{"http://a.b.example.org/path", true},
+		{"http://a.example.org.evil.com/path", false},
+		{"http://127.0.0.1/path", false},
+	} {
+		sent = 0
+		err := client.Get(c.url).Do(context.Background(), nil).Unwrap()
+		if c.allowed {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", c.url, err)
+			} else if sent != 1 {
+				t.Errorf("%s: expect the request to be sent", c.url)
+			}
+		} else {
+			if err == nil {
+				t.Errorf("%s: expect an error, but got nil", c.url)
+			} else if _, ok := err.(Error).Err.(HostNotAllowedError); !ok {
+				t.Errorf("%s: expect HostNotAllowedError, but got %T", c.url, err.(Error).Err)
+			} else if sent != 0 {
+				t.Errorf("%s: expect the request not to be sent", c.url)
+			}
+		}
+	}
+
+	sent = 0
+	client.SetAllowedHosts()
+	if err := client.Get("http://www.example.com").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if sent != 1 {
+		t.Errorf("expect the request to be sent")
+	}
+}
+
+func TestClientBlockPrivateHosts(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil).BlockPrivateHosts(true).SetHostResolver(testHostResolver{
+		"public.example.com":   "93.184.216.34",
+		"internal.example.com": "10.1.2.3",
+		"localhost":            "::1",
+	})
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	for _, c := range []struct {
+		url     string
+		allowed bool
+	}{
+		{"http://93.184.216.34", true},
+		{"http://public.example.com", true},
+		{"http://127.0.0.1", false},
+		{"http://0.0.0.0", false},
+		{"http://10.0.0.1", false},
+		{"http://172.16.0.1", false},
+		{"http://172.32.0.1", true},
+		{"http://192.168.1.1", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://[::1]", false},
+		{"http://[fd00::1]", false},
+		{"http://[fe80::1]", false},
+		{"http://internal.example.com", false},
+		{"http://localhost", false},
+		{"http://unknown.example.com", false},
+	} {
+		err := client.Get(c.url).Do(context.Background(), nil).Unwrap()
+		if c.allowed && err != nil {
+			t.Errorf("%s: unexpected error: %v", c.url, err)
+		} else if !c.allowed && err == nil {
+			t.Errorf("%s: expect an error, but got nil", c.url)
+		}
+	}
+}
+
+func TestAllowedHostsRedirect(t *testing.T) {
+	var hit bool
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+		w.WriteHeader(204)
+	}))
+	defer target.Close()
+
+	// Redirect to the same server by the other host.
+	redirect := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, redirect, http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := NewClient(new(http.Client)).OnResponse(nil).SetAllowedHosts("127.0.0.1")
+	err := client.Get(server.URL).Do(context.Background(), nil).Unwrap()
+	if err == nil {
+		t.Fatal("expect an error, but got nil")
+	} else if !strings.Contains(err.Error(), "the host 'localhost' is not allowed") {
+		t.Errorf("unexpected error: %v", err)
+	} else if hit {
+		t.Errorf("expect the redirect not to be followed")
+	}
+
+	if err := client.Get(target.URL).Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if !hit {
+		t.Errorf("expect the target to be requested")
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+// MaxRawErrorDataSize is the maximum size of Error.RawData,
+// which is the original response body when Error.Data is reduced from it.
+// If negative, it is not truncated.
+var MaxRawErrorDataSize = 4096
+
+// reduceErrorData reduces the html error page in err.Data to the readable
+// text, and keeps the original in err.RawData.
+func reduceErrorData(err *Error, ct string) {
+	if !strings.EqualFold(ct, "text/html") || err.Data == "" {
+		return
+	}
+
+	text := reduceHTML(err.Data)
+	if text == "" {
+		return
+	}
+
+	raw := err.Data
+	if MaxRawErrorDataSize >= 0 && len(raw) > MaxRawErrorDataSize {
+		raw = raw[:MaxRawErrorDataSize]
+	}
+
+	err.Data, err.RawData, err.DataReduced = text, raw, true
+}
+
+// reduceHTML extracts the text of the title and the first heading
+// or paragraph from the html document, such as "502 Bad Gateway",
+// which returns "" if there is none of them.
+//
+// It is a simple tokenizer for the error pages, not a html parser.
+func reduceHTML(s string) string {
+	title, first := getBuffer(), getBuffer()
+	defer putBuffer(title)
+	defer putBuffer(first)
+
+	var capture *bytes.Buffer
+	var captureTag string
+	var found bool
+
+	for len(s) > 0 {
+		index := strings.IndexByte(s, '<')
+		if index < 0 {
+			index = len(s)
+		}
+		if capture != nil {
+			capture.WriteString(s[:index])
+		}
+		if s = s[index:]; s == "" {
+			break
+		}
+
+		if strings.HasPrefix(s, "<!--") {
+			if index = strings.Index(s, "-->"); index < 0 {
+				break
+			}
+			s = s[index+3:]
+			continue
+		}
+
+		if index = strings.IndexByte(s, '>'); index < 0 {
+			break
+		}
+		tag := s[1:index]
+		s = s[index+1:]
+
+		closing := strings.HasPrefix(tag, "/")
+		name := htmlTagName(strings.TrimPrefix(tag, "/"))
+		switch {
+		case closing:
+			if capture != nil && name == captureTag {
+				if capture == first {
+					if found = collapseSpaces(first.String()) != ""; !found {
+						first.Reset()
+					}
+				}
+				capture = nil
+			}
+
+		case name == "script" || name == "style":
+			if index = strings.Index(strings.ToLower(s), "</"+name); index < 0 {
+				s = ""
+			} else {
+				s = s[index:]
+			}
+
+		case capture != nil:
+			capture.WriteByte(' ') // Separate the text of the inner elements.
+
+		case name == "title" && title.Len() == 0:
+			capture, captureTag = title, name
+
+		case !found && isHTMLTextTag(name):
+			capture, captureTag = first, name
+		}
+	}
+
+	text1 := collapseSpaces(html.UnescapeString(title.String()))
+	text2 := collapseSpaces(html.UnescapeString(first.String()))
+	switch {
+	case text1 == "" || strings.EqualFold(text1, text2):
+		return text2
+	case text2 == "":
+		return text1
+	default:
+		return text1 + ": " + text2
+	}
+}
+
+func htmlTagName(tag string) string {
+	if index := strings.IndexAny(tag, " \t\r\n/"); index > -1 {
+		tag = tag[:index]
+	}
+	return strings.ToLower(tag)
+}
+
+func isHTMLTextTag(name string) bool {
+	switch name {
+	case "h1", "h2", "h3", "h4", "h5", "h6", "p":
+		return true
+	default:
+		return false
+	}
+}
+
+func collapseSpaces(s string) string { return strings.Join(strings.Fields(s), " ") }
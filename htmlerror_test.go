@@ -0,0 +1,136 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const (
+	nginxErrorPage = "<html>\r\n<head><title>502 Bad Gateway</title></head>\r\n<body>\r\n" +
+		"<center><h1>502 Bad Gateway</h1></center>\r\n<hr><center>nginx/1.25.3</center>\r\n" +
+		"</body>\r\n</html>\r\n"
+
+	cloudFrontErrorPage = `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">
+<HTML><HEAD><META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=iso-8859-1">
+<TITLE>ERROR: The request could not be satisfied</TITLE>
+</HEAD><BODY>
+<H1>403 ERROR</H1>
+<H2>The request could not be satisfied.</H2>
+<HR noshade size="1px">
+Request blocked.
+We can't connect to the server for this app or website at this time.
+<BR clear="all">
+<HR noshade size="1px">
+<PRE>
+Generated by cloudfront (CloudFront)
+Request ID: abc123==
+</PRE>
+<ADDRESS>
+</ADDRESS>
+</BODY></HTML>`
+
+	albErrorPage = "<html>\r\n<head><title>503 Service Temporarily Unavailable</title></head>\r\n" +
+		"<body>\r\n<center><h1>503 Service Temporarily Unavailable</h1></center>\r\n</body>\r\n</html>\r\n"
+
+	appErrorPage = `<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Oops &amp; Sorry</title>
+  <style>h1 { color: red; }</style>
+  <script>var title = "<h1>not this</h1>";</script>
+</head>
+<body>
+  <!-- <p>commented out</p> -->
+  <div class="logo"><h1><img src="logo.png"></h1></div>
+  <p>The service is <b>under
+     maintenance</b>, please retry later.</p>
+  <p>The second paragraph.</p>
+</body>
+</html>`
+)
+
+func TestReadResponseBodyAsErrorHTML(t *testing.T) {
+	tests := []struct {
+		Name string
+		Body string
+		Data string
+	}{
+		{Name: "nginx", Body: nginxErrorPage, Data: "502 Bad Gateway"},
+		{Name: "cloudfront", Body: cloudFrontErrorPage, Data: "ERROR: The request could not be satisfied: 403 ERROR"},
+		{Name: "alb", Body: albErrorPage, Data: "503 Service Temporarily Unavailable"},
+		{Name: "app", Body: appErrorPage, Data: "Oops & Sorry: The service is under maintenance, please retry later."},
+		{Name: "notext", Body: "<html><body><img src='error.png'></body></html>"},
+	}
+
+	for _, test := range tests {
+		resp := &http.Response{
+			StatusCode: 502,
+			Header:     http.Header{HeaderContentType: []string{"text/html; charset=utf-8"}},
+			Body:       ioutil.NopCloser(strings.NewReader(test.Body)),
+		}
+
+		err := ReadResponseBodyAsError(nil, resp).(Error)
+		if test.Data == "" {
+			if err.DataReduced || err.Data != test.Body || err.RawData != "" {
+				t.Errorf("%s: expect the original data, but got '%s'", test.Name, err.Data)
+			}
+			continue
+		}
+
+		if !err.DataReduced {
+			t.Errorf("%s: expect the data to be reduced", test.Name)
+		}
+		if err.Data != test.Data {
+			t.Errorf("%s: expect the data '%s', but got '%s'", test.Name, test.Data, err.Data)
+		}
+		if err.RawData != test.Body {
+			t.Errorf("%s: expect the raw data to be the original body", test.Name)
+		}
+		if s := err.Error(); !strings.Contains(s, "data="+test.Data+" (reduced from html)") {
+			t.Errorf("%s: expect the reduction in the error, but got '%s'", test.Name, s)
+		}
+	}
+}
+
+func TestReadResponseBodyAsErrorRawDataLimit(t *testing.T) {
+	defer func(max int) { MaxRawErrorDataSize = max }(MaxRawErrorDataSize)
+	MaxRawErrorDataSize = 16
+
+	resp := &http.Response{
+		StatusCode: 502,
+		Header:     http.Header{HeaderContentType: []string{"text/html"}},
+		Body:       ioutil.NopCloser(strings.NewReader(nginxErrorPage)),
+	}
+	err := ReadResponseBodyAsError(nil, resp).(Error)
+	if expect := nginxErrorPage[:16]; err.RawData != expect {
+		t.Errorf("expect the raw data '%s', but got '%s'", expect, err.RawData)
+	}
+
+	// The non-html body is not reduced.
+	resp = &http.Response{
+		StatusCode: 502,
+		Header:     http.Header{HeaderContentType: []string{"text/plain"}},
+		Body:       ioutil.NopCloser(strings.NewReader(nginxErrorPage)),
+	}
+	err = ReadResponseBodyAsError(nil, resp).(Error)
+	if err.DataReduced || err.Data != nginxErrorPage {
+		t.Errorf("expect the original data, but got '%s'", err.Data)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrClientClosed is returned when sending the request by the client
+// which has been shut down.
+var ErrClientClosed = errors.New("http client is closed")
+
+// inflight is used to track the in-flight requests.
+type inflight struct {
+	lock   sync.Mutex
+	done   chan struct{}
+	num    int
+	closed bool
+}
+
+func newInflight() *inflight { return &inflight{} }
+
+// Add adds an in-flight request and reports whether it is added,
+// which returns false if closed.
+func (f *inflight) Add() (ok bool) {
+	if f == nil {
+		return true
+	}
+
+	f.lock.Lock()
+	if ok = !f.closed; ok {
+		if f.num++; f.num == 1 {
+			f.done = make(chan struct{})
+		}
+	}
+	f.lock.Unlock()
+	return
+}
+
+func (f *inflight) Close() {
+	if f != nil {
+		f.lock.Lock()
+		f.closed = true
+		f.lock.Unlock()
+	}
+}
+
+func (f *inflight) Done() {
+	if f == nil {
+		return
+	}
+
+	f.lock.Lock()
+	if f.num > 0 {
+		if f.num--; f.num == 0 {
+			close(f.done)
+			f.done = nil
+		}
+	}
+	f.lock.Unlock()
+}
+
+func (f *inflight) Num() (n int) {
+	if f != nil {
+		f.lock.Lock()
+		n = f.num
+		f.lock.Unlock()
+	}
+	return
+}
+
+func (f *inflight) Wait(c context.Context) error {
+	if f == nil {
+		return nil
+	}
+
+	f.lock.Lock()
+	done := f.done
+	f.lock.Unlock()
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-c.Done():
+		return c.Err()
+	}
+}
+
+// inflightDone is used to finish an in-flight request only once,
+// whether by closing the response body or by the fallback of Do.
+type inflightDone struct {
+	inflight *inflight
+	once     sync.Once
+	tracked  bool // Whether the response body is tracked to finish it
+}
+
+func newInflightDone(f *inflight) *inflightDone {
+	return &inflightDone{inflight: f}
+}
+
+func (d *inflightDone) Done() { d.once.Do(d.inflight.Done) }
+
+// inflightBody is used to finish the in-flight request
+// when the response body is closed.
+type inflightBody struct {
+	io.ReadCloser
+	done *inflightDone
+}
+
+func (b *inflightBody) Close() (err error) {
+	err = b.ReadCloser.Close()
+	b.done.Done()
+	return
+}
+
+// InFlight returns the number of the in-flight requests started
+// through the client, which are not completed.
+//
+// A request is completed only after its response body is closed.
+func (c *Client) InFlight() int { return c.inflight.Num() }
+
+// Wait blocks until all the in-flight requests started through the client
+// have completed, or the context is done.
+//
+// A request is completed only after its response body is closed.
+func (c *Client) Wait(ctx context.Context) error { return c.inflight.Wait(ctx) }
+
+// CloseIdleConnections closes the idle connections of the inner doer
+// if it has the method CloseIdleConnections, such as *http.Client.
+func (c *Client) CloseIdleConnections() {
+	if c.doer == nil {
+		return
+	}
+
+	// http.Client.CloseIdleConnections is added in go1.12.
+	if ic, ok := c.doer.(interface{ CloseIdleConnections() }); ok {
+		ic.CloseIdleConnections()
+	}
+}
+
+// Shutdown shuts down the client gracefully, which rejects the new requests
+// with ErrClientClosed, waits for all the in-flight requests to complete
+// until ctx is done, and closes the idle connections at last.
+//
+// Notice: the clones of the client are not affected.
+func (c *Client) Shutdown(ctx context.Context) (err error) {
+	c.inflight.Close()
+	err = c.inflight.Wait(ctx)
+	c.CloseIdleConnections()
+	return
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientInFlight(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	client.SetResponseHandler(func(interface{}, *http.Response) error { return nil })
+
+	resp := client.Get(server.URL).Do(context.Background(), nil)
+	if err := resp.Result(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := client.InFlight(); n != 1 {
+		t.Errorf("expect 1 in-flight request, but got %d", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	if err := client.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expect error DeadlineExceeded, but got %v", err)
+	}
+	cancel()
+
+	go resp.Close()
+	if err := client.Wait(context.Background()); err != nil {
+		t.Error(err)
+	} else if n := client.InFlight(); n != 0 {
+		t.Errorf("expect no in-flight requests, but got %d", n)
+	}
+
+	// Close the body twice.
+	resp.Body().Close()
+	if n := client.InFlight(); n != 0 {
+		t.Errorf("expect no in-flight requests, but got %d", n)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = client.Get(server.URL).Do(context.Background(), nil).Unwrap()
+		}()
+	}
+	wg.Wait()
+	if n := client.InFlight(); n != 0 {
+		t.Errorf("expect no in-flight requests, but got %d", n)
+	}
+}
+
+func TestClientInFlightPanic(t *testing.T) {
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	func() {
+		defer func() { _ = recover() }()
+		client.Get("http://127.0.0.1").AddHook(HookFunc(func(*http.Request) *http.Request {
+			panic("test")
+		})).Do(context.Background(), nil)
+	}()
+
+	if n := client.InFlight(); n != 0 {
+		t.Errorf("expect no in-flight requests, but got %d", n)
+	}
+
+	// The handler closes the response body and then panics.
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Header: http.Header{}, Body: http.NoBody}, nil
+	}))
+	func() {
+		defer func() { _ = recover() }()
+		client.Get("http://127.0.0.1").Do(context.Background(), func(resp *http.Response) error {
+			_ = resp.Body.Close()
+			panic("test")
+		})
+	}()
+
+	if n := client.InFlight(); n != 0 {
+		t.Errorf("expect no in-flight requests, but got %d", n)
+	}
+
+	inflight := newInflight()
+	inflight.Done()
+	if n := inflight.Num(); n != 0 {
+		t.Errorf("expect no in-flight requests, but got %d", n)
+	}
+}
+
+func TestClientShutdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewClient(&http.Client{Transport: &http.Transport{}}).OnResponse(nil)
+	client.SetResponseHandler(func(interface{}, *http.Response) error { return nil })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				err := client.Get(server.URL).Do(context.Background(), nil).Unwrap()
+				if err == nil {
+					continue
+				}
+
+				if e, ok := err.(Error); !ok || e.Err != ErrClientClosed {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+		}()
+	}
+
+	time.Sleep(time.Millisecond * 20)
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	} else if n := client.InFlight(); n != 0 {
+		t.Errorf("expect no in-flight requests, but got %d", n)
+	}
+	wg.Wait()
+
+	err := client.Get(server.URL).Do(context.Background(), nil).Unwrap()
+	if e, ok := err.(Error); !ok || e.Err != ErrClientClosed {
+		t.Errorf("expect error ErrClientClosed, but got %v", err)
+	}
+
+	if err := client.Clone().Get(server.URL).Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
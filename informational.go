@@ -0,0 +1,76 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/textproto"
+)
+
+// OnInformational sets the callback function to be called
+// when receiving a 1xx informational response, such as 100 or 103,
+// before the final response.
+//
+// Notice: it requires go1.11+, or the 1xx responses are not reported.
+//
+// Default: nil
+func (c *Client) OnInformational(f func(code int, header textproto.MIMEHeader)) *Client {
+	c.oninfo = f
+	return c
+}
+
+// OnInformational sets the callback function to be called
+// when receiving a 1xx informational response.
+//
+// See Client.OnInformational.
+func (r *Request) OnInformational(f func(code int, header textproto.MIMEHeader)) *Request {
+	r.oninfo = f
+	return r
+}
+
+// informational handles the 1xx informational response, which calls
+// the informational callback and the 1xx response handler in turn.
+//
+// The informational response has no body, so the 1xx response handler
+// cannot decode it. If the handler returns an error, the request is aborted.
+func (r *Request) informational(resp *Response, code int, header textproto.MIMEHeader) error {
+	if code == 103 { // Early Hints
+		resp.hints = append(resp.hints, header["Link"]...)
+	}
+
+	if r.oninfo != nil {
+		r.oninfo(code, header)
+	}
+
+	if r.handler.H1xx == nil {
+		return nil
+	}
+
+	return r.handler.H1xx(nil, &http.Response{
+		Status:     fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode: code,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header(header),
+		Body:       http.NoBody,
+		Request:    resp.req,
+	})
+}
+
+// EarlyHints returns the values of the header "Link" of all the received
+// 103 Early Hints responses, such as "</style.css>; rel=preload; as=style".
+func (r *Response) EarlyHints() []string { return r.hints }
@@ -0,0 +1,85 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.11
+// +build go1.11
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestInformationalResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", "</style.css>; rel=preload; as=style")
+		w.WriteHeader(103)
+		w.Header().Set("Link", "</script.js>; rel=preload; as=script")
+		w.WriteHeader(103)
+		w.Header().Del("Link")
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	var codes []int
+	var handled int
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		OnInformational(func(code int, header textproto.MIMEHeader) { codes = append(codes, code) }).
+		SetResponseHandler1xx(func(_ interface{}, resp *http.Response) error {
+			if resp.StatusCode != 103 {
+				t.Errorf("expect status code %d, but got %d", 103, resp.StatusCode)
+			}
+			handled++
+			return nil
+		})
+
+	resp := client.Get(server.URL).Do(context.Background(), nil)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if code := resp.StatusCode(); code != 204 {
+		t.Errorf("expect status code %d, but got %d", 204, code)
+	}
+
+	if len(codes) != 2 || codes[0] != 103 || codes[1] != 103 {
+		t.Errorf("unexpected informational codes: %v", codes)
+	}
+	if handled != 2 {
+		t.Errorf("expect the 1xx handler to be called %d times, but got %d", 2, handled)
+	}
+
+	expects := []string{"</style.css>; rel=preload; as=style", "</script.js>; rel=preload; as=script"}
+	if hints := resp.EarlyHints(); len(hints) != len(expects) {
+		t.Errorf("expect %d early hints, but got %v", len(expects), hints)
+	} else {
+		for i, hint := range hints {
+			if hint != expects[i] {
+				t.Errorf("%d: expect hint '%s', but got '%s'", i, expects[i], hint)
+			}
+		}
+	}
+
+	errAbort := errors.New("abort")
+	err := client.Get(server.URL).
+		SetResponseHandler1xx(func(interface{}, *http.Response) error { return errAbort }).
+		Do(context.Background(), nil).Unwrap()
+	if err == nil || !strings.Contains(err.Error(), errAbort.Error()) {
+		t.Errorf("expect error '%v', but got '%v'", errAbort, err)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheEntry is the entry of the cached response body.
+type CacheEntry struct {
+	ContentType string
+	Body        []byte
+	Time        time.Time // The time when the entry is saved.
+}
+
+// CacheStore is used to store the cached response bodies.
+type CacheStore interface {
+	Get(key string) (entry CacheEntry, ok bool)
+	Set(key string, entry CacheEntry)
+	Delete(key string)
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// MemoryCacheStore is a LRU cache store in memory.
+type MemoryCacheStore struct {
+	max   int
+	lock  sync.Mutex
+	list  *list.List
+	items map[string]*list.Element
+}
+
+var _ CacheStore = new(MemoryCacheStore)
+
+// NewMemoryCacheStore returns a new memory cache store, which evicts
+// the least recently used entry when the number of the entries exceeds max.
+//
+// If max is equal to or less than 0, no limit.
+func NewMemoryCacheStore(max int) *MemoryCacheStore {
+	return &MemoryCacheStore{max: max, list: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Len returns the number of the cached entries.
+func (s *MemoryCacheStore) Len() int {
+	s.lock.Lock()
+	n := s.list.Len()
+	s.lock.Unlock()
+	return n
+}
+
+// Get implements the interface CacheStore.
+func (s *MemoryCacheStore) Get(key string) (entry CacheEntry, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.items[key]
+	if ok {
+		s.list.MoveToFront(e)
+		entry = e.Value.(*memoryCacheItem).entry
+	}
+	return
+}
+
+// Set implements the interface CacheStore.
+func (s *MemoryCacheStore) Set(key string, entry CacheEntry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if e, ok := s.items[key]; ok {
+		e.Value.(*memoryCacheItem).entry = entry
+		s.list.MoveToFront(e)
+		return
+	}
+
+	s.items[key] = s.list.PushFront(&memoryCacheItem{key: key, entry: entry})
+	if s.max > 0 && s.list.Len() > s.max {
+		e := s.list.Back()
+		s.list.Remove(e)
+		delete(s.items, e.Value.(*memoryCacheItem).key)
+	}
+}
+
+// Delete implements the interface CacheStore.
+func (s *MemoryCacheStore) Delete(key string) {
+	s.lock.Lock()
+	if e, ok := s.items[key]; ok {
+		s.list.Remove(e)
+		delete(s.items, key)
+	}
+	s.lock.Unlock()
+}
+
+// LastGoodStats is the statistics of the last known good responses.
+type LastGoodStats struct {
+	Saved  uint64 // The number of the saved response bodies.
+	Served uint64 // The number of the failed requests served by the stale bodies.
+}
+
+// DefaultLastGoodMaxEntrySize is the default maximum size of the response body
+// to be saved as the last known good one.
+const DefaultLastGoodMaxEntrySize = 1024 * 1024
+
+type lastGood struct {
+	store   CacheStore
+	ttl     time.Duration
+	maxsize int64
+
+	saved  uint64
+	served uint64
+}
+
+// EnableLastGood enables the "last known good" store for the degradation,
+// which saves the successful 2xx response bodies of the GET requests
+// keyed by the method and url, and decodes the saved body into the result
+// when the request fails with the transport error or the 5xx response.
+//
+// Unlike the http cache, it ignores the header Cache-Control entirely.
+// The saved body is served only if its age does not exceed ttl,
+// and the response is marked as Response.Stale, whose Unwrap returns nil
+// but Result still returns the original error.
+//
+// If store is nil, disable it.
+func (c *Client) EnableLastGood(store CacheStore, ttl time.Duration) *Client {
+	if store == nil {
+		c.lastgood = nil
+	} else {
+		c.lastgood = &lastGood{store: store, ttl: ttl, maxsize: DefaultLastGoodMaxEntrySize}
+	}
+	return c
+}
+
+// SetLastGoodMaxEntrySize resets the maximum size of the response body
+// to be saved, and the larger body is not saved.
+//
+// Notice: it must be called after EnableLastGood.
+//
+// Default: DefaultLastGoodMaxEntrySize
+func (c *Client) SetLastGoodMaxEntrySize(n int64) *Client {
+	if c.lastgood == nil {
+		panic("Client.SetLastGoodMaxEntrySize: the last good store is not enabled")
+	}
+	c.lastgood.maxsize = n
+	return c
+}
+
+// LastGoodStats returns the statistics of the last known good responses.
+func (c *Client) LastGoodStats() (stats LastGoodStats) {
+	if c.lastgood != nil {
+		stats.Saved = atomic.LoadUint64(&c.lastgood.saved)
+		stats.Served = atomic.LoadUint64(&c.lastgood.served)
+	}
+	return
+}
+
+// Stale returns the age of the saved body which is served as the result
+// instead of the failed response, and reports whether it is served.
+func (r *Response) Stale() (age time.Duration, ok bool) {
+	return r.stale, r.staled
+}
+
+func lastGoodKey(req *http.Request) string { return req.Method + " " + req.URL.String() }
+
+// captureBody captures the response body until EOF to save it.
+type captureBody struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	max      int64
+	eof      bool
+	overflow bool
+}
+
+func (b *captureBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	if !b.overflow {
+		if int64(b.buf.Len()+n) > b.max {
+			b.overflow = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		b.eof = true
+	}
+	return
+}
+
+// capture wraps the body of the successful response to save it.
+func (g *lastGood) capture(resp *http.Response) *captureBody {
+	if resp.Request == nil || resp.Request.Method != http.MethodGet ||
+		resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil
+	}
+
+	body := &captureBody{ReadCloser: resp.Body, max: g.maxsize}
+	resp.Body = body
+	return body
+}
+
+// save saves the captured body if the response is handled successfully.
+func (g *lastGood) save(resp *Response, body *captureBody) {
+	if body == nil || !body.eof || body.overflow {
+		return
+	}
+
+	resp.bodydata = body.buf.Bytes()
+	if resp.err != nil {
+		return
+	}
+
+	g.store.Set(lastGoodKey(resp.req), CacheEntry{
+		ContentType: GetContentType(resp.resp.Header),
+		Body:        body.buf.Bytes(),
+		Time:        time.Now(),
+	})
+	atomic.AddUint64(&g.saved, 1)
+}
+
+// serve decodes the saved body into result if the request fails
+// with the transport error or the 5xx response.
+func (g *lastGood) serve(resp *Response, result interface{}) {
+	if resp.err == nil || resp.req == nil || resp.req.Method != http.MethodGet || result == nil ||
+		(resp.resp != nil && resp.resp.StatusCode < 500) {
+		return
+	}
+
+	if _, ok := result.(func(*http.Response) error); ok {
+		return
+	}
+
+	key := lastGoodKey(resp.req)
+	entry, ok := g.store.Get(key)
+	if !ok {
+		return
+	}
+
+	age := time.Since(entry.Time)
+	if age > g.ttl {
+		g.store.Delete(key)
+		return
+	}
+
+	if DecodeFromReader(result, entry.ContentType, bytes.NewReader(entry.Body)) == nil {
+		resp.stale, resp.staled, resp.bodydata = age, true, entry.Body
+		atomic.AddUint64(&g.served, 1)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheStore(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+	store.Set("a", CacheEntry{Body: []byte("a")})
+	store.Set("b", CacheEntry{Body: []byte("b")})
+	_, _ = store.Get("a")
+	store.Set("c", CacheEntry{Body: []byte("c")})
+
+	if n := store.Len(); n != 2 {
+		t.Errorf("expect %d entries, but got %d", 2, n)
+	}
+	if _, ok := store.Get("b"); ok {
+		t.Errorf("expect the entry 'b' to be evicted")
+	}
+	if e, ok := store.Get("a"); !ok || string(e.Body) != "a" {
+		t.Errorf("expect the entry 'a', but got %v", e)
+	}
+
+	store.Delete("a")
+	if _, ok := store.Get("a"); ok {
+		t.Errorf("expect the entry 'a' to be deleted")
+	}
+}
+
+func TestClientLastGood(t *testing.T) {
+	var failed int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failed) == 1 {
+			w.WriteHeader(503)
+			return
+		}
+
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		if r.URL.Path == "/large" {
+			_, _ = w.Write([]byte(`"0123456789"`))
+		} else {
+			_, _ = w.Write([]byte(`"` + r.URL.Path + `"`))
+		}
+	}))
+	defer server.Close()
+
+	store := NewMemoryCacheStore(0)
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		EnableLastGood(store, time.Minute).SetLastGoodMaxEntrySize(10)
+
+	do := func(path string) (result string, resp *Response) {
+		resp = client.Get(server.URL+path).Do(context.Background(), &result)
+		return
+	}
+
+	for _, path := range []string{"/a", "/b", "/large"} {
+		if _, resp := do(path); resp.Unwrap() != nil {
+			t.Fatal(resp.Unwrap())
+		}
+	}
+	if stats := client.LastGoodStats(); stats.Saved != 2 || stats.Served != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	atomic.StoreInt32(&failed, 1)
+	result, resp := do("/a")
+	if err := resp.Unwrap(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if result != "/a" {
+		t.Errorf("expect result '%s', but got '%s'", "/a", result)
+	} else if age, ok := resp.Stale(); !ok || age <= 0 || age > time.Minute {
+		t.Errorf("expect a stale response, but got age=%s, ok=%v", age, ok)
+	} else if e, ok := resp.Result().(Error); !ok || e.Code != 503 {
+		t.Errorf("expect the original error, but got %v", resp.Result())
+	}
+
+	// Not saved because of too large
+	if _, resp := do("/large"); resp.Unwrap() == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+
+	// Expired
+	client.lastgood.ttl = 0
+	if _, resp := do("/b"); resp.Unwrap() == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if store.Len() != 1 {
+		t.Errorf("expect the expired entry to be deleted")
+	}
+
+	if stats := client.LastGoodStats(); stats.Saved != 2 || stats.Served != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
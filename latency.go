@@ -0,0 +1,246 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBounds returns the upper bounds of the exponential buckets
+// of LatencyRecorder, which are from 1ms to about 65s, and the latencies
+// greater than the last bound are counted into the overflow bucket.
+func LatencyBounds() []time.Duration {
+	return append([]time.Duration(nil), latencyBounds...)
+}
+
+var latencyBounds = func() []time.Duration {
+	bounds := make([]time.Duration, latencyBucketNum-1)
+	for i := range bounds {
+		bounds[i] = time.Millisecond << uint(i)
+	}
+	return bounds
+}()
+
+const latencyBucketNum = 18 // The last is the overflow bucket.
+
+var latencyClassNames = [...]string{"error", "1xx", "2xx", "3xx", "4xx", "5xx"}
+
+type latencyClass struct {
+	buckets [latencyBucketNum]uint64
+	count   uint64
+	sum     uint64
+}
+
+// LatencyRecorder is a lightweight recorder of the request latencies,
+// which counts them into the fixed exponential buckets per status class,
+// such as "2xx", and the requests failing without the response
+// are counted into the class "error".
+//
+// It only uses the atomic operations, so it is cheap enough to be
+// always enabled in production.
+type LatencyRecorder struct {
+	classes [len(latencyClassNames)]latencyClass
+}
+
+// NewLatencyRecorder returns a new latency recorder.
+func NewLatencyRecorder() *LatencyRecorder { return new(LatencyRecorder) }
+
+// Record records the latency of the request with the response status code,
+// which is 0 if the request fails without the response.
+func (r *LatencyRecorder) Record(statusCode int, latency time.Duration) {
+	var index int
+	if statusCode >= 100 && statusCode < 600 {
+		index = statusCode / 100
+	}
+
+	if latency < 0 {
+		latency = 0
+	}
+
+	class := &r.classes[index]
+	atomic.AddUint64(&class.buckets[latencyBucket(latency)], 1)
+	atomic.AddUint64(&class.sum, uint64(latency))
+	atomic.AddUint64(&class.count, 1)
+}
+
+func latencyBucket(latency time.Duration) int {
+	ms := uint64((latency + time.Millisecond - 1) / time.Millisecond)
+	if ms <= 1 {
+		return 0
+	}
+
+	// The bit length of ms-1, like bits.Len64 added in go1.9.
+	var index int
+	for n := ms - 1; n > 0 && index < latencyBucketNum-1; n >>= 1 {
+		index++
+	}
+	return index
+}
+
+// Reset resets all the recorded latencies.
+//
+// Notice: the latencies recorded concurrently may be partially reset.
+func (r *LatencyRecorder) Reset() {
+	for i := range r.classes {
+		class := &r.classes[i]
+		for j := range class.buckets {
+			atomic.StoreUint64(&class.buckets[j], 0)
+		}
+		atomic.StoreUint64(&class.sum, 0)
+		atomic.StoreUint64(&class.count, 0)
+	}
+}
+
+// Snapshot returns the snapshot of the recorded latencies.
+func (r *LatencyRecorder) Snapshot() LatencySnapshot {
+	snapshot := LatencySnapshot{
+		Classes: make(map[string]LatencyStats, len(r.classes)),
+		All:     LatencyStats{Buckets: make([]uint64, latencyBucketNum)},
+	}
+
+	for i := range r.classes {
+		class := &r.classes[i]
+		stats := LatencyStats{Buckets: make([]uint64, latencyBucketNum)}
+		for j := range class.buckets {
+			n := atomic.LoadUint64(&class.buckets[j])
+			stats.Buckets[j] = n
+			stats.Count += n
+			snapshot.All.Buckets[j] += n
+		}
+
+		if stats.Count == 0 {
+			continue
+		}
+
+		stats.Sum = time.Duration(atomic.LoadUint64(&class.sum))
+		stats.init()
+		snapshot.Classes[latencyClassNames[i]] = stats
+
+		snapshot.All.Count += stats.Count
+		snapshot.All.Sum += stats.Sum
+	}
+
+	snapshot.All.init()
+	return snapshot
+}
+
+// LatencySnapshot is the snapshot of the latencies recorded by LatencyRecorder.
+type LatencySnapshot struct {
+	// Classes is the latency statistics of the status classes,
+	// such as "2xx", "5xx" and "error", which only contains
+	// the classes having the recorded latencies.
+	Classes map[string]LatencyStats `json:"classes"`
+
+	// All is the latency statistics of all the status classes.
+	All LatencyStats `json:"all"`
+}
+
+// LatencyStats is the latency statistics of a status class.
+type LatencyStats struct {
+	Count uint64        `json:"count"`
+	Sum   time.Duration `json:"sum"`
+
+	// Buckets is the counts of the buckets whose upper bounds are LatencyBounds(),
+	// and the last is the overflow bucket.
+	Buckets []uint64 `json:"buckets"`
+
+	// The approximate quantiles.
+	P50 time.Duration `json:"p50"`
+	P90 time.Duration `json:"p90"`
+	P99 time.Duration `json:"p99"`
+}
+
+func (s *LatencyStats) init() {
+	s.P50 = s.Quantile(0.5)
+	s.P90 = s.Quantile(0.9)
+	s.P99 = s.Quantile(0.99)
+}
+
+// Mean returns the mean of the latencies.
+func (s LatencyStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// Quantile returns the approximate quantile q between 0 and 1 of the latencies,
+// which is interpolated linearly in the bucket.
+//
+// For the overflow bucket, return the last bound of LatencyBounds().
+func (s LatencyStats) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	} else if q < 0 {
+		q = 0
+	} else if q > 1 {
+		q = 1
+	}
+
+	rank := q * float64(s.Count)
+	var total float64
+	for i, n := range s.Buckets {
+		if n == 0 {
+			continue
+		}
+
+		if total+float64(n) < rank {
+			total += float64(n)
+			continue
+		}
+
+		if i >= len(latencyBounds) {
+			break
+		}
+
+		var lower time.Duration
+		if i > 0 {
+			lower = latencyBounds[i-1]
+		}
+		upper := latencyBounds[i]
+		return lower + time.Duration(float64(upper-lower)*(rank-total)/float64(n))
+	}
+
+	return latencyBounds[len(latencyBounds)-1]
+}
+
+// SetLatencyRecorder sets the latency recorder to record the latencies
+// of the requests, which is shared by the cloned clients.
+//
+// If recorder is nil, disable it.
+//
+// Default: nil
+func (c *Client) SetLatencyRecorder(recorder *LatencyRecorder) *Client {
+	c.latency = recorder
+	return c
+}
+
+// LatencySnapshot returns the snapshot of the latencies recorded
+// by the latency recorder.
+//
+// Return the zero snapshot if the latency recorder is not set.
+func (c *Client) LatencySnapshot() LatencySnapshot {
+	if c.latency == nil {
+		return LatencySnapshot{}
+	}
+	return c.latency.Snapshot()
+}
+
+func (r *Request) recordLatency(resp *Response) {
+	if r.latency != nil && resp.req != nil && resp.cost > 0 {
+		r.latency.Record(resp.StatusCode(), resp.cost)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLatencyBucket(t *testing.T) {
+	tests := []struct {
+		Latency time.Duration
+		Bucket  int
+	}{
+		{0, 0},
+		{time.Millisecond, 0},
+		{time.Millisecond + 1, 1},
+		{2 * time.Millisecond, 1},
+		{3 * time.Millisecond, 2},
+		{4 * time.Millisecond, 2},
+		{time.Second, 10},
+		{time.Hour, latencyBucketNum - 1},
+	}
+
+	bounds := LatencyBounds()
+	for _, test := range tests {
+		if bucket := latencyBucket(test.Latency); bucket != test.Bucket {
+			t.Errorf("%s: expect bucket %d, but got %d", test.Latency, test.Bucket, bucket)
+		} else if bucket < len(bounds) && test.Latency > bounds[bucket] {
+			t.Errorf("%s: exceed the bound %s", test.Latency, bounds[bucket])
+		}
+	}
+}
+
+func TestLatencyRecorder(t *testing.T) {
+	recorder := NewLatencyRecorder()
+	for i := 0; i < 99; i++ {
+		recorder.Record(200, 3*time.Millisecond)
+	}
+	recorder.Record(200, 900*time.Millisecond)
+	recorder.Record(503, 10*time.Millisecond)
+	recorder.Record(0, time.Hour)
+
+	snapshot := recorder.Snapshot()
+	if len(snapshot.Classes) != 3 {
+		t.Errorf("expect %d classes, but got %d", 3, len(snapshot.Classes))
+	}
+
+	stats := snapshot.Classes["2xx"]
+	if stats.Count != 100 {
+		t.Errorf("expect count %d, but got %d", 100, stats.Count)
+	} else if stats.P50 <= 2*time.Millisecond || stats.P50 > 4*time.Millisecond {
+		t.Errorf("expect p50 in (2ms, 4ms], but got %s", stats.P50)
+	} else if stats.P99 > 4*time.Millisecond {
+		t.Errorf("expect p99 in (2ms, 4ms], but got %s", stats.P99)
+	} else if p := stats.Quantile(1); p <= 512*time.Millisecond || p > 1024*time.Millisecond {
+		t.Errorf("expect p100 in (512ms, 1024ms], but got %s", p)
+	} else if mean := stats.Mean(); mean != (99*3*time.Millisecond+900*time.Millisecond)/100 {
+		t.Errorf("unexpected mean %s", mean)
+	}
+
+	if stats := snapshot.Classes["error"]; stats.Count != 1 || stats.P50 != latencyBounds[len(latencyBounds)-1] {
+		t.Errorf("unexpected error class: %+v", stats)
+	}
+	if snapshot.All.Count != 102 {
+		t.Errorf("expect the total count %d, but got %d", 102, snapshot.All.Count)
+	}
+
+	recorder.Reset()
+	if snapshot := recorder.Snapshot(); len(snapshot.Classes) != 0 || snapshot.All.Count != 0 {
+		t.Errorf("expect the empty snapshot, but got %+v", snapshot)
+	}
+}
+
+func TestClientLatencySnapshot(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil).SetLatencyRecorder(NewLatencyRecorder())
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		time.Sleep(time.Millisecond)
+		if r.URL.Path == "/error" {
+			return nil, errors.New("test")
+		}
+		return &http.Response{StatusCode: 204, Header: http.Header{}, Body: http.NoBody}, nil
+	}))
+
+	_ = client.Get("http://127.0.0.1").Do(context.Background(), nil)
+	_ = client.Get("http://127.0.0.1/error").Do(context.Background(), nil)
+
+	snapshot := client.LatencySnapshot()
+	if snapshot.Classes["2xx"].Count != 1 || snapshot.Classes["error"].Count != 1 {
+		t.Errorf("unexpected snapshot: %+v", snapshot)
+	}
+
+	if snapshot := client.SetLatencyRecorder(nil).LatencySnapshot(); snapshot.All.Count != 0 {
+		t.Errorf("expect the zero snapshot, but got %+v", snapshot)
+	}
+}
+
+func BenchmarkLatencyRecorder(b *testing.B) {
+	recorder := NewLatencyRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		recorder.Record(200, time.Duration(i)*time.Microsecond)
+	}
+}
+
+func BenchmarkLatencyRecorderParallel(b *testing.B) {
+	recorder := NewLatencyRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			i++
+			recorder.Record(200+i%4*100, time.Duration(i)*time.Microsecond)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RequestBodyTooLargeError is returned when the request body
+// exceeds the maximum size.
+type RequestBodyTooLargeError struct {
+	Limit int64
+
+	// Size is the actual size of the request body,
+	// or the size read so far for the streaming body.
+	Size int64
+}
+
+func (e RequestBodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body size %d exceeds the limit %d", e.Size, e.Limit)
+}
+
+// ResponseBodyTooLargeError is returned when reading the response body
+// beyond the maximum size.
+type ResponseBodyTooLargeError struct {
+	Limit int64
+
+	// Size is the Content-Length of the response body,
+	// or the size read so far if unknown.
+	Size int64
+}
+
+func (e ResponseBodyTooLargeError) Error() string {
+	return fmt.Sprintf("response body size %d exceeds the limit %d", e.Size, e.Limit)
+}
+
+// SetMaxResponseBodySize sets the maximum size of the response body,
+// which is checked after decompressing it.
+//
+// If the Content-Length of the response exceeds the limit, reading
+// the body fails at once. Or, reading the body fails when it exceeds
+// the limit. Both fail with ResponseBodyTooLargeError.
+//
+// If n is equal to or less than 0, no limit.
+//
+// Default: 0
+func (c *Client) SetMaxResponseBodySize(n int64) *Client {
+	c.maxrespbody = n
+	return c
+}
+
+// SetMaxResponseBodySize overrides the maximum size of the response body.
+//
+// See Client.SetMaxResponseBodySize.
+func (r *Request) SetMaxResponseBodySize(n int64) *Request {
+	r.maxrespbody = n
+	return r
+}
+
+// limitResponseBody limits the size of the response body.
+func (r *Request) limitResponseBody(resp *http.Response) {
+	if r.maxrespbody <= 0 || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+
+	body := &limitResponseBody{ReadCloser: resp.Body, limit: r.maxrespbody}
+	if resp.ContentLength > r.maxrespbody {
+		body.err = ResponseBodyTooLargeError{Limit: r.maxrespbody, Size: resp.ContentLength}
+	}
+	resp.Body = body
+}
+
+type limitResponseBody struct {
+	io.ReadCloser
+	size  int64
+	limit int64
+	err   error
+}
+
+func (b *limitResponseBody) Read(p []byte) (n int, err error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	// Read one more byte to detect the body beyond the limit.
+	if max := b.limit - b.size + 1; int64(len(p)) > max {
+		p = p[:max]
+	}
+
+	n, err = b.ReadCloser.Read(p)
+	if b.size += int64(n); b.size > b.limit {
+		n, b.err = n-int(b.size-b.limit), ResponseBodyTooLargeError{Limit: b.limit, Size: b.size}
+		err = b.err
+	}
+	return
+}
+
+// SetMaxRequestBodySize sets the maximum size of the request body.
+//
+// If the encoded body exceeds the limit, the request fails before sending.
+// If the streaming body exceeds the limit, the request is cut off
+// in flight. Both fail with RequestBodyTooLargeError.
+//
+// If n is equal to or less than 0, no limit.
+//
+// Default: 0
+func (c *Client) SetMaxRequestBodySize(n int64) *Client {
+	c.maxbody = n
+	return c
+}
+
+// SetMaxRequestBodySize overrides the maximum size of the request body.
+//
+// See Client.SetMaxRequestBodySize.
+func (r *Request) SetMaxRequestBodySize(n int64) *Request {
+	r.maxbody = n
+	return r
+}
+
+// encodeBody encodes the body into bodybuf, which discards the data
+// beyond the maximum size but still counts the actual size.
+func (r *Request) encodeBody(ct string, body interface{}) (err error) {
+	encoder := r.bodyEncoder()
+	if r.maxbody <= 0 {
+		return encoder(r.bodybuf, ct, body)
+	}
+
+	w := &limitWriter{w: r.bodybuf, limit: r.maxbody}
+	if err = encoder(w, ct, body); err == nil && w.size > w.limit {
+		err = RequestBodyTooLargeError{Limit: w.limit, Size: w.size}
+	}
+	return
+}
+
+// limitBody checks the size of the request body, and limits it
+// if its size is unknown.
+func (r *Request) limitBody(req *http.Request) error {
+	if r.maxbody <= 0 || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	if req.ContentLength > r.maxbody {
+		_ = req.Body.Close()
+		return RequestBodyTooLargeError{Limit: r.maxbody, Size: req.ContentLength}
+	}
+
+	if req.ContentLength <= 0 {
+		req.Body = &limitReadCloser{ReadCloser: req.Body, limit: r.maxbody}
+	}
+	return nil
+}
+
+type limitWriter struct {
+	w     io.Writer
+	size  int64
+	limit int64
+}
+
+func (w *limitWriter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	if remain := w.limit - w.size; remain > 0 {
+		if int64(n) > remain {
+			_, err = w.w.Write(p[:remain])
+		} else {
+			_, err = w.w.Write(p)
+		}
+	}
+	w.size += int64(n)
+	return
+}
+
+type limitReadCloser struct {
+	io.ReadCloser
+	size  int64
+	limit int64
+}
+
+func (r *limitReadCloser) Read(p []byte) (n int, err error) {
+	n, err = r.ReadCloser.Read(p)
+	if r.size += int64(n); r.size > r.limit {
+		return 0, RequestBodyTooLargeError{Limit: r.limit, Size: r.size}
+	}
+	return
+}
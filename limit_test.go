@@ -0,0 +1,129 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type onlyReader struct{ io.Reader }
+
+func TestMaxRequestBodySize(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		_, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).SetMaxRequestBodySize(10)
+
+	// Buffered
+	err := client.Post(server.URL).SetBody(strings.Repeat("a", 100)).Do(context.Background(), nil).Unwrap()
+	if e, ok := err.(Error).Err.(RequestBodyTooLargeError); !ok {
+		t.Errorf("expect RequestBodyTooLargeError, but got %v", err)
+	} else if e.Limit != 10 || e.Size != 100 {
+		t.Errorf("expect limit %d and size %d, but got %d and %d", 10, 100, e.Limit, e.Size)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("expect no requests sent, but got %d", n)
+	}
+
+	// The limit is set after the body.
+	err = client.Clone().SetMaxRequestBodySize(0).Post(server.URL).SetBody(strings.Repeat("a", 100)).
+		SetMaxRequestBodySize(50).Do(context.Background(), nil).Unwrap()
+	if e, ok := err.(Error).Err.(RequestBodyTooLargeError); !ok || e.Size != 100 {
+		t.Errorf("expect RequestBodyTooLargeError, but got %v", err)
+	}
+
+	// Streaming
+	body := onlyReader{strings.NewReader(strings.Repeat("a", 100))}
+	err = client.Post(server.URL).SetBody(body).Do(context.Background(), nil).Unwrap()
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit 10") {
+		t.Errorf("expect RequestBodyTooLargeError, but got %v", err)
+	}
+
+	// Per-request override
+	err = client.Post(server.URL).SetMaxRequestBodySize(100).SetBody(strings.Repeat("a", 100)).
+		Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Error(err)
+	}
+
+	body = onlyReader{strings.NewReader(strings.Repeat("a", 10))}
+	if err = client.Post(server.URL).SetBody(body).Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMaxResponseBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") != "" {
+			w.(http.Flusher).Flush() // Send the body without Content-Length.
+		}
+		if r.URL.Query().Get("json") != "" {
+			w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		}
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	read := func(req *Request) (data string, err error) {
+		err = req.Do(context.Background(), func(resp *http.Response) error {
+			buf, err := ioutil.ReadAll(resp.Body)
+			data = string(buf)
+			return err
+		}).Unwrap()
+		return
+	}
+
+	for _, chunked := range []string{"", "1"} {
+		data, err := read(client.Get(server.URL).AddQuery("chunked", chunked).SetMaxResponseBodySize(10))
+		if err != nil {
+			t.Errorf("chunked=%s: unexpected error: %v", chunked, err)
+		} else if data != "0123456789" {
+			t.Errorf("chunked=%s: expect the body '%s', but got '%s'", chunked, "0123456789", data)
+		}
+
+		data, err = read(client.Get(server.URL).AddQuery("chunked", chunked).SetMaxResponseBodySize(9))
+		if err == nil {
+			t.Errorf("chunked=%s: expect an error, but got nil", chunked)
+		} else if e, ok := err.(Error).Err.(ResponseBodyTooLargeError); !ok || e.Limit != 9 {
+			t.Errorf("chunked=%s: expect ResponseBodyTooLargeError, but got %v", chunked, err)
+		} else if kind := Kind(err); kind != KindInvalidResponse {
+			t.Errorf("chunked=%s: expect the error kind '%s', but got '%s'", chunked, KindInvalidResponse, kind)
+		} else if len(data) > 9 {
+			t.Errorf("chunked=%s: expect at most 9 bytes, but got '%s'", chunked, data)
+		}
+	}
+
+	// Decode the response body beyond the limit.
+	var result interface{}
+	err := client.Get(server.URL).AddQuery("json", "1").SetMaxResponseBodySize(5).Do(context.Background(), &result).Unwrap()
+	if _, ok := err.(Error).Err.(ResponseBodyTooLargeError); !ok {
+		t.Errorf("expect ResponseBodyTooLargeError, but got %v", err)
+	} else if kind := Kind(err); kind != KindInvalidResponse {
+		t.Errorf("expect the error kind '%s', but got '%s'", KindInvalidResponse, kind)
+	}
+}
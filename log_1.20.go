@@ -17,9 +17,51 @@
 
 package httpclient
 
-import "log"
+import (
+	"fmt"
+	"log"
+	"net/http"
+)
 
 func logOnResponse(r *Response) {
-	log.Printf("log the http request: method=%s, url=%s, statuscode=%d, cost=%s, err=%s",
-		r.Method(), r.Url(), r.StatusCode(), r.Cost().String(), r.Error())
+	var sampled string
+	if v, ok := r.Sampled(); ok {
+		sampled = fmt.Sprintf(", sampled=%v", v)
+	}
+
+	var attempts string
+	if n := r.Attempts(); n > 1 {
+		attempts = fmt.Sprintf(", attempts=%d", n)
+	}
+
+	var connwait string
+	if wait := r.ConnWait(); wait > 0 {
+		connwait = ", connwait=" + wait.String()
+	}
+
+	var phase string
+	if p := r.FailurePhase(); p != "" {
+		phase = ", phase=" + string(p)
+	}
+
+	var reqid string
+	if id := r.ServerRequestID(); id != "" {
+		reqid = ", requestid=" + id
+	}
+
+	var labels string
+	if v := r.Labels(); len(v) > 0 {
+		labels = fmt.Sprintf(", labels=%v", v)
+	}
+
+	log.Printf("log the http request: method=%s, url=%s, statuscode=%d, cost=%s, err=%s%s%s%s%s%s%s",
+		r.Method(), r.FinalURL(), r.StatusCode(), r.Cost().String(), r.Error(), reqid, connwait, phase, sampled, attempts, labels)
+}
+
+func logRequestWarning(req *http.Request, msg string, err error) {
+	var errmsg string
+	if err != nil {
+		errmsg = ", err=" + err.Error()
+	}
+	log.Printf("%s: method=%s, url=%s%s", msg, req.Method, redactRequestURL(req), errmsg)
 }
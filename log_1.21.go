@@ -18,11 +18,11 @@
 package httpclient
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"unsafe"
 )
 
@@ -37,11 +37,7 @@ func logOnResponse(r *Response) { _logOnResponse(r, slog.LevelDebug, nil) }
 
 func _logOnResponse(r *Response, level slog.Level,
 	appendAttrs func(r *Response, kvs []slog.Attr) []slog.Attr) {
-	ctx := context.Background()
-	if r.req != nil {
-		ctx = r.req.Context()
-	}
-
+	ctx := r.Context()
 	if !slog.Default().Enabled(ctx, level) {
 		return
 	}
@@ -49,12 +45,20 @@ func _logOnResponse(r *Response, level slog.Level,
 	kvs := make([]slog.Attr, 0, 10)
 	kvs = append(kvs,
 		slog.String("method", r.Method()),
-		slog.String("url", r.Url()),
+		slog.String("url", r.FinalURL()),
 	)
 
 	if r.req != nil {
 		kvs = append(kvs, slog.Any("reqheaders", r.req.Header))
-		if ct := GetContentType(r.req.Header); _logreqbody(ct) {
+		if p, ok := r.ReqBody().(BodyProvider); ok {
+			kvs = append(kvs, slog.String("reqbody", describeBodyProvider(p)))
+		} else if f := r.logBodyFormatter(); f != nil {
+			if body := r.ReqBody(); body != nil {
+				if v := f(GetContentType(r.req.Header), body); !v.Equal(slog.Value{}) {
+					kvs = append(kvs, slog.Attr{Key: "reqbody", Value: v})
+				}
+			}
+		} else if ct := GetContentType(r.req.Header); _logreqbody(ct) {
 			switch body := r.ReqBody().(type) {
 			case string:
 				data := unsafe.Slice(unsafe.StringData(body), len(body))
@@ -79,6 +83,29 @@ func _logOnResponse(r *Response, level slog.Level,
 
 	if r.resp != nil {
 		kvs = append(kvs, slog.Any("respheaders", r.resp.Header))
+		if id := getServerRequestID(r.resp.Header); id != "" {
+			kvs = append(kvs, slog.String("requestid", id))
+		}
+	}
+
+	if wait := r.ConnWait(); wait > 0 {
+		kvs = append(kvs, slog.String("connwait", wait.String()))
+	}
+
+	if phase := r.FailurePhase(); phase != "" {
+		kvs = append(kvs, slog.String("phase", string(phase)))
+	}
+
+	if sampled, ok := r.Sampled(); ok {
+		kvs = append(kvs, slog.Bool("sampled", sampled))
+	}
+
+	if attempts := r.Attempts(); attempts > 1 {
+		kvs = append(kvs, slog.Int("attempts", attempts))
+	}
+
+	if labels := r.Labels(); len(labels) > 0 {
+		kvs = append(kvs, slog.Any("labels", labels))
 	}
 
 	if appendAttrs != nil {
@@ -110,3 +137,14 @@ func _logreqbody(ct string) bool {
 		return false
 	}
 }
+
+func logRequestWarning(req *http.Request, msg string, err error) {
+	attrs := []any{
+		slog.String("method", req.Method),
+		slog.String("url", redactRequestURL(req)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("err", err))
+	}
+	slog.WarnContext(req.Context(), msg, attrs...)
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package httpclient
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// LogBodyFormatter is used to format the request body in the response log.
+//
+// If returning the zero slog.Value, the request body won't be logged.
+type LogBodyFormatter func(contentType string, body any) slog.Value
+
+// SetLogBodyFormatter sets the formatter to format the request body
+// in the response log, which may be used to redact the sensitive fields,
+// truncate the large body, or disable the body logging by the content type.
+//
+// If formatter is nil, clear it and use the default behavior, which only
+// logs the body of JSON, XML, form and plain text.
+//
+// Default: nil
+func (c *Client) SetLogBodyFormatter(formatter LogBodyFormatter) *Client {
+	if formatter == nil {
+		c.logbodyfmt = nil
+	} else {
+		c.logbodyfmt = formatter
+	}
+	return c
+}
+
+func (r *Response) logBodyFormatter() LogBodyFormatter {
+	f, _ := r.logbodyfmt.(LogBodyFormatter)
+	return f
+}
+
+// RedactLogBody is a LogBodyFormatter to redact the fields of the struct body,
+// which replaces the values of the struct fields with the tag `log:"-"`
+// with RedactedValue recursively, including the structs in the pointers,
+// slices and maps.
+//
+// The string and []byte bodies are logged as they are. The struct fields
+// are named by the json tag like the json encoding, and the values
+// implementing json.Marshaler, encoding.TextMarshaler or fmt.Stringer
+// are logged as they are, such as time.Time.
+func RedactLogBody(contentType string, body any) slog.Value {
+	switch v := body.(type) {
+	case nil:
+		return slog.Value{}
+	case string:
+		return slog.StringValue(v)
+	case []byte:
+		return slog.StringValue(string(v))
+	case json.RawMessage:
+		return slog.AnyValue(v)
+	default:
+		return slog.AnyValue(redactLogValue(reflect.ValueOf(body), 0))
+	}
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	fmtStringerType   = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// maxRedactLogDepth is the maximum depth to redact the nested values,
+// which avoids the infinite recursion of the cyclic pointers.
+const maxRedactLogDepth = 32
+
+func redactLogValue(v reflect.Value, depth int) any {
+	if !v.IsValid() {
+		return nil
+	} else if depth++; depth > maxRedactLogDepth {
+		return "..."
+	}
+
+	switch typ := v.Type(); typ.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if typ.Kind() == reflect.Ptr && isLoggedAsIs(typ) {
+			return v.Interface()
+		}
+		return redactLogValue(v.Elem(), depth)
+
+	case reflect.Struct:
+		if isLoggedAsIs(typ) {
+			return v.Interface()
+		}
+
+		fields := make(map[string]any, v.NumField())
+		redactLogStruct(fields, v, depth)
+		return fields
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+
+		values := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			values[fmt.Sprint(iter.Key().Interface())] = redactLogValue(iter.Value(), depth)
+		}
+		return values
+
+	case reflect.Slice, reflect.Array:
+		if typ.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		} else if typ.Elem().Kind() == reflect.Uint8 && typ.Kind() == reflect.Slice {
+			return string(v.Bytes())
+		}
+
+		values := make([]any, v.Len())
+		for i := range values {
+			values[i] = redactLogValue(v.Index(i), depth)
+		}
+		return values
+
+	default:
+		return v.Interface()
+	}
+}
+
+func isLoggedAsIs(typ reflect.Type) bool {
+	return typ.Implements(jsonMarshalerType) ||
+		typ.Implements(textMarshalerType) ||
+		typ.Implements(fmtStringerType)
+}
+
+func redactLogStruct(fields map[string]any, v reflect.Value, depth int) {
+	typ := v.Type()
+	for i, _len := 0, typ.NumField(); i < _len; i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+
+			if fv.Kind() == reflect.Struct && !isLoggedAsIs(fv.Type()) {
+				redactLogStruct(fields, fv, depth)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if field.Tag.Get("log") == "-" {
+			fields[name] = RedactedValue
+		} else {
+			fields[name] = redactLogValue(v.Field(i), depth)
+		}
+	}
+}
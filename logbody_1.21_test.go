@@ -0,0 +1,108 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type logBodyLogin struct {
+	Username string `json:"username"`
+	Password string `json:"password" log:"-"`
+	Ignored  string `json:"-"`
+	internal string
+
+	Time   time.Time          `json:"time"`
+	Tokens []logBodyToken     `json:"tokens,omitempty"`
+	Extra  map[string]*string `json:"extra,omitempty"`
+}
+
+type logBodyToken struct {
+	Name  string `json:"name"`
+	Value string `json:"value" log:"-"`
+}
+
+func TestRedactLogBody(t *testing.T) {
+	now := time.Now()
+	body := &logBodyLogin{
+		Username: "user",
+		Password: "pass",
+		Ignored:  "ignored",
+		internal: "internal",
+
+		Time:   now,
+		Tokens: []logBodyToken{{Name: "token", Value: "secret"}},
+		Extra:  map[string]*string{"key": nil},
+	}
+
+	expect := map[string]any{
+		"username": "user",
+		"password": RedactedValue,
+		"time":     now,
+		"tokens":   []any{map[string]any{"name": "token", "value": RedactedValue}},
+		"extra":    map[string]any{"key": nil},
+	}
+	if v := RedactLogBody(MIMEApplicationJSON, body).Any(); !reflect.DeepEqual(expect, v) {
+		t.Errorf("expect %v, but got %v", expect, v)
+	}
+
+	if v := RedactLogBody(MIMEApplicationJSON, []byte("abc")); v.String() != "abc" {
+		t.Errorf("expect '%s', but got '%s'", "abc", v.String())
+	}
+
+	if v := RedactLogBody(MIMEApplicationJSON, nil); !v.Equal(slog.Value{}) {
+		t.Errorf("expect the zero value, but got %v", v)
+	}
+}
+
+func TestClientSetLogBodyFormatter(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	client := NewClient(nil).SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	body := logBodyLogin{Username: "user", Password: "pass"}
+	client.Post("http://127.0.0.1").SetBody(body).Do(context.Background(), nil).Close()
+	if s := buf.String(); !strings.Contains(s, "Password:pass") {
+		t.Errorf("expect the password logged by default, but got '%s'", s)
+	}
+
+	buf.Reset()
+	client.SetLogBodyFormatter(RedactLogBody)
+	client.Post("http://127.0.0.1").SetBody(body).Do(context.Background(), nil).Close()
+	if s := buf.String(); strings.Contains(s, "password:pass") || !strings.Contains(s, "password:***") {
+		t.Errorf("expect the password redacted, but got '%s'", s)
+	}
+
+	buf.Reset()
+	client.SetLogBodyFormatter(func(string, any) slog.Value { return slog.Value{} })
+	client.Post("http://127.0.0.1").SetBody(body).Do(context.Background(), nil).Close()
+	if s := buf.String(); strings.Contains(s, "reqbody=") {
+		t.Errorf("expect no request body logged, but got '%s'", s)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// StrictMethodBody sets whether to check the request body by the method.
+//
+// If true, the request with the body fails before sending if the method
+// is GET, HEAD, OPTIONS or DELETE, unless AllowDeleteBody is enabled
+// for DELETE. And a warning is logged if the request with the method
+// POST, PUT or PATCH is sent without the body, unless the request
+// has called AllowEmptyBody.
+//
+// Default: false
+func (c *Client) StrictMethodBody(strict bool) *Client {
+	c.strictbody = strict
+	return c
+}
+
+// AllowDeleteBody sets whether to allow the body of the DELETE request
+// in the strict method body mode, since some APIs take the body.
+//
+// Default: false
+func (c *Client) AllowDeleteBody(allow bool) *Client {
+	c.deletebody = allow
+	return c
+}
+
+// AllowEmptyBody allows the request with the method POST, PUT or PATCH
+// to be sent without the body in the strict method body mode.
+func (r *Request) AllowEmptyBody() *Request {
+	r.emptybody = true
+	return r
+}
+
+// checkMethodBody checks whether the request body is allowed by the method.
+func (r *Request) checkMethodBody() error {
+	if !r.strictbody || r.body == nil {
+		return nil
+	}
+
+	switch method := strings.ToUpper(r.method); method {
+	case http.MethodDelete:
+		if r.deletebody {
+			return nil
+		}
+		fallthrough
+
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return fmt.Errorf("the request body is not allowed for the method %s", method)
+
+	default:
+		return nil
+	}
+}
+
+// warnEmptyBody logs a warning if the request should have the body.
+func (r *Request) warnEmptyBody(req *http.Request) {
+	if !r.strictbody || r.emptybody || r.body != nil {
+		return
+	}
+
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		logRequestWarning(req, "send the request without the body", nil)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStrictMethodBody(t *testing.T) {
+	var logbuf bytes.Buffer
+	log.SetOutput(&logbuf)
+	defer log.SetOutput(os.Stderr)
+
+	client := NewClient(nil).OnResponse(nil).StrictMethodBody(true)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	tests := []struct {
+		Method string
+		Body   bool
+		Error  bool
+		Warn   bool
+	}{
+		{Method: http.MethodGet, Body: false},
+		{Method: http.MethodGet, Body: true, Error: true},
+		{Method: http.MethodHead, Body: false},
+		{Method: http.MethodHead, Body: true, Error: true},
+		{Method: http.MethodOptions, Body: false},
+		{Method: http.MethodOptions, Body: true, Error: true},
+		{Method: http.MethodDelete, Body: false},
+		{Method: http.MethodDelete, Body: true, Error: true},
+		{Method: http.MethodPost, Body: true},
+		{Method: http.MethodPost, Body: false, Warn: true},
+		{Method: http.MethodPut, Body: true},
+		{Method: http.MethodPut, Body: false, Warn: true},
+		{Method: http.MethodPatch, Body: true},
+		{Method: http.MethodPatch, Body: false, Warn: true},
+	}
+
+	for _, test := range tests {
+		logbuf.Reset()
+		req := client.Request(test.Method, "http://127.0.0.1")
+		if test.Body {
+			req.SetBody(map[string]string{"a": "b"})
+		}
+
+		err := req.Do(context.Background(), nil).Unwrap()
+		if test.Error && err == nil {
+			t.Errorf("%s with body=%v: expect an error, but got nil", test.Method, test.Body)
+		} else if !test.Error && err != nil {
+			t.Errorf("%s with body=%v: unexpected error: %v", test.Method, test.Body, err)
+		}
+
+		if warned := strings.Contains(logbuf.String(), "without the body"); warned != test.Warn {
+			t.Errorf("%s with body=%v: expect warned=%v, but got %v", test.Method, test.Body, test.Warn, warned)
+		}
+	}
+
+	logbuf.Reset()
+	err := client.Post("http://127.0.0.1").AllowEmptyBody().Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if logbuf.Len() > 0 {
+		t.Errorf("unexpected warning: %s", logbuf.String())
+	}
+
+	client.AllowDeleteBody(true)
+	err = client.Delete("http://127.0.0.1").SetBody("body").Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Error(err)
+	}
+
+	client.StrictMethodBody(false)
+	err = client.Get("http://127.0.0.1").SetBody("body").Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+// Middleware is used to wrap the doer to send the http request,
+// which can see the raw http request and response, such as retrying,
+// recording the metrics, caching or logging.
+type Middleware func(next Doer) Doer
+
+// Use appends the middlewares to wrap the doer of the client,
+// in which the middleware registered first is the outermost.
+//
+// The middlewares are kept when the doer is reset by SetDoer or SetHTTPClient,
+// and GetDoer and GetHTTPClient still return the inner doer not wrapped.
+func (c *Client) Use(mws ...Middleware) *Client {
+	for _, mw := range mws {
+		if mw == nil {
+			panic("Client.Use: the middleware must not be nil")
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.middlewares = append(c.middlewares, mws...)
+	c.updateChain()
+	return c
+}
+
+// updateChain wraps the doer by the middlewares.
+func (c *Client) updateChain() {
+	c.chain = c.doer
+	if c.chain == nil {
+		return
+	} else if c.client != nil && c.hostguard.enabled() {
+		c.chain = c.hostguard.guardRedirect(c.client)
+	}
+
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		if c.chain = c.middlewares[i](c.chain); c.chain == nil {
+			panic("Client.Use: the middleware must not return nil")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClientUse(t *testing.T) {
+	var calls []string
+	newMiddleware := func(name string) Middleware {
+		return func(next Doer) Doer {
+			return DoFunc(func(r *http.Request) (*http.Response, error) {
+				calls = append(calls, name+".before")
+				resp, err := next.Do(r)
+				calls = append(calls, name+".after")
+				return resp, err
+			})
+		}
+	}
+
+	doer := DoFunc(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, "doer")
+		return &http.Response{StatusCode: 204, Header: http.Header{}, Body: http.NoBody, Request: r}, nil
+	})
+
+	client := NewClient(nil).OnResponse(nil).SetDoer(doer).Use(newMiddleware("mw1"), newMiddleware("mw2"))
+	if err := client.Get("http://localhost").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := "mw1.before,mw2.before,doer,mw2.after,mw1.after"
+	if s := strings.Join(calls, ","); s != expect {
+		t.Errorf("expect calls '%s', but got '%s'", expect, s)
+	}
+
+	if _, ok := client.GetDoer().(DoFunc); !ok {
+		t.Errorf("expect the inner doer DoFunc, but got %T", client.GetDoer())
+	}
+
+	// The clone does not affect the original client.
+	calls = calls[:0]
+	clone := client.Clone().Use(newMiddleware("mw3"))
+	if err := clone.Get("http://localhost").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+	expect = "mw1.before,mw2.before,mw3.before,doer,mw3.after,mw2.after,mw1.after"
+	if s := strings.Join(calls, ","); s != expect {
+		t.Errorf("expect calls '%s', but got '%s'", expect, s)
+	}
+
+	calls = calls[:0]
+	if err := client.Get("http://localhost").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+	expect = "mw1.before,mw2.before,doer,mw2.after,mw1.after"
+	if s := strings.Join(calls, ","); s != expect {
+		t.Errorf("expect calls '%s', but got '%s'", expect, s)
+	}
+
+	// The middlewares are kept when resetting the http client.
+	client.SetHTTPClient(http.DefaultClient)
+	if client.GetHTTPClient() != http.DefaultClient {
+		t.Errorf("expect the inner http client")
+	} else if len(client.middlewares) != 2 || client.chain == client.doer {
+		t.Errorf("expect the doer to be wrapped by the middlewares")
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MockTestingT is the interface of *testing.T used by MockDoer.
+type MockTestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// MockDoer is a doer to serve the canned responses for the expected
+// requests, which is used to test the code sending the requests
+// without the real server.
+//
+// The request is matched against the expectations in the registration order.
+// If no expectation matches, the response is 500 with the body listing
+// all the registered expectations.
+type MockDoer struct {
+	lock      sync.Mutex
+	expects   []*MockExpectation
+	unmatched []string
+}
+
+// NewMockDoer returns a new mock doer.
+func NewMockDoer() *MockDoer { return new(MockDoer) }
+
+// Expect registers and returns a new expectation of the request
+// with the method and the url path, such as "/v1/users".
+//
+// The expectation replies 200 with no body by default.
+func (m *MockDoer) Expect(method, path string) *MockExpectation {
+	e := &MockExpectation{method: method, path: path, code: http.StatusOK}
+	m.lock.Lock()
+	m.expects = append(m.expects, e)
+	m.lock.Unlock()
+	return e
+}
+
+// Do implements the interface Doer.
+func (m *MockDoer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, e := range m.expects {
+		if e.match(req, body) {
+			e.calls++
+			if e.err != nil {
+				return nil, e.err
+			}
+
+			header := cloneHeader(e.respheader)
+			if header == nil {
+				header = make(http.Header, 1)
+			}
+			return newStaticResponse(req, e.code, header, e.respbody), nil
+		}
+	}
+
+	m.unmatched = append(m.unmatched, req.Method+" "+req.URL.RequestURI())
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "no mock expectation matches the request '%s %s', expectations:",
+		req.Method, req.URL.RequestURI())
+	for _, e := range m.expects {
+		buf.WriteString("\n  ")
+		buf.WriteString(e.String())
+	}
+
+	header := http.Header{HeaderContentType: []string{"text/plain; charset=utf-8"}}
+	return newStaticResponse(req, http.StatusInternalServerError, header, buf.Bytes()), nil
+}
+
+// AssertExpectations reports the error by t if any expectation is not
+// satisfied or any request is unmatched, and returns whether all is ok.
+func (m *MockDoer) AssertExpectations(t MockTestingT) (ok bool) {
+	t.Helper()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ok = true
+	for _, e := range m.expects {
+		switch {
+		case e.times > 0 && e.calls != e.times:
+			t.Errorf("mock expectation '%s' is expected to be called %d times, but got %d", e.String(), e.times, e.calls)
+			ok = false
+		case e.calls == 0:
+			t.Errorf("mock expectation '%s' is not called", e.String())
+			ok = false
+		}
+	}
+
+	for _, req := range m.unmatched {
+		t.Errorf("unexpected request '%s'", req)
+		ok = false
+	}
+
+	return
+}
+
+// MockExpectation is an expectation of the request registered by MockDoer.
+//
+// It must be configured before sending the requests.
+type MockExpectation struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	bodyf  func([]byte) bool
+	times  int
+	calls  int
+
+	code       int
+	respheader http.Header
+	respbody   []byte
+	err        error
+}
+
+// WithQuery expects the request to have the query with the value.
+func (e *MockExpectation) WithQuery(key, value string) *MockExpectation {
+	if e.query == nil {
+		e.query = make(url.Values, 4)
+	}
+	e.query.Add(key, value)
+	return e
+}
+
+// WithHeader expects the request to have the header with the value.
+func (e *MockExpectation) WithHeader(key, value string) *MockExpectation {
+	if e.header == nil {
+		e.header = make(http.Header, 4)
+	}
+	e.header.Add(key, value)
+	return e
+}
+
+// WithBody expects the request body to satisfy the predicate.
+func (e *MockExpectation) WithBody(match func(body []byte) bool) *MockExpectation {
+	e.bodyf = match
+	return e
+}
+
+// Times expects the request to be matched exactly n times, and the further
+// requests are not matched by it. If n is 0, it may be matched any times
+// but at least once.
+//
+// Default: 0
+func (e *MockExpectation) Times(n int) *MockExpectation {
+	e.times = n
+	return e
+}
+
+// Reply sets the response of the expectation.
+func (e *MockExpectation) Reply(code int, header http.Header, body []byte) *MockExpectation {
+	if header == nil {
+		header = make(http.Header, 2)
+	}
+	e.code, e.respheader, e.respbody, e.err = code, header, body, nil
+	return e
+}
+
+// ReplyJSON sets the response of the expectation with the body
+// encoded from v as JSON.
+func (e *MockExpectation) ReplyJSON(code int, v interface{}) *MockExpectation {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Errorf("MockExpectation.ReplyJSON: %s", err))
+	}
+
+	header := http.Header{HeaderContentType: []string{MIMEApplicationJSON}}
+	return e.Reply(code, header, body)
+}
+
+// ReplyError sets the error returned instead of the response,
+// which is used to simulate the network failure.
+func (e *MockExpectation) ReplyError(err error) *MockExpectation {
+	if err == nil {
+		panic("MockExpectation.ReplyError: the error must not be nil")
+	}
+	e.err = err
+	return e
+}
+
+func (e *MockExpectation) match(req *http.Request, body []byte) bool {
+	if e.times > 0 && e.calls >= e.times {
+		return false
+	} else if req.Method != e.method || req.URL.Path != e.path {
+		return false
+	}
+
+	if len(e.query) > 0 {
+		query := req.URL.Query()
+		for key, values := range e.query {
+			if !containStrings(query[key], values) {
+				return false
+			}
+		}
+	}
+
+	for key, values := range e.header {
+		if !containStrings(req.Header[key], values) {
+			return false
+		}
+	}
+
+	return e.bodyf == nil || e.bodyf(body)
+}
+
+func containStrings(ss, subs []string) bool {
+	for _, sub := range subs {
+		var found bool
+		for _, s := range ss {
+			if s == sub {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *MockExpectation) String() string {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.WriteString(e.method)
+	buf.WriteByte(' ')
+	buf.WriteString(e.path)
+	if len(e.query) > 0 {
+		buf.WriteByte('?')
+		buf.WriteString(e.query.Encode())
+	}
+
+	keys := make([]string, 0, len(e.header))
+	for key := range e.header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(buf, " [%s: %s]", key, strings.Join(e.header[key], ", "))
+	}
+
+	if e.bodyf != nil {
+		buf.WriteString(" [body]")
+	}
+	if e.times > 0 {
+		fmt.Fprintf(buf, " (times=%d)", e.times)
+	}
+	return buf.String()
+}
+
+// newStaticResponse returns a new http response with the static body.
+func newStaticResponse(req *http.Request, code int, header http.Header, body []byte) *http.Response {
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type mockTestingT struct{ errs []string }
+
+func (t *mockTestingT) Helper() {}
+func (t *mockTestingT) Errorf(format string, args ...interface{}) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+func TestMockDoer(t *testing.T) {
+	m := NewMockDoer()
+	m.Expect(http.MethodGet, "/v1/users").WithQuery("id", "3").
+		ReplyJSON(200, map[string]interface{}{"id": 3, "name": "Aaron"})
+	m.Expect(http.MethodPost, "/v1/users").WithHeader("X-Token", "abc").
+		WithBody(func(body []byte) bool { return bytes.Contains(body, []byte(`"name":"Bob"`)) }).
+		ReplyJSON(201, map[string]interface{}{"id": 4}).Times(1)
+	m.Expect(http.MethodDelete, "/v1/users").ReplyError(errors.New("connection reset"))
+
+	client := NewClient(nil).OnResponse(nil).SetDoer(m)
+
+	var user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	err := client.Get("http://localhost/v1/users").AddQuery("id", "3").
+		Do(context.Background(), &user).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	} else if user.ID != 3 || user.Name != "Aaron" {
+		t.Errorf("unexpected user %+v", user)
+	}
+
+	err = client.Post("http://localhost/v1/users").SetHeader("X-Token", "abc").
+		SetBody(map[string]string{"name": "Bob"}).Do(context.Background(), &user).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	} else if user.ID != 4 {
+		t.Errorf("expect user id %d, but got %d", 4, user.ID)
+	}
+
+	err = client.Delete("http://localhost/v1/users").Do(context.Background(), nil).Unwrap()
+	if e, ok := err.(Error); !ok || e.Err == nil || e.Err.Error() != "connection reset" {
+		t.Errorf("expect the error 'connection reset', but got %v", err)
+	}
+
+	mt := new(mockTestingT)
+	if !m.AssertExpectations(mt) {
+		t.Errorf("unexpected errors: %v", mt.errs)
+	}
+
+	// The expectation with Times(1) is exhausted.
+	err = client.Post("http://localhost/v1/users").SetHeader("X-Token", "abc").
+		SetBody(map[string]string{"name": "Bob"}).Do(context.Background(), nil).Unwrap()
+	if e, ok := err.(Error); !ok || e.Code != 500 {
+		t.Errorf("expect the 500 error, but got %v", err)
+	} else if !strings.Contains(e.Data, "GET /v1/users?id=3") ||
+		!strings.Contains(e.Data, "POST /v1/users [X-Token: abc] [body] (times=1)") {
+		t.Errorf("expect the error to list the expectations, but got '%s'", e.Data)
+	}
+
+	mt = new(mockTestingT)
+	if m.AssertExpectations(mt) {
+		t.Errorf("expect the assertion to fail")
+	} else if len(mt.errs) != 1 || mt.errs[0] != "unexpected request 'POST /v1/users'" {
+		t.Errorf("unexpected errors: %v", mt.errs)
+	}
+}
+
+func TestMockDoerAssertExpectations(t *testing.T) {
+	m := NewMockDoer()
+	m.Expect(http.MethodGet, "/a")
+	m.Expect(http.MethodGet, "/b").Times(2)
+
+	client := NewClient(nil).OnResponse(nil).SetDoer(m)
+	if err := client.Get("http://localhost/b").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	mt := new(mockTestingT)
+	m.AssertExpectations(mt)
+	expects := []string{
+		"mock expectation 'GET /a' is not called",
+		"mock expectation 'GET /b (times=2)' is expected to be called 2 times, but got 1",
+	}
+	if strings.Join(mt.errs, "\n") != strings.Join(expects, "\n") {
+		t.Errorf("expect errors %q, but got %q", expects, mt.errs)
+	}
+}
@@ -0,0 +1,410 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type multipartPart struct {
+	field    string
+	filename string
+	ctype    string
+
+	value  string           // For the field
+	path   string           // For the file
+	reader *multipartReader // For the reader
+	size   int64
+}
+
+// multipartReader buffers the data of the reader while it is read
+// for the first time, so that the body containing it can be replayed.
+type multipartReader struct {
+	lock   sync.Mutex
+	reader io.Reader
+	data   []byte
+	err    error
+	eof    bool
+}
+
+// WriteTo writes the buffered data, then continues to read the rest
+// of the reader, which is buffered and written.
+func (r *multipartReader) WriteTo(w io.Writer) (n int64, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	m, err := w.Write(r.data)
+	if n = int64(m); err != nil || r.eof {
+		return
+	} else if r.err != nil {
+		return n, r.err
+	}
+
+	buf := make([]byte, 32*1024)
+	for {
+		m, rerr := r.reader.Read(buf)
+		if m > 0 {
+			r.data = append(r.data, buf[:m]...)
+			m, err = w.Write(buf[:m])
+			if n += int64(m); err != nil {
+				return
+			}
+		}
+
+		if rerr != nil {
+			if rerr == io.EOF {
+				r.eof = true
+			} else {
+				r.err, err = rerr, rerr
+			}
+
+			if c, ok := r.reader.(io.Closer); ok {
+				_ = c.Close()
+			}
+			return
+		}
+	}
+}
+
+// Multipart is a builder of the multipart/form-data body, which implements
+// the interface BodyProvider and streams the parts through an io.Pipe
+// instead of buffering the whole body into the memory, except the data
+// of the readers added by AddReader.
+//
+// For the default, the length of the body is unknown and the body is sent
+// in chunked. But the exact length can be computed by ComputeLength
+// if the sizes of all the parts are known.
+type Multipart struct {
+	lock     sync.Mutex
+	parts    []multipartPart
+	boundary string
+	compute  bool
+}
+
+// NewMultipart returns a new multipart/form-data body builder.
+func NewMultipart() *Multipart {
+	return &Multipart{boundary: multipart.NewWriter(ioutil.Discard).Boundary()}
+}
+
+// ComputeLength sets whether to compute the exact length of the body
+// to send the header Content-Length instead of the chunked body.
+//
+// If the size of any reader part is unknown, the length is still unknown.
+func (m *Multipart) ComputeLength(compute bool) *Multipart {
+	m.compute = compute
+	return m
+}
+
+// AddField adds a form field.
+func (m *Multipart) AddField(name, value string) *Multipart {
+	m.parts = append(m.parts, multipartPart{field: name, value: value, size: int64(len(value))})
+	return m
+}
+
+// AddFile adds a form file read from the file path, which is opened
+// and streamed for each sending.
+//
+// If contentType is empty, use "application/octet-stream" instead.
+func (m *Multipart) AddFile(field, path, contentType string) *Multipart {
+	m.parts = append(m.parts, multipartPart{
+		field:    field,
+		filename: filepath.Base(path),
+		ctype:    contentType,
+		path:     path,
+		size:     -1,
+	})
+	return m
+}
+
+// AddReader adds a form file read from the reader, which will be closed
+// after being read to the end if it implements the interface io.Closer.
+//
+// size is the size of the data of the reader, which is -1 if unknown.
+// If contentType is empty, use "application/octet-stream" instead.
+//
+// Notice: the reader can be read only once, so its data is buffered into
+// the memory while it is read for the first time, so that the body can be
+// replayed, such as the preview of DryRun and the redirects.
+func (m *Multipart) AddReader(field, filename, contentType string, r io.Reader, size int64) *Multipart {
+	m.parts = append(m.parts, multipartPart{
+		field:    field,
+		filename: filename,
+		ctype:    contentType,
+		reader:   &multipartReader{reader: r},
+		size:     size,
+	})
+	return m
+}
+
+// ContentType implements the interface BodyProvider.
+func (m *Multipart) ContentType() string {
+	return "multipart/form-data; boundary=" + m.boundary
+}
+
+// String implements the interface fmt.Stringer.
+func (m *Multipart) String() string {
+	fields := make([]string, len(m.parts))
+	for i, part := range m.parts {
+		fields[i] = part.field
+	}
+	return fmt.Sprintf("multipart(%s)", strings.Join(fields, ","))
+}
+
+// Body implements the interface BodyProvider.
+func (m *Multipart) Body() (body io.ReadCloser, length int64, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	length = -1
+	if m.compute {
+		if length, err = m.computeLength(); err != nil {
+			return
+		}
+	}
+
+	pr, pw := io.Pipe()
+	go func() { _ = pw.CloseWithError(m.writeTo(pw)) }()
+	return pr, length, nil
+}
+
+func (m *Multipart) newWriter(w io.Writer) *multipart.Writer {
+	mw := multipart.NewWriter(w)
+	_ = mw.SetBoundary(m.boundary)
+	return mw
+}
+
+func (m *Multipart) createPart(mw *multipart.Writer, part multipartPart) (io.Writer, error) {
+	if part.path == "" && part.reader == nil {
+		return mw.CreateFormField(part.field)
+	}
+
+	ctype := part.ctype
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+
+	header := make(textproto.MIMEHeader, 2)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(part.field), escapeQuotes(part.filename)))
+	header.Set("Content-Type", ctype)
+	return mw.CreatePart(header)
+}
+
+func (m *Multipart) computeLength() (length int64, err error) {
+	var w countWriter
+	mw := m.newWriter(&w)
+	for _, part := range m.parts {
+		size := part.size
+		if part.path != "" {
+			var info os.FileInfo
+			if info, err = os.Stat(part.path); err != nil {
+				return
+			}
+			size = info.Size()
+		}
+
+		if size < 0 {
+			return -1, nil
+		}
+
+		if _, err = m.createPart(mw, part); err != nil {
+			return
+		}
+		w += countWriter(size)
+	}
+
+	if err = mw.Close(); err == nil {
+		length = int64(w)
+	}
+	return
+}
+
+func (m *Multipart) writeTo(w io.Writer) (err error) {
+	mw := m.newWriter(w)
+	for _, part := range m.parts {
+		var pw io.Writer
+		if pw, err = m.createPart(mw, part); err != nil {
+			return
+		}
+
+		switch {
+		case part.path != "":
+			err = copyFile(pw, part.path)
+		case part.reader != nil:
+			_, err = part.reader.WriteTo(pw)
+		default:
+			_, err = io.WriteString(pw, part.value)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+
+	return mw.Close()
+}
+
+func copyFile(w io.Writer, path string) (err error) {
+	file, err := os.Open(path)
+	if err == nil {
+		_, err = io.CopyBuffer(w, file, make([]byte, 32*1024))
+		_ = file.Close()
+	}
+	return
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string { return quoteEscaper.Replace(s) }
+
+type countWriter int64
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	*w += countWriter(len(p))
+	return len(p), nil
+}
+
+// Multipart validates the response Content-Type, such as "multipart/form-data"
+// or "multipart/mixed", with the boundary, and returns the multipart reader
+// of the response body.
+//
+// Notice: the caller should close the response body after reading the parts.
+func (r *Response) Multipart() (*multipart.Reader, error) {
+	if r.resp == nil {
+		return nil, r.Result()
+	}
+
+	ct := r.resp.Header.Get(HeaderContentType)
+	mediatype, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart response Content-Type '%s': %s", ct, err)
+	} else if !strings.HasPrefix(mediatype, "multipart/") {
+		return nil, fmt.Errorf("unexpected multipart response Content-Type '%s'", ct)
+	} else if params["boundary"] == "" {
+		return nil, fmt.Errorf("no boundary in the multipart response Content-Type '%s'", ct)
+	}
+
+	return multipart.NewReader(r.resp.Body, params["boundary"]), nil
+}
+
+// MultipartPart is the specification of a named part of the multipart response.
+type MultipartPart struct {
+	// Name is the form name of the part in the header "Content-Disposition".
+	Name string
+
+	// Dst is the destination of the part data, which may be
+	//   - io.Writer: copy the part data into it.
+	//   - *[]byte: read all the part data into it.
+	//   - others: decode the part data into it by the part Content-Type,
+	//     such as a struct pointer, which is json if no Content-Type.
+	Dst interface{}
+
+	// Required reports whether the part must be present.
+	Required bool
+}
+
+// MultipartSpec is the specification of the multipart response.
+type MultipartSpec struct {
+	Parts []MultipartPart
+
+	// IgnoreUnknown reports whether to discard the parts not in Parts.
+	// If false, the unknown part results in an error.
+	IgnoreUnknown bool
+}
+
+// DecodeMultipart reads the multipart response body and maps the named parts
+// to the destinations by spec, then closes the response body.
+//
+// It returns an error if a required part is missing, a part occurs more than
+// once, or an unknown part occurs and spec.IgnoreUnknown is false.
+func (r *Response) DecodeMultipart(spec MultipartSpec) (err error) {
+	reader, err := r.Multipart()
+	if err != nil {
+		return
+	}
+	defer r.close()
+
+	found := make([]bool, len(spec.Parts))
+	for {
+		var part *multipart.Part
+		if part, err = reader.NextPart(); err == io.EOF {
+			break
+		} else if err != nil {
+			return
+		}
+
+		name := part.FormName()
+		index := -1
+		for i := range spec.Parts {
+			if spec.Parts[i].Name == name {
+				index = i
+				break
+			}
+		}
+
+		switch {
+		case index < 0 && spec.IgnoreUnknown:
+		case index < 0:
+			err = fmt.Errorf("unknown multipart part '%s'", name)
+		case found[index]:
+			err = fmt.Errorf("duplicated multipart part '%s'", name)
+		default:
+			found[index] = true
+			if err = decodeMultipartPart(spec.Parts[index].Dst, part); err != nil {
+				err = fmt.Errorf("fail to decode multipart part '%s': %s", name, err)
+			}
+		}
+
+		_ = part.Close()
+		if err != nil {
+			return
+		}
+	}
+
+	for i, part := range spec.Parts {
+		if part.Required && !found[i] {
+			return fmt.Errorf("missing the required multipart part '%s'", part.Name)
+		}
+	}
+
+	return nil
+}
+
+func decodeMultipartPart(dst interface{}, part *multipart.Part) (err error) {
+	switch v := dst.(type) {
+	case nil:
+	case io.Writer:
+		_, err = io.Copy(v, part)
+	case *[]byte:
+		*v, err = ioutil.ReadAll(part)
+	default:
+		ct := GetContentType(http.Header(part.Header))
+		if ct == "" {
+			ct = MIMEApplicationJSON
+		}
+		err = DecodeFromReader(dst, ct, part)
+	}
+	return
+}
@@ -0,0 +1,259 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newMultipartServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ignore" {
+			w.WriteHeader(200)
+			return
+		}
+
+		reader, err := r.MultipartReader()
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+
+		var results []string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				w.WriteHeader(400)
+				return
+			}
+
+			data, _ := ioutil.ReadAll(part)
+			results = append(results, fmt.Sprintf("%s:%s:%d", part.FormName(), part.FileName(), len(data)))
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%d;%s", r.ContentLength, strings.Join(results, ","))
+	}))
+}
+
+func TestMultipart(t *testing.T) {
+	server := newMultipartServer()
+	defer server.Close()
+
+	file, err := ioutil.TempFile("", "httpclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	_, _ = file.Write(bytes.Repeat([]byte("a"), 100000))
+	file.Close()
+
+	newbody := func() *Multipart {
+		return NewMultipart().
+			AddField("key", "value").
+			AddFile("file", file.Name(), "").
+			AddReader("reader", "reader.txt", "text/plain", strings.NewReader("reader"), 6)
+	}
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	parts := fmt.Sprintf("key::5,file:%s:100000,reader:reader.txt:6", file.Name()[strings.LastIndexByte(file.Name(), os.PathSeparator)+1:])
+
+	// Chunked
+	body, err := client.Post(server.URL).SetBody(newbody()).Do(context.Background(), nil).ReadBody()
+	if err != nil {
+		t.Error(err)
+	} else if expect := "-1;" + parts; body != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, body)
+	}
+
+	// Compute the length
+	mp := newbody().ComputeLength(true)
+	_, length, err := NewMultipart().AddFile("file", file.Name(), "").ComputeLength(true).Body()
+	if err != nil || length <= 100000 {
+		t.Errorf("unexpected length %d: %v", length, err)
+	}
+
+	body, err = client.Post(server.URL).SetBody(mp).Do(context.Background(), nil).ReadBody()
+	if err != nil {
+		t.Error(err)
+	} else if !strings.HasSuffix(body, ";"+parts) || strings.HasPrefix(body, "-1;") {
+		t.Errorf("unexpected response '%s'", body)
+	}
+
+	// The readers are buffered to be replayed.
+	body, err = client.Post(server.URL).SetBody(mp).Do(context.Background(), nil).ReadBody()
+	if err != nil {
+		t.Error(err)
+	} else if !strings.HasSuffix(body, ";"+parts) || strings.HasPrefix(body, "-1;") {
+		t.Errorf("unexpected response '%s'", body)
+	}
+
+	// Preview the body by DryRun, then send it.
+	data := strings.Repeat("a", MaxBodyPreviewSize*4)
+	mp = NewMultipart().AddReader("reader", "reader.txt", "", strings.NewReader(data), -1)
+	req := client.Post(server.URL).SetBody(mp)
+	if summary, err := req.DryRun(context.Background()); err != nil {
+		t.Error(err)
+	} else if !summary.BodyTruncated {
+		t.Errorf("expect the truncated body preview")
+	}
+
+	body, err = req.Do(context.Background(), nil).ReadBody()
+	if expect := fmt.Sprintf("-1;reader:reader.txt:%d", len(data)); err != nil {
+		t.Error(err)
+	} else if body != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, body)
+	}
+}
+
+type multipartErrReader struct{ n int }
+
+func (r *multipartErrReader) Read(p []byte) (int, error) {
+	if r.n++; r.n > 3 {
+		return 0, errors.New("test reader error")
+	}
+	return copy(p, "abc"), nil
+}
+
+type multipartBlockReader struct{ reads int32 }
+
+func (r *multipartBlockReader) Read(p []byte) (int, error) {
+	atomic.AddInt32(&r.reads, 1)
+	time.Sleep(time.Millisecond)
+	return copy(p, "abc"), nil
+}
+
+func TestMultipartError(t *testing.T) {
+	server := newMultipartServer()
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	body := NewMultipart().AddReader("file", "file", "", &multipartErrReader{}, -1)
+	err := client.Post(server.URL).SetBody(body).Do(context.Background(), nil).Unwrap()
+	if err == nil || !strings.Contains(err.Error(), "test reader error") {
+		t.Errorf("expect the reader error, but got %v", err)
+	}
+
+	// Cancel the request
+	reader := new(multipartBlockReader)
+	body = NewMultipart().AddReader("file", "file", "", reader, -1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	err = client.Post(server.URL+"/ignore").SetBody(body).Do(ctx, nil).Unwrap()
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+
+	time.Sleep(time.Millisecond * 50)
+	reads := atomic.LoadInt32(&reader.reads)
+	time.Sleep(time.Millisecond * 50)
+	if n := atomic.LoadInt32(&reader.reads); n != reads {
+		t.Errorf("the writer goroutine is not terminated")
+	}
+}
+
+const multipartResponseFixture = "--BOUNDARY\r\n" +
+	"Content-Disposition: form-data; name=\"metadata\"\r\n" +
+	"Content-Type: application/json\r\n" +
+	"\r\n" +
+	"{\"name\":\"file.bin\",\"size\":4}\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Disposition: form-data; name=\"payload\"; filename=\"file.bin\"\r\n" +
+	"Content-Type: application/octet-stream\r\n" +
+	"\r\n" +
+	"\x00\x01\x02\x03\r\n" +
+	"--BOUNDARY\r\n" +
+	"Content-Disposition: form-data; name=\"extra\"\r\n" +
+	"\r\n" +
+	"extra\r\n" +
+	"--BOUNDARY--\r\n"
+
+func TestResponseDecodeMultipart(t *testing.T) {
+	ct := "multipart/form-data; boundary=BOUNDARY"
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": {ct}},
+			Body:       ioutil.NopCloser(strings.NewReader(multipartResponseFixture)),
+		}, nil
+	}))
+
+	type Metadata struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+	}
+
+	var metadata Metadata
+	var payload bytes.Buffer
+	var extra []byte
+	err := client.Get("http://127.0.0.1").Do(context.Background(), nil).
+		DecodeMultipart(MultipartSpec{Parts: []MultipartPart{
+			{Name: "metadata", Dst: &metadata, Required: true},
+			{Name: "payload", Dst: &payload, Required: true},
+			{Name: "extra", Dst: &extra},
+		}})
+	if err != nil {
+		t.Error(err)
+	} else if metadata != (Metadata{Name: "file.bin", Size: 4}) {
+		t.Errorf("unexpected metadata: %+v", metadata)
+	} else if !bytes.Equal(payload.Bytes(), []byte{0, 1, 2, 3}) {
+		t.Errorf("unexpected payload: %v", payload.Bytes())
+	} else if string(extra) != "extra" {
+		t.Errorf("expect extra '%s', but got '%s'", "extra", string(extra))
+	}
+
+	// Unknown part
+	err = client.Get("http://127.0.0.1").Do(context.Background(), nil).
+		DecodeMultipart(MultipartSpec{Parts: []MultipartPart{
+			{Name: "metadata", Dst: &metadata},
+			{Name: "payload", Dst: &payload},
+		}})
+	if err == nil || err.Error() != "unknown multipart part 'extra'" {
+		t.Errorf("expect an unknown part error, but got %v", err)
+	}
+
+	// Missing part
+	err = client.Get("http://127.0.0.1").Do(context.Background(), nil).
+		DecodeMultipart(MultipartSpec{IgnoreUnknown: true, Parts: []MultipartPart{
+			{Name: "metadata", Dst: &metadata},
+			{Name: "checksum", Dst: ioutil.Discard, Required: true},
+		}})
+	if err == nil || err.Error() != "missing the required multipart part 'checksum'" {
+		t.Errorf("expect a missing part error, but got %v", err)
+	}
+
+	// Invalid Content-Type
+	for _, ct = range []string{"application/json", "multipart/form-data"} {
+		if _, err := client.Get("http://127.0.0.1").Do(context.Background(), nil).Multipart(); err == nil {
+			t.Errorf("%s: expect an error, but got nil", ct)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import "context"
+
+// MustDo is the same as Do, but panics with the error, which is an Error,
+// if failing, and closes the response body.
+//
+// It is intended for the test fixtures and throwaway tools.
+func (r *Request) MustDo(ctx context.Context, result interface{}) *Response {
+	resp := r.Do(ctx, result)
+	if err := resp.Unwrap(); err != nil {
+		panic(err)
+	}
+	return resp
+}
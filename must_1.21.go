@@ -0,0 +1,30 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package httpclient
+
+import "context"
+
+// MustGetAs sends a GET request to the url by DefaultClient,
+// decodes the response body into the value of T and returns it.
+//
+// It panics with the error, which is an Error, if failing,
+// and is intended for the test fixtures and throwaway tools.
+func MustGetAs[T any](ctx context.Context, url string) (v T) {
+	Get(url).MustDo(ctx, &v)
+	return
+}
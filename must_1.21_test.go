@@ -0,0 +1,54 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMustGetAs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(500)
+			return
+		}
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		_, _ = w.Write([]byte(`{"id":123}`))
+	}))
+	defer server.Close()
+
+	defer func(onresp func(*Response)) { DefaultClient.OnResponse(onresp) }(DefaultClient.onresp)
+	DefaultClient.OnResponse(nil)
+
+	if v := MustGetAs[map[string]int](context.Background(), server.URL); v["id"] != 123 {
+		t.Errorf("unexpected result: %v", v)
+	}
+
+	defer func() {
+		if err, ok := recover().(Error); !ok {
+			t.Errorf("expect to panic with Error, but got %T", err)
+		} else if err.Code != 500 {
+			t.Errorf("expect status code %d, but got %d", 500, err.Code)
+		}
+	}()
+	MustGetAs[map[string]int](context.Background(), server.URL+"/error")
+	t.Errorf("expect a panic")
+}
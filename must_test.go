@@ -0,0 +1,54 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestMustDo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(404)
+			_, _ = w.Write([]byte("not found"))
+			return
+		}
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		_, _ = w.Write([]byte(`"ok"`))
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+
+	var result string
+	if code := client.Get(server.URL).MustDo(context.Background(), &result).StatusCode(); code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, code)
+	} else if result != "ok" {
+		t.Errorf("expect result '%s', but got '%s'", "ok", result)
+	}
+
+	defer func() {
+		if err, ok := recover().(Error); !ok {
+			t.Errorf("expect to panic with Error, but got %T", err)
+		} else if err.Code != 404 || err.Data != "not found" {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}()
+	client.Get(server.URL+"/error").MustDo(context.Background(), nil)
+	t.Errorf("expect a panic")
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Pre-define some headers about the allowed methods.
+const (
+	HeaderAllow                     = "Allow"
+	HeaderAccessControlAllowMethods = "Access-Control-Allow-Methods"
+	HeaderAccessControlAllowHeaders = "Access-Control-Allow-Headers"
+)
+
+// parseHeaderList parses the comma-separated values of the header,
+// which removes the empty and duplicated values.
+func parseHeaderList(values []string, normalize func(string) string) (list []string) {
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+
+			if normalize != nil {
+				v = normalize(v)
+			}
+
+			var exist bool
+			for _, s := range list {
+				if s == v {
+					exist = true
+					break
+				}
+			}
+
+			if !exist {
+				list = append(list, v)
+			}
+		}
+	}
+	return
+}
+
+func (r *Response) headerList(key string, normalize func(string) string) []string {
+	if r.resp == nil {
+		return nil
+	}
+	return parseHeaderList(r.resp.Header[key], normalize)
+}
+
+// AllowedMethods parses the response header "Allow" and returns
+// the list of the allowed methods, which are upper-case and deduplicated.
+//
+// Return nil if there is an error when sending the request.
+func (r *Response) AllowedMethods() []string {
+	return r.headerList(HeaderAllow, strings.ToUpper)
+}
+
+// AccessControlAllowMethods parses the CORS response header
+// "Access-Control-Allow-Methods" and returns the list of the allowed methods,
+// which are upper-case and deduplicated.
+//
+// Return nil if there is an error when sending the request.
+func (r *Response) AccessControlAllowMethods() []string {
+	return r.headerList(HeaderAccessControlAllowMethods, strings.ToUpper)
+}
+
+// AccessControlAllowHeaders parses the CORS response header
+// "Access-Control-Allow-Headers" and returns the list of the allowed headers,
+// which are canonicalized and deduplicated.
+//
+// Return nil if there is an error when sending the request.
+func (r *Response) AccessControlAllowHeaders() []string {
+	return r.headerList(HeaderAccessControlAllowHeaders, http.CanonicalHeaderKey)
+}
+
+// Allowed sends an OPTIONS request to the url and returns the allowed methods
+// parsed from the response header "Allow".
+func (c *Client) Allowed(ctx context.Context, url string) (methods []string, err error) {
+	err = c.Options(url).Do(ctx, func(resp *http.Response) error {
+		if resp.StatusCode >= 400 {
+			return ReadResponseBodyAsError(nil, resp)
+		}
+
+		methods = parseHeaderList(resp.Header[HeaderAllow], strings.ToUpper)
+		return nil
+	}).Unwrap()
+	return
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestResponseAllowedMethods(t *testing.T) {
+	tests := []struct {
+		Allow  []string
+		Expect []string
+	}{
+		{nil, nil},
+		{[]string{""}, nil},
+		{[]string{"GET, HEAD, OPTIONS"}, []string{"GET", "HEAD", "OPTIONS"}},
+		{[]string{"GET,POST,put"}, []string{"GET", "POST", "PUT"}},
+		{[]string{" get ,  , Get, HEAD"}, []string{"GET", "HEAD"}},
+		{[]string{"GET, HEAD", "POST", "head"}, []string{"GET", "HEAD", "POST"}},
+	}
+
+	for i, test := range tests {
+		resp := NewTestResponse(ResponseOptions{
+			StatusCode: 204,
+			Header:     http.Header{HeaderAllow: test.Allow},
+		})
+		if methods := resp.AllowedMethods(); !reflect.DeepEqual(methods, test.Expect) {
+			t.Errorf("%d: expect %v, but got %v", i, test.Expect, methods)
+		}
+	}
+
+	if methods := NewTestResponse(ResponseOptions{}).AllowedMethods(); methods != nil {
+		t.Errorf("expect nil, but got %v", methods)
+	}
+}
+
+func TestResponseAccessControl(t *testing.T) {
+	resp := NewTestResponse(ResponseOptions{
+		StatusCode: 204,
+		Header: http.Header{
+			HeaderAccessControlAllowMethods: []string{"get, post", "POST, delete"},
+			HeaderAccessControlAllowHeaders: []string{"content-type, x-request-id, Content-Type"},
+		},
+	})
+
+	expect := []string{"GET", "POST", "DELETE"}
+	if methods := resp.AccessControlAllowMethods(); !reflect.DeepEqual(methods, expect) {
+		t.Errorf("expect %v, but got %v", expect, methods)
+	}
+
+	expect = []string{"Content-Type", "X-Request-Id"}
+	if headers := resp.AccessControlAllowHeaders(); !reflect.DeepEqual(headers, expect) {
+		t.Errorf("expect %v, but got %v", expect, headers)
+	}
+}
+
+func TestClientAllowed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions {
+			w.WriteHeader(405)
+			return
+		}
+
+		if r.URL.Path == "/notfound" {
+			w.WriteHeader(404)
+			return
+		}
+
+		w.Header().Set(HeaderAllow, "OPTIONS, GET, HEAD, get")
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	methods, err := client.Allowed(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{"OPTIONS", "GET", "HEAD"}
+	if !reflect.DeepEqual(methods, expect) {
+		t.Errorf("expect %v, but got %v", expect, methods)
+	}
+
+	_, err = client.Allowed(context.Background(), server.URL+"/notfound")
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if code := err.(Error).StatusCode(); code != 404 {
+		t.Errorf("expect status code 404, but got %d", code)
+	}
+}
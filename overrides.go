@@ -0,0 +1,136 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Overrides is the overrides of the request settings carried by the context,
+// such as the tenant-specific upstream and auth in a multi-tenant gateway,
+// so that a shared client can be used instead of one per tenant.
+//
+// The precedence chain is
+//
+//	URL:    the absolute request url > Overrides.BaseURL > Client base url
+//	Header: Request header > Overrides.Header > Client default header
+//
+// Here, the request header is the one set on Request and different from
+// the client default header.
+type Overrides struct {
+	// BaseURL is used to resolve the relative request url, such as "/path".
+	BaseURL string
+
+	// Header is merged into the request header.
+	Header http.Header
+
+	// Labels is the extra information of the request, such as the tenant,
+	// which can be got by Response.Labels, for example, to be logged.
+	Labels map[string]string
+}
+
+type overridesKey struct{}
+
+// WithRequestOverrides returns a new context carrying the overrides,
+// which are applied when sending the request with it by Request.Do.
+func WithRequestOverrides(ctx context.Context, overrides Overrides) context.Context {
+	overrides.BaseURL = strings.TrimRight(overrides.BaseURL, "/")
+	return context.WithValue(ctx, overridesKey{}, overrides)
+}
+
+// GetRequestOverrides returns the overrides carried by the context.
+func GetRequestOverrides(ctx context.Context) (overrides Overrides, ok bool) {
+	overrides, ok = ctx.Value(overridesKey{}).(Overrides)
+	return
+}
+
+// Labels returns the labels of the request overrides carried by
+// the context passed to Request.Do.
+//
+// Return nil if no overrides.
+func (r *Response) Labels() map[string]string {
+	if r.ctx == nil {
+		return nil
+	}
+
+	overrides, _ := GetRequestOverrides(r.ctx)
+	return overrides.Labels
+}
+
+// resolveURL resolves the relative request url against the base url
+// of the overrides or the client.
+func (r *Request) resolveURL(c context.Context) {
+	if r.relurl == "" {
+		return
+	}
+
+	baseurl := r.baseurl()
+	if overrides, ok := GetRequestOverrides(c); ok && overrides.BaseURL != "" {
+		baseurl = overrides.BaseURL
+	}
+
+	switch {
+	case baseurl != "":
+		r.url = mergeurl(baseurl, r.relurl)
+		if r.err == r.urlerr {
+			r.err = nil
+		}
+	case r.err == nil:
+		r.url, r.err = r.relurl, r.urlerr
+	}
+}
+
+// overrideHeader merges the header of the overrides into the request header,
+// but the header set on the request takes precedence.
+func (r *Request) overrideHeader(c context.Context, req *http.Request) {
+	overrides, ok := GetRequestOverrides(c)
+	if !ok || len(overrides.Header) == 0 {
+		return
+	}
+
+	header := cloneHeaderForWrite(req)
+	for key, values := range overrides.Header {
+		if key = headerKey(key, r.rawheader); !r.isRequestHeader(key) {
+			header[key] = values
+		}
+	}
+}
+
+// isRequestHeader reports whether the header is set on the request,
+// which is different from the client default header.
+func (r *Request) isRequestHeader(key string) bool {
+	if r.hclone {
+		return false
+	}
+
+	values, ok := r.header[key]
+	if !ok {
+		return false
+	}
+
+	defaults := r.cheader[key]
+	if len(values) != len(defaults) {
+		return true
+	}
+
+	for i := range values {
+		if values[i] != defaults[i] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestRequestOverrides(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil).SetBaseURL("http://default").
+		SetHeader("X-Tenant", "default").SetHeader("X-Common", "common")
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		header := http.Header{
+			"X-Url":    {r.URL.String()},
+			"X-Tenant": {r.Header.Get("X-Tenant")},
+			"X-Common": {r.Header.Get("X-Common")},
+			"X-Auth":   {r.Header.Get("X-Auth")},
+		}
+		return &http.Response{StatusCode: 204, Header: header, Body: http.NoBody}, nil
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			tenant := fmt.Sprintf("tenant%d", i)
+			ctx := WithRequestOverrides(context.Background(), Overrides{
+				BaseURL: fmt.Sprintf("http://%s.upstream/", tenant),
+				Header:  http.Header{"X-Tenant": {tenant}, "X-Auth": {"auth-" + tenant}},
+				Labels:  map[string]string{"tenant": tenant},
+			})
+
+			req := client.Get("/path")
+			if i%2 == 1 { // The request setting takes precedence.
+				req.SetHeader("X-Auth", "request")
+			}
+
+			resp := req.Do(ctx, nil)
+			if err := resp.Unwrap(); err != nil {
+				t.Error(err)
+				return
+			}
+
+			header := resp.Response().Header
+			expectAuth := "auth-" + tenant
+			if i%2 == 1 {
+				expectAuth = "request"
+			}
+
+			if url := header.Get("X-Url"); url != "http://"+tenant+".upstream/path" {
+				t.Errorf("%s: unexpected url '%s'", tenant, url)
+			} else if v := header.Get("X-Tenant"); v != tenant {
+				t.Errorf("%s: unexpected tenant header '%s'", tenant, v)
+			} else if v := header.Get("X-Common"); v != "common" {
+				t.Errorf("%s: unexpected common header '%s'", tenant, v)
+			} else if v := header.Get("X-Auth"); v != expectAuth {
+				t.Errorf("%s: expect auth header '%s', but got '%s'", tenant, expectAuth, v)
+			} else if labels := resp.Labels(); labels["tenant"] != tenant {
+				t.Errorf("%s: unexpected labels %v", tenant, labels)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Without the overrides
+	resp := client.Get("/path").Do(context.Background(), nil)
+	if err := resp.Unwrap(); err != nil {
+		t.Error(err)
+	} else if url := resp.Response().Header.Get("X-Url"); url != "http://default/path" {
+		t.Errorf("unexpected url '%s'", url)
+	} else if v := resp.Response().Header.Get("X-Tenant"); v != "default" {
+		t.Errorf("unexpected tenant header '%s'", v)
+	} else if resp.Labels() != nil {
+		t.Errorf("unexpected labels %v", resp.Labels())
+	}
+
+	// The absolute url takes precedence.
+	ctx := WithRequestOverrides(context.Background(), Overrides{BaseURL: "http://tenant"})
+	resp = client.Get("http://absolute/path").Do(ctx, nil)
+	if url := resp.Response().Header.Get("X-Url"); url != "http://absolute/path" {
+		t.Errorf("unexpected url '%s'", url)
+	}
+
+	// The non-canonical header keys of the overrides
+	var header http.Header
+	hctx := WithRequestOverrides(context.Background(), Overrides{
+		Header: http.Header{"x-tenant": {"lower"}, "x-auth": {"lower"}},
+	})
+	err := client.Clone().SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		header = r.Header
+		return &http.Response{StatusCode: 204, Header: http.Header{}, Body: http.NoBody}, nil
+	})).Get("/path").SetHeader("X-Auth", "request").Do(hctx, nil).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if v := header["X-Tenant"]; len(v) != 1 || v[0] != "lower" || len(header["x-tenant"]) != 0 {
+		t.Errorf("unexpected tenant header %v", header)
+	} else if v := header["X-Auth"]; len(v) != 1 || v[0] != "request" || len(header["x-auth"]) != 0 {
+		t.Errorf("unexpected auth header %v", header)
+	}
+
+	// The client has no base url.
+	client = client.Clone().SetBaseURL("")
+	req := client.Get("/path")
+	if err := req.Do(ctx, nil).Unwrap(); err != nil {
+		t.Error(err)
+	} else if err := req.Do(context.Background(), nil).Unwrap(); err == nil {
+		t.Errorf("expect an invalid url error, but got nil")
+	}
+}
+
+func TestResolveBaseURLWhenSending(t *testing.T) {
+	var sent string
+	client := NewClient(nil).OnResponse(nil).SetBaseURL("http://old")
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r.URL.String()
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	// Change the base url after building the request.
+	req := client.Get("/path")
+	if url := req.URLString(); url != "http://old/path" {
+		t.Errorf("expect url '%s', but got '%s'", "http://old/path", url)
+	}
+
+	client.SetBaseURL("http://new/v1")
+	if url := req.URLString(); url != "http://new/v1/path" {
+		t.Errorf("expect url '%s', but got '%s'", "http://new/v1/path", url)
+	}
+
+	resp := req.Do(context.Background(), nil)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if sent != "http://new/v1/path" {
+		t.Errorf("expect to send to '%s', but got '%s'", "http://new/v1/path", sent)
+	} else if url := resp.Url(); url != sent {
+		t.Errorf("expect response url '%s', but got '%s'", sent, url)
+	}
+
+	// Clear the base url after building the request.
+	req = client.Get("/path")
+	client.SetBaseURL("")
+	if err := req.Do(context.Background(), nil).Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+
+	// Set the base url after building the request.
+	req = client.Get("/path")
+	if _, err := req.Build(context.Background()); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+
+	client.SetBaseURL("http://late")
+	if r, err := req.Build(context.Background()); err != nil {
+		t.Error(err)
+	} else if url := r.URL.String(); url != "http://late/path" {
+		t.Errorf("expect url '%s', but got '%s'", "http://late/path", url)
+	}
+}
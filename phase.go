@@ -0,0 +1,152 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http/httptrace"
+	"net/textproto"
+	"sync/atomic"
+	"time"
+)
+
+// FailurePhase is the phase in progress when the request fails.
+type FailurePhase string
+
+// Pre-define some failure phases.
+const (
+	PhaseEncodeBody     FailurePhase = "EncodeBody"
+	PhaseDNS            FailurePhase = "DNS"
+	PhaseConnect        FailurePhase = "Connect"
+	PhaseTLSHandshake   FailurePhase = "TLSHandshake"
+	PhaseWritingRequest FailurePhase = "WritingRequest"
+	PhaseWaitingHeaders FailurePhase = "WaitingHeaders"
+	PhaseReadingBody    FailurePhase = "ReadingBody"
+)
+
+// The indexes of the phases tracked by httptrace.
+const (
+	tracedNone = iota // No trace event has fired, such as failing before the transport.
+	tracedConnect
+	tracedDNS
+	tracedTLSHandshake
+	tracedWritingRequest
+	tracedWaitingHeaders
+)
+
+var tracedPhases = []FailurePhase{
+	tracedNone:           "",
+	tracedConnect:        PhaseConnect,
+	tracedDNS:            PhaseDNS,
+	tracedTLSHandshake:   PhaseTLSHandshake,
+	tracedWritingRequest: PhaseWritingRequest,
+	tracedWaitingHeaders: PhaseWaitingHeaders,
+}
+
+// phaseTracker tracks the phase of the request by httptrace.
+type phaseTracker struct {
+	written  int64 // The bytes of the request body read by the transport
+	getconn  int64 // The unix nanoseconds when starting to get the connection
+	connwait int64 // The total nanoseconds waiting for the connections
+	phase    int32
+	gotresp  int32 // Whether the response has started to be received
+}
+
+type phaseTrackerKey struct{}
+
+func (t *phaseTracker) set(i int)           { atomic.StoreInt32(&t.phase, int32(i)) }
+func (t *phaseTracker) Phase() FailurePhase { return tracedPhases[atomic.LoadInt32(&t.phase)] }
+func (t *phaseTracker) ConnWait() time.Duration {
+	return time.Duration(atomic.LoadInt64(&t.connwait))
+}
+
+func (t *phaseTracker) WithContext(c context.Context,
+	got1xx func(int, textproto.MIMEHeader) error,
+	gotConn func(httptrace.GotConnInfo)) context.Context {
+	c = context.WithValue(c, phaseTrackerKey{}, t)
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			atomic.StoreInt64(&t.getconn, time.Now().UnixNano())
+			t.set(tracedConnect)
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { t.set(tracedDNS) },
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			if info.Err == nil {
+				t.set(tracedConnect)
+			}
+		},
+		ConnectStart:      func(string, string) { t.set(tracedConnect) },
+		TLSHandshakeStart: func() { t.set(tracedTLSHandshake) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				t.set(tracedConnect)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if start := atomic.LoadInt64(&t.getconn); start > 0 {
+				atomic.AddInt64(&t.connwait, time.Now().UnixNano()-start)
+			}
+			t.set(tracedWritingRequest)
+			gotConn(info)
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				t.set(tracedWaitingHeaders)
+			}
+		},
+		GotFirstResponseByte: func() {
+			atomic.StoreInt32(&t.gotresp, 1)
+			t.set(tracedWaitingHeaders)
+		},
+	}
+
+	setGot1xxResponse(trace, func(code int, header textproto.MIMEHeader) error {
+		atomic.StoreInt32(&t.gotresp, 1)
+		return got1xx(code, header)
+	})
+	return httptrace.WithClientTrace(c, trace)
+}
+
+// isReadError reports whether err is the error when reading the body,
+// such as the network error or the context error.
+func isReadError(c context.Context, err error) bool {
+	if c.Err() != nil || err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	for err != nil {
+		switch err.(type) {
+		case net.Error, BodyReadError:
+			return true
+		}
+
+		if u, ok := err.(interface{ Unwrap() error }); ok {
+			err = u.Unwrap()
+		} else {
+			break
+		}
+	}
+	return false
+}
+
+// FailurePhase returns the phase in progress when the request fails,
+// such as PhaseDNS or PhaseWaitingHeaders.
+//
+// Return "" if the request does not fail, or fails not in any phase,
+// such as the response status code error.
+func (r *Response) FailurePhase() FailurePhase { return r.phase }
@@ -0,0 +1,27 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.11
+// +build go1.11
+
+package httpclient
+
+import (
+	"net/http/httptrace"
+	"net/textproto"
+)
+
+func setGot1xxResponse(trace *httptrace.ClientTrace, got1xx func(int, textproto.MIMEHeader) error) {
+	trace.Got1xxResponse = got1xx
+}
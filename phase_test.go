@@ -0,0 +1,128 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseFailurePhase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/headers":
+			time.Sleep(time.Millisecond * 200)
+		case "/body":
+			w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+			_, _ = w.Write([]byte(`{"a":`))
+			w.(http.Flusher).Flush()
+			time.Sleep(time.Millisecond * 200)
+		case "/status":
+			w.WriteHeader(500)
+		}
+	}))
+	defer server.Close()
+
+	// A listener which accepts the connections but never responds.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	// A closed port to refuse the connections.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	client := NewClient(&http.Client{Transport: &http.Transport{}}).OnResponse(nil)
+	do := func(req *Request, result interface{}) *Response {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+		defer cancel()
+		return req.Do(ctx, result)
+	}
+
+	// A client whose resolver always fails.
+	dnsclient := NewClient(&http.Client{Transport: &http.Transport{
+		DialContext: (&net.Dialer{Resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return nil, errors.New("dns failure")
+			},
+		}}).DialContext,
+	}}).OnResponse(nil)
+
+	var result map[string]interface{}
+	tests := []struct {
+		Resp  *Response
+		Phase FailurePhase
+	}{
+		{Resp: do(client.Post(server.URL).SetBody(func() {}), nil), Phase: PhaseEncodeBody},
+		{Resp: do(dnsclient.Get("http://www.example.test"), nil), Phase: PhaseDNS},
+		{Resp: do(client.Get("http://"+closedAddr), nil), Phase: PhaseConnect},
+		{Resp: do(client.Get("https://"+ln.Addr().String()), nil), Phase: PhaseTLSHandshake},
+		{Resp: do(client.Get(server.URL+"/headers"), nil), Phase: PhaseWaitingHeaders},
+		{Resp: do(client.Get(server.URL+"/body"), &result), Phase: PhaseReadingBody},
+		{Resp: do(client.Get(server.URL+"/status"), nil), Phase: ""},
+		{Resp: do(client.Get(server.URL), nil), Phase: ""},
+	}
+
+	for i, test := range tests {
+		if phase := test.Resp.FailurePhase(); phase != test.Phase {
+			t.Errorf("%d: expect phase '%s', but got '%s': %v", i, test.Phase, phase, test.Resp.Error())
+		}
+
+		err := test.Resp.Unwrap()
+		if test.Phase == "" {
+			continue
+		}
+
+		if e, ok := err.(Error); !ok || e.Phase != test.Phase {
+			t.Errorf("%d: expect the error phase '%s', but got %v", i, test.Phase, err)
+		} else if !strings.Contains(e.Error(), "phase="+string(test.Phase)) {
+			t.Errorf("%d: expect the phase in the error string, but got '%s'", i, e.Error())
+		}
+	}
+
+	// The failure before the transport, such as the mock doer.
+	mockclient := NewClient(nil).OnResponse(nil).SetDoer(DoFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("test")
+	}))
+	resp := do(mockclient.Get("http://127.0.0.1"), nil)
+	if phase := resp.FailurePhase(); phase != "" {
+		t.Errorf("expect no phase, but got '%s'", phase)
+	}
+	if kind := Kind(resp.Unwrap()); kind != KindUnknown {
+		t.Errorf("expect the error kind '%s', but got '%s'", KindUnknown, kind)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrResponseReleased is returned when using the response after released,
+// which is only detected in the race builds.
+var ErrResponseReleased = errors.New("http response has been released")
+
+var respool = sync.Pool{New: func() interface{} { return new(Response) }}
+
+func newResponse(pooling bool) (resp *Response) {
+	if pooling {
+		resp = respool.Get().(*Response)
+		resp.pooled = true
+	} else {
+		resp = new(Response)
+	}
+	return
+}
+
+// SetResponsePooling sets whether to obtain the responses returned by Do
+// from a pool, which reduces the allocations for the high-volume clients.
+//
+// If enabled, the caller should call Release on the response
+// after all the accessors are done, which returns it to the pool.
+//
+// Default: false
+func (c *Client) SetResponsePooling(enabled bool) *Client {
+	c.pooling = enabled
+	return c
+}
+
+// Release closes the response body and returns the response to the pool
+// if it is obtained from the pool, that's, the response pooling is enabled.
+//
+// Notice: Release invalidates the response, so it must not be used any more,
+// including calling Release again. In the race builds, the response is not
+// reused but poisoned, so any use after release returns ErrResponseReleased.
+func (r *Response) Release() {
+	r.close()
+	if !r.pooled || r.released {
+		return
+	}
+
+	if raceEnabled {
+		*r = Response{err: ErrResponseReleased, closed: true, released: true}
+		return
+	}
+
+	*r = Response{}
+	respool.Put(r)
+}
+
+// PoisonReleasedBytes reports whether to poison the bytes returned by
+// Response.ReadBodyBytes when released, which overwrites them with 0xDE
+// and does not reuse the buffer, so that any use after release is visible.
+//
+// Default: true in the race builds, or false
+var PoisonReleasedBytes = raceEnabled
+
+// ReadBodyBytes is the same as ReadBody, but returns the bytes
+// of the pooled buffer directly without copying, and a release function
+// to return the buffer to the pool, which is for the hot paths that
+// parse or hash the body and do not need to retain it.
+//
+// Notice: data must not be used after calling release, and release
+// must be called only once, or it will panic. If there is an error,
+// release is still non-nil and should be called.
+func (r *Response) ReadBodyBytes() (data []byte, release func(), err error) {
+	if r.resp == nil {
+		return nil, func() {}, r.err
+	}
+
+	buf := getBuffer()
+	_, err = r.WriteTo(buf)
+	data = buf.Bytes()
+
+	var released uint32
+	release = func() {
+		if !atomic.CompareAndSwapUint32(&released, 0, 1) {
+			panic("Response.ReadBodyBytes: release is called twice")
+		}
+
+		if PoisonReleasedBytes {
+			b := buf.Bytes()
+			for i := range b {
+				b[i] = 0xDE
+			}
+			return
+		}
+
+		putBuffer(buf)
+	}
+
+	return
+}
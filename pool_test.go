@@ -0,0 +1,133 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newPoolTestClient(pooling bool) *Client {
+	doer := DoFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 204,
+			Header:     make(http.Header),
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	})
+
+	return NewClient(nil).SetDoer(doer).OnResponse(nil).SetResponsePooling(pooling)
+}
+
+func TestResponsePooling(t *testing.T) {
+	client := newPoolTestClient(true)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				resp := client.Get("http://127.0.0.1").Do(context.Background(), nil)
+				if err := resp.Unwrap(); err != nil {
+					t.Error(err)
+				} else if code := resp.StatusCode(); code != 204 {
+					t.Errorf("expect status code %d, but got %d", 204, code)
+				}
+				resp.Release()
+			}
+		}()
+	}
+	wg.Wait()
+
+	resp := client.Get("http://127.0.0.1").Do(context.Background(), nil)
+	resp.Release()
+	resp.Release() // Release twice
+	if raceEnabled {
+		if err := resp.Unwrap(); err == nil || err.(Error).Err != ErrResponseReleased {
+			t.Errorf("expect error ErrResponseReleased, but got %v", err)
+		}
+	}
+
+	// The response is not pooled.
+	client.SetResponsePooling(false)
+	resp = client.Get("http://127.0.0.1").Do(context.Background(), nil)
+	resp.Release()
+	if code := resp.StatusCode(); code != 204 {
+		t.Errorf("expect status code %d, but got %d", 204, code)
+	}
+}
+
+func benchmarkResponsePooling(b *testing.B, pooling bool) {
+	client := newPoolTestClient(pooling)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.Get("http://127.0.0.1").Do(ctx, nil).Release()
+	}
+}
+
+func BenchmarkResponseNoPooling(b *testing.B) { benchmarkResponsePooling(b, false) }
+func BenchmarkResponsePooling(b *testing.B)   { benchmarkResponsePooling(b, true) }
+
+func TestResponseReadBodyBytes(t *testing.T) {
+	doer := DoFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Header:     make(http.Header),
+			Body:       ioutil.NopCloser(strings.NewReader("abc")),
+			Request:    req,
+		}, nil
+	})
+
+	defer func(poison bool) { PoisonReleasedBytes = poison }(PoisonReleasedBytes)
+	PoisonReleasedBytes = true
+
+	client := NewClient(nil).SetDoer(doer).OnResponse(nil)
+	resp := client.Get("http://127.0.0.1").Do(context.Background(), func(*http.Response) error { return nil })
+	data, release, err := resp.ReadBodyBytes()
+	if err != nil {
+		t.Fatal(err)
+	} else if string(data) != "abc" {
+		t.Errorf("expect body '%s', but got '%s'", "abc", string(data))
+	}
+
+	release()
+	if string(data) != "\xde\xde\xde" {
+		t.Errorf("expect the poisoned data, but got '%x'", data)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect a panic when calling release twice")
+			}
+		}()
+		release()
+	}()
+
+	resp = NewTestResponse(ResponseOptions{Err: ErrClientClosed})
+	if _, release, err = resp.ReadBodyBytes(); err != ErrClientClosed {
+		t.Errorf("expect error ErrClientClosed, but got %v", err)
+	}
+	release()
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Pre-define some headers about the preferences of RFC 7240.
+const (
+	HeaderPrefer            = "Prefer"
+	HeaderPreferenceApplied = "Preference-Applied"
+)
+
+// SetPrefer sets the request header "Prefer" with the preferences,
+// such as "return=minimal", "respond-async" and "wait=100",
+// which are joined by the comma and the duplicated preferences
+// with the same name are suppressed, that's, only the first is kept.
+//
+// If prefs is empty, it does nothing.
+func (r *Request) SetPrefer(prefs ...string) *Request {
+	values := make([]string, 0, len(prefs))
+	names := make([]string, 0, len(prefs))
+	for _, pref := range prefs {
+		if pref = strings.TrimSpace(pref); pref == "" {
+			continue
+		}
+
+		name, _ := parsePreference(pref)
+		var exist bool
+		for _, n := range names {
+			if n == name {
+				exist = true
+				break
+			}
+		}
+
+		if !exist {
+			names = append(names, name)
+			values = append(values, pref)
+		}
+	}
+
+	if len(values) == 0 {
+		return r
+	}
+	return r.SetHeader(HeaderPrefer, strings.Join(values, ", "))
+}
+
+// PreferenceApplied parses the response header "Preference-Applied"
+// and returns the applied preferences, whose names are lower-case,
+// and the value is empty if the preference has no value.
+//
+// Return nil if the header is missing.
+func (r *Response) PreferenceApplied() map[string]string {
+	if r.resp == nil {
+		return nil
+	}
+	return parsePreferences(r.resp.Header[HeaderPreferenceApplied])
+}
+
+// AsyncLocation returns the url of the header "Location" to poll the status
+// of the asynchronous processing, when the server applies the preference
+// "respond-async" and responds with 202.
+func (r *Response) AsyncLocation() (location string, ok bool) {
+	if r.resp == nil || r.resp.StatusCode != http.StatusAccepted {
+		return
+	}
+
+	if _, ok = r.PreferenceApplied()["respond-async"]; ok {
+		location = r.resp.Header.Get("Location")
+		ok = location != ""
+	}
+	return
+}
+
+func parsePreferences(values []string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	prefs := make(map[string]string, 4)
+	for _, value := range values {
+		for _, pref := range splitPreferences(value) {
+			if name, value := parsePreference(pref); name != "" {
+				if _, ok := prefs[name]; !ok {
+					prefs[name] = value
+				}
+			}
+		}
+	}
+	return prefs
+}
+
+// splitPreferences splits the header value by the comma,
+// which ignores the commas in the quoted strings.
+func splitPreferences(s string) (prefs []string) {
+	for s != "" {
+		index := indexUnquoted(s, ',')
+		if index < 0 {
+			index = len(s)
+		}
+
+		if pref := strings.TrimSpace(s[:index]); pref != "" {
+			prefs = append(prefs, pref)
+		}
+
+		if index < len(s) {
+			index++
+		}
+		s = s[index:]
+	}
+	return
+}
+
+// indexUnquoted returns the index of the first c not in the quoted strings.
+func indexUnquoted(s string, c byte) int {
+	var quoted bool
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case '\\':
+			if quoted {
+				i++
+			}
+		case c:
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parsePreference parses the name and value of the preference,
+// which ignores the parameters, such as "foo; bar".
+func parsePreference(pref string) (name, value string) {
+	if index := indexUnquoted(pref, ';'); index >= 0 {
+		pref = pref[:index]
+	}
+
+	if index := strings.IndexByte(pref, '='); index >= 0 {
+		name, value = pref[:index], strings.TrimSpace(pref[index+1:])
+		if v, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+			value = v
+		}
+	} else {
+		name = pref
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	return
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestRequestSetPrefer(t *testing.T) {
+	tests := []struct {
+		Prefs  []string
+		Expect string
+	}{
+		// The examples of RFC 7240
+		{[]string{"respond-async", "wait=100"}, "respond-async, wait=100"},
+		{[]string{"return=minimal"}, "return=minimal"},
+		{[]string{"handling=lenient"}, "handling=lenient"},
+		{[]string{"foo; bar"}, "foo; bar"},
+		{[]string{`foo="bar, baz"`}, `foo="bar, baz"`},
+
+		{[]string{"return=minimal", " ", "Return=representation", "wait=10"}, "return=minimal, wait=10"},
+	}
+
+	for _, test := range tests {
+		req := NewClient(nil).Get("http://127.0.0.1").SetPrefer(test.Prefs...)
+		if v := req.header.Get(HeaderPrefer); v != test.Expect {
+			t.Errorf("expect '%s', but got '%s'", test.Expect, v)
+		}
+	}
+
+	if req := NewClient(nil).Get("http://127.0.0.1").SetPrefer(); req.header.Get(HeaderPrefer) != "" {
+		t.Errorf("unexpected header Prefer")
+	}
+}
+
+func TestResponsePreferenceApplied(t *testing.T) {
+	tests := []struct {
+		Values []string
+		Expect map[string]string
+	}{
+		{nil, nil},
+		{[]string{"return=representation"}, map[string]string{"return": "representation"}},
+		{[]string{"respond-async, wait=100"}, map[string]string{"respond-async": "", "wait": "100"}},
+		{[]string{"Foo; bar", `baz="a, b"`}, map[string]string{"foo": "", "baz": "a, b"}},
+		{[]string{`foo="a;b"; p=1, foo=c`}, map[string]string{"foo": "a;b"}},
+	}
+
+	for _, test := range tests {
+		resp := &Response{resp: &http.Response{Header: http.Header{}}}
+		if test.Values != nil {
+			resp.resp.Header[HeaderPreferenceApplied] = test.Values
+		}
+
+		prefs := resp.PreferenceApplied()
+		if len(prefs) != len(test.Expect) || (test.Expect == nil) != (prefs == nil) {
+			t.Errorf("expect %v, but got %v", test.Expect, prefs)
+			continue
+		}
+		for k, v := range test.Expect {
+			if value, ok := prefs[k]; !ok || value != v {
+				t.Errorf("%s: expect '%s', but got '%s'", k, v, value)
+			}
+		}
+	}
+}
+
+func TestResponseAsyncLocation(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		resp := &http.Response{StatusCode: 200, Header: make(http.Header), Body: http.NoBody, Request: r}
+		if _, ok := parsePreferences(r.Header[HeaderPrefer])["respond-async"]; ok {
+			resp.StatusCode = http.StatusAccepted
+			resp.Header.Set(HeaderPreferenceApplied, "respond-async")
+			resp.Header.Set("Location", "/status/1")
+		}
+		return resp, nil
+	}))
+
+	resp := client.Post("http://127.0.0.1").SetPrefer("respond-async", "wait=10").
+		Do(context.Background(), func(*http.Response) error { return nil })
+	if location, ok := resp.AsyncLocation(); !ok || location != "/status/1" {
+		t.Errorf("expect async location '%s', but got '%s'", "/status/1", location)
+	}
+
+	resp = client.Post("http://127.0.0.1").Do(context.Background(), func(*http.Response) error { return nil })
+	if location, ok := resp.AsyncLocation(); ok {
+		t.Errorf("unexpected async location '%s'", location)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+// BodyProvider is used to provide the replayable request body,
+// such as the file or the generated content, without buffering it.
+type BodyProvider interface {
+	// ContentType returns the content type of the body,
+	// which may be empty to use the Content-Type header of the request.
+	ContentType() string
+
+	// Body returns a fresh reader of the body and its length
+	// for each call, which is -1 if unknown.
+	Body() (body io.ReadCloser, length int64, err error)
+}
+
+func newProviderRequest(c context.Context, method, url string, p BodyProvider) (*http.Request, error) {
+	body, length, err := p.Body()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := NewRequestWithContext(c, method, url, body)
+	if err != nil {
+		_ = body.Close()
+		return nil, err
+	}
+
+	if length == 0 {
+		_ = body.Close()
+		req.Body = http.NoBody
+	}
+
+	req.ContentLength = length
+	req.GetBody = func() (io.ReadCloser, error) {
+		body, _, err := p.Body()
+		return body, err
+	}
+
+	return req, nil
+}
+
+func describeBodyProvider(p BodyProvider) string {
+	if s, ok := p.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+// BytesBodyProvider is a body provider based on the bytes.
+type BytesBodyProvider struct {
+	Type string
+	Data []byte
+}
+
+// ContentType implements the interface BodyProvider.
+func (p BytesBodyProvider) ContentType() string { return p.Type }
+
+// Body implements the interface BodyProvider.
+func (p BytesBodyProvider) Body() (io.ReadCloser, int64, error) {
+	return ioutil.NopCloser(bytes.NewReader(p.Data)), int64(len(p.Data)), nil
+}
+
+// String implements the interface fmt.Stringer.
+func (p BytesBodyProvider) String() string {
+	return fmt.Sprintf("bytes(%d)", len(p.Data))
+}
+
+// FileBodyProvider is a body provider based on the file,
+// which opens the file for each call of Body.
+type FileBodyProvider struct {
+	Type string
+	Path string
+}
+
+// ContentType implements the interface BodyProvider.
+func (p FileBodyProvider) ContentType() string { return p.Type }
+
+// Body implements the interface BodyProvider.
+func (p FileBodyProvider) Body() (io.ReadCloser, int64, error) {
+	file, err := os.Open(p.Path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, 0, err
+	}
+
+	return file, info.Size(), nil
+}
+
+// String implements the interface fmt.Stringer.
+func (p FileBodyProvider) String() string {
+	return fmt.Sprintf("file(%s)", p.Path)
+}
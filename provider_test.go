@@ -0,0 +1,81 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestBodyProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/final", http.StatusTemporaryRedirect)
+			return
+		}
+
+		data, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(r.Header.Get(HeaderContentType) + ":" + string(data)))
+	}))
+	defer server.Close()
+
+	file, err := ioutil.TempFile("", "httpclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	_, _ = file.WriteString("file data")
+	file.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	tests := []struct {
+		Provider BodyProvider
+		Expect   string
+	}{
+		{BytesBodyProvider{Type: "text/plain", Data: []byte("bytes data")}, "text/plain:bytes data"},
+		{FileBodyProvider{Type: "application/octet-stream", Path: file.Name()}, "application/octet-stream:file data"},
+		{BytesBodyProvider{}, MIMEApplicationJSONCharsetUTF8 + ":"},
+	}
+
+	for i, test := range tests {
+		body, err := client.Post(server.URL+"/redirect").SetBody(test.Provider).
+			Do(context.Background(), nil).ReadBody()
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if body != test.Expect {
+			t.Errorf("%d: expect '%s', but got '%s'", i, test.Expect, body)
+		}
+	}
+
+	summary, err := client.Post(server.URL).
+		SetBody(FileBodyProvider{Path: file.Name()}).
+		DryRun(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	} else if summary.BodyLength != 9 || summary.BodyPreview != "file data" {
+		t.Errorf("unexpected body summary: %d, %s", summary.BodyLength, summary.BodyPreview)
+	}
+
+	err = client.Post(server.URL).SetBody(FileBodyProvider{Path: file.Name() + ".notexist"}).
+		Do(context.Background(), nil).Unwrap()
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
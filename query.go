@@ -0,0 +1,143 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// AddQueryInt adds the query key with the integer value.
+func (c *Client) AddQueryInt(key string, v int64) *Client {
+	return c.AddQuery(key, strconv.FormatInt(v, 10))
+}
+
+// AddQueryBool adds the query key with the bool value, "true" or "false".
+func (c *Client) AddQueryBool(key string, v bool) *Client {
+	return c.AddQuery(key, strconv.FormatBool(v))
+}
+
+// AddQueryFloat adds the query key with the float value,
+// which is formatted in the shortest decimal without the exponent.
+func (c *Client) AddQueryFloat(key string, v float64) *Client {
+	return c.AddQuery(key, formatQueryFloat(v))
+}
+
+// AddQueryTime adds the query key with the time formatted by layout.
+//
+// If layout is empty, use time.RFC3339.
+func (c *Client) AddQueryTime(key string, t time.Time, layout string) *Client {
+	return c.AddQuery(key, formatQueryTime(t, layout))
+}
+
+// AddQueryAny adds the query key with the value of any type.
+// See Request.AddQueryAny.
+//
+// But it panics if the value is not supported.
+func (c *Client) AddQueryAny(key string, v interface{}) *Client {
+	value, err := formatQueryAny(v)
+	if err != nil {
+		panic(fmt.Sprintf("Client.AddQueryAny: invalid query '%s': %v", key, err))
+	}
+	return c.AddQuery(key, value)
+}
+
+// AddQueryInt adds the query key with the integer value.
+func (r *Request) AddQueryInt(key string, v int64) *Request {
+	return r.AddQuery(key, strconv.FormatInt(v, 10))
+}
+
+// AddQueryBool adds the query key with the bool value, "true" or "false".
+func (r *Request) AddQueryBool(key string, v bool) *Request {
+	return r.AddQuery(key, strconv.FormatBool(v))
+}
+
+// AddQueryFloat adds the query key with the float value,
+// which is formatted in the shortest decimal without the exponent.
+func (r *Request) AddQueryFloat(key string, v float64) *Request {
+	return r.AddQuery(key, formatQueryFloat(v))
+}
+
+// AddQueryTime adds the query key with the time formatted by layout.
+//
+// If layout is empty, use time.RFC3339.
+func (r *Request) AddQueryTime(key string, t time.Time, layout string) *Request {
+	return r.AddQuery(key, formatQueryTime(t, layout))
+}
+
+// AddQueryAny adds the query key with the value of any type,
+// which is formatted by encoding.TextMarshaler or fmt.Stringer if implemented,
+// or the string, integer, float or bool value of it. The pointer is
+// dereferenced.
+//
+// The other values, such as the nil, the map, the slice, or the struct
+// without Stringer, are not supported, which would be rendered as the Go
+// syntax. And the error is stored and returned when calling Do.
+func (r *Request) AddQueryAny(key string, v interface{}) *Request {
+	value, err := formatQueryAny(v)
+	if err != nil {
+		r.err = fmt.Errorf("invalid query '%s': %v", key, err)
+		return r
+	}
+	return r.AddQuery(key, value)
+}
+
+func formatQueryFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatQueryTime(t time.Time, layout string) string {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return t.Format(layout)
+}
+
+func formatQueryAny(v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return "", fmt.Errorf("unsupported nil %T", v)
+	}
+
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case encoding.TextMarshaler:
+		data, err := value.MarshalText()
+		return string(data), err
+	case fmt.Stringer:
+		return value.String(), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()), nil
+	case reflect.Ptr:
+		return formatQueryAny(rv.Elem().Interface())
+	}
+
+	return "", fmt.Errorf("unsupported query value type %T", v)
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type queryStringer int
+
+func (s queryStringer) String() string { return "stringer" }
+
+func TestTypedQuery(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := NewClient(nil).AddQueryInt("limit", 10).AddQueryBool("all", false)
+
+	req := client.Get("http://127.0.0.1").
+		AddQueryInt("limit", 0).
+		AddQueryInt("offset", -20).
+		AddQueryBool("active", true).
+		AddQueryFloat("ratio", 0.25).
+		AddQueryFloat("large", 1e21).
+		AddQueryTime("since", at, "").
+		AddQueryTime("until", at, "2006-01-02").
+		AddQueryAny("s", "abc").
+		AddQueryAny("u", uint8(8)).
+		AddQueryAny("p", &at).
+		AddQueryAny("ip", net.ParseIP("127.0.0.1")).
+		AddQueryAny("stringer", queryStringer(1))
+	if req.err != nil {
+		t.Fatal(req.err)
+	}
+
+	expect := url.Values{
+		"limit":    []string{"10", "0"},
+		"all":      []string{"false"},
+		"offset":   []string{"-20"},
+		"active":   []string{"true"},
+		"ratio":    []string{"0.25"},
+		"large":    []string{"1000000000000000000000"},
+		"since":    []string{"2024-01-02T03:04:05Z"},
+		"until":    []string{"2024-01-02"},
+		"s":        []string{"abc"},
+		"u":        []string{"8"},
+		"p":        []string{"2024-01-02T03:04:05Z"},
+		"ip":       []string{"127.0.0.1"},
+		"stringer": []string{"stringer"},
+	}
+	if !reflect.DeepEqual(expect, req.query) {
+		t.Errorf("expect %v, but got %v", expect, req.query)
+	}
+
+	// The request does not modify the defaults of the client.
+	expect = url.Values{"limit": []string{"10"}, "all": []string{"false"}}
+	if !reflect.DeepEqual(expect, client.query) {
+		t.Errorf("expect %v, but got %v", expect, client.query)
+	} else if req = client.Get("http://127.0.0.1"); !reflect.DeepEqual(expect, req.query) {
+		t.Errorf("expect %v, but got %v", expect, req.query)
+	}
+}
+
+func TestAddQueryAnyError(t *testing.T) {
+	client := NewClient(nil).SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	var nilptr *int
+	var niltime *time.Time
+	for _, v := range []interface{}{nil, nilptr, niltime, map[string]int{}, []int{1}, struct{}{}} {
+		err := client.Get("http://127.0.0.1").AddQueryAny("key", v).Do(context.Background(), nil).Unwrap()
+		if err == nil {
+			t.Errorf("%T: expect an error, but got nil", v)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect a panic, but got nil")
+		}
+	}()
+	client.AddQueryAny("key", map[string]int{})
+}
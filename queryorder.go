@@ -0,0 +1,94 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// PreserveQueryOrder sets whether to encode the queries in the order
+// in which the keys are added first, instead of sorting them by the key,
+// such as the api validating the signature over the query string.
+//
+// The queries in the request url go first in their original order,
+// and the values of the same key are always encoded together.
+// The keys added from a map, such as AddQueries, are added in the sorted
+// order, but the keys added by SetQueryStruct are in the order of the fields.
+//
+// Default: false
+func (c *Client) PreserveQueryOrder(preserve bool) *Client {
+	c.keepqorder = preserve
+	return c
+}
+
+// queryOrder returns the order of the query keys to encode,
+// which is nil if not preserving the order.
+func (r *Request) queryOrder() []string {
+	if !r.keepqorder {
+		return nil
+	} else if r.qorder == nil {
+		return []string{}
+	}
+	return r.qorder
+}
+
+// appendQueryKey appends key into order if it is a new key of query.
+func appendQueryKey(order []string, query url.Values, key string) []string {
+	if _, ok := query[key]; !ok {
+		order = append(order, key)
+	}
+	return order
+}
+
+func sortedQueryKeys(queries url.Values) []string {
+	keys := make([]string, 0, len(queries))
+	for key := range queries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedMapKeys(queries map[string]string) []string {
+	keys := make([]string, 0, len(queries))
+	for key := range queries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rawQueryKeys returns the keys of the raw query in order.
+func rawQueryKeys(rawquery string) (keys []string) {
+	for rawquery != "" {
+		var pair string
+		if i := strings.IndexByte(rawquery, '&'); i < 0 {
+			pair, rawquery = rawquery, ""
+		} else {
+			pair, rawquery = rawquery[:i], rawquery[i+1:]
+		}
+
+		if i := strings.IndexByte(pair, '='); i >= 0 {
+			pair = pair[:i]
+		}
+
+		if key, err := url.QueryUnescape(pair); err == nil && key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestPreserveQueryOrder(t *testing.T) {
+	var rawquery string
+	client := NewClient(nil).OnResponse(nil).PreserveQueryOrder(true).
+		AddQuery("zeta", "1").AddQuery("alpha", "2")
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		rawquery = r.URL.RawQuery
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	do := func(r *Request) string {
+		if err := r.Do(context.Background(), nil).Unwrap(); err != nil {
+			t.Fatal(err)
+		}
+		return rawquery
+	}
+
+	type Params struct {
+		Timestamp int    `query:"ts"`
+		Nonce     string `query:"nonce"`
+	}
+
+	req := client.Get("http://127.0.0.1/path").
+		AddQuery("mid", "a b").
+		SetQuery("alpha", "3").
+		AddQuery("zeta", "4").
+		SetQueryStruct(Params{Timestamp: 1, Nonce: "x"}).
+		AddQueryMap(map[string]string{"y": "5", "b": "6"})
+	if expect := "zeta=1&zeta=4&alpha=3&mid=a+b&ts=1&nonce=x&b=6&y=5"; do(req) != expect {
+		t.Errorf("expect raw query '%s', but got '%s'", expect, rawquery)
+	}
+
+	// The queries in the url go first.
+	req = client.Get("http://127.0.0.1/path?q=1&alpha=0").AddQuery("first", "1")
+	if expect := "q=1&alpha=2&zeta=1&first=1"; do(req) != expect {
+		t.Errorf("expect raw query '%s', but got '%s'", expect, rawquery)
+	}
+
+	// The request does not change the order of the client.
+	if expect := "zeta=1&alpha=2"; do(client.Get("http://127.0.0.1")) != expect {
+		t.Errorf("expect raw query '%s', but got '%s'", expect, rawquery)
+	}
+
+	// The clone keeps the order, which is independent of the original.
+	clone := client.Clone().AddQuery("beta", "7")
+	client.AddQuery("gamma", "8")
+	if expect := "zeta=1&alpha=2&beta=7"; do(clone.Get("http://127.0.0.1")) != expect {
+		t.Errorf("expect raw query '%s', but got '%s'", expect, rawquery)
+	}
+	if expect := "zeta=1&alpha=2&gamma=8"; do(client.Get("http://127.0.0.1")) != expect {
+		t.Errorf("expect raw query '%s', but got '%s'", expect, rawquery)
+	}
+
+	// Combine with the array style.
+	req = client.Get("http://127.0.0.1").SetQueryArrayStyle(QueryArrayComma).AddQuery("alpha", "9")
+	if expect := "zeta=1&alpha=2,9&gamma=8"; do(req) != expect {
+		t.Errorf("expect raw query '%s', but got '%s'", expect, rawquery)
+	}
+
+	// Sort the keys by default.
+	client.PreserveQueryOrder(false)
+	if expect := "alpha=2&gamma=8&zeta=1"; do(client.Get("http://127.0.0.1")) != expect {
+		t.Errorf("expect raw query '%s', but got '%s'", expect, rawquery)
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetQueryStruct encodes the struct v into the default queries,
+// which replaces the existing values of the same keys.
+//
+// See Request.SetQueryStruct for the encoding rules.
+// But it panics if failing to encode v.
+func (c *Client) SetQueryStruct(v interface{}) *Client {
+	query := make(url.Values, 8)
+	keys, err := encodeQueryStruct(query, v)
+	if err != nil {
+		panic("Client.SetQueryStruct: " + err.Error())
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, key := range keys {
+		c.qorder = appendQueryKey(c.qorder, c.query, key)
+		c.query[key] = query[key]
+	}
+	return c
+}
+
+// SetQueryStruct encodes the struct v into the request queries,
+// which replaces the existing values of the same keys.
+// v may be a struct or a pointer to struct, and the nil pointer is ignored.
+//
+// The field is named by the tag "query", or the tag "url" if missing,
+// such as `query:"name,omitempty"`, which is the field name by default,
+// and the field with the tag "-" is ignored. The option "omitempty" omits
+// the field with the zero value.
+//
+// The supported field types are string, integer, float, bool, time.Time,
+// the pointers to them, and the slices or arrays of them. The nil pointer
+// is omitted, and the element of the slice or array is encoded as a value
+// of the repeated key. time.Time is formatted by time.RFC3339 by default,
+// which may be overridden by the tag "layout", such as `layout:"2006-01-02"`.
+//
+// The fields of the embedded struct without the name are promoted like the
+// json encoding, which are shadowed by the outer fields with the same names.
+// But it is an error if the fields at the same depth have the same name.
+//
+// If failing to encode v, such as the unsupported field type,
+// the error is stored and returned when calling Do.
+func (r *Request) SetQueryStruct(v interface{}) *Request {
+	query := make(url.Values, 8)
+	keys, err := encodeQueryStruct(query, v)
+	if err != nil {
+		r.err = err
+		return r
+	} else if len(keys) == 0 {
+		return r
+	}
+
+	r.cloneQuery()
+	for _, key := range keys {
+		r.qorder = appendQueryKey(r.qorder, r.query, key)
+		r.query[key] = query[key]
+	}
+	return r
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type queryField struct {
+	name   string
+	depth  int
+	layout string
+	omit   bool // omitempty
+	value  reflect.Value
+	dup    bool // Whether there are the other fields with the same depth
+}
+
+// encodeQueryStruct encodes the struct v into query,
+// and returns the keys encoded in the order of the fields.
+func encodeQueryStruct(query url.Values, v interface{}) (keys []string, err error) {
+	return encodeStruct(query, v, structTags{kind: "query", tags: []string{"query", "url"}})
+}
+
+// structTags is the tags to encode the struct fields.
+type structTags struct {
+	kind  string   // Such as "query" or "header"
+	tags  []string // The tags to name the field, which are looked up in turn
+	canon func(string) string
+}
+
+func (t structTags) lookup(tag reflect.StructTag) string {
+	for _, key := range t.tags {
+		if value, ok := tag.Lookup(key); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// encodeStruct encodes the struct v into dst by the tags,
+// and returns the keys encoded in the order of the fields.
+func encodeStruct(dst map[string][]string, v interface{}, tags structTags) (keys []string, err error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("the %s struct must be a struct, but got %T", tags.kind, v)
+	}
+
+	var names []string
+	fields := make(map[string]*queryField, value.NumField())
+	collectQueryFields(fields, &names, value, 0, tags)
+
+	for _, name := range names {
+		field := fields[name]
+		if field.dup {
+			return nil, fmt.Errorf("duplicate %s key '%s' in %T", tags.kind, name, v)
+		}
+
+		values, err := encodeQueryValue(nil, field.value, field.layout, field.omit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s field '%s': %v", tags.kind, name, err)
+		} else if len(values) > 0 {
+			dst[name] = values
+			keys = append(keys, name)
+		}
+	}
+
+	return
+}
+
+func collectQueryFields(fields map[string]*queryField, names *[]string, v reflect.Value, depth int, tags structTags) {
+	typ := v.Type()
+	for i, _len := 0, typ.NumField(); i < _len; i++ {
+		sf := typ.Field(i)
+		tag := tags.lookup(sf.Tag)
+		if tag == "-" {
+			continue
+		}
+
+		name, opts := tag, ""
+		if index := strings.IndexByte(tag, ','); index > -1 {
+			name, opts = tag[:index], tag[index+1:]
+		}
+
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+
+				collectQueryFields(fields, names, fv, depth+1, tags)
+				continue
+			}
+		}
+
+		if sf.PkgPath != "" { // Unexported
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		if tags.canon != nil {
+			name = tags.canon(name)
+		}
+
+		field := &queryField{
+			name:   name,
+			depth:  depth,
+			layout: sf.Tag.Get("layout"),
+			value:  fv,
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "omitempty" {
+				field.omit = true
+			}
+		}
+
+		switch old, ok := fields[name]; {
+		case !ok:
+			*names = append(*names, name)
+			fields[name] = field
+		case old.depth > depth:
+			fields[name] = field
+		case old.depth == depth:
+			old.dup = true
+		}
+	}
+}
+
+func encodeQueryValue(values []string, v reflect.Value, layout string, omitempty bool) ([]string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return values, nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		if !v.CanInterface() {
+			return nil, fmt.Errorf("inaccessible time.Time promoted from an unexported struct")
+		}
+
+		t := v.Interface().(time.Time)
+		if omitempty && t.IsZero() {
+			return values, nil
+		} else if layout == "" {
+			layout = time.RFC3339
+		}
+		return append(values, t.Format(layout)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if s := v.String(); !omitempty || s != "" {
+			values = append(values, s)
+		}
+
+	case reflect.Bool:
+		if b := v.Bool(); !omitempty || b {
+			values = append(values, strconv.FormatBool(b))
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := v.Int(); !omitempty || i != 0 {
+			values = append(values, strconv.FormatInt(i, 10))
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if i := v.Uint(); !omitempty || i != 0 {
+			values = append(values, strconv.FormatUint(i, 10))
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); !omitempty || f != 0 {
+			values = append(values, strconv.FormatFloat(f, 'f', -1, v.Type().Bits()))
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i, _len := 0, v.Len(); i < _len; i++ {
+			elem := v.Index(i)
+			switch elem.Kind() {
+			case reflect.Slice, reflect.Array:
+				return nil, fmt.Errorf("unsupported type %s", v.Type())
+			}
+
+			var err error
+			if values, err = encodeQueryValue(values, elem, layout, false); err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return values, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type QueryPage struct {
+	Page  int    `query:"page,omitempty"`
+	Limit int    `query:"limit"`
+	Sort  string `query:"sort"`
+}
+
+type queryFilter struct {
+	*QueryPage
+	Sort string `query:"sort"` // Shadow QueryPage.Sort
+
+	Name     string     `url:"name,omitempty"`
+	Tags     []string   `query:"tag"`
+	IDs      [2]uint8   `query:"id"`
+	Active   *bool      `query:"active"`
+	Deleted  *bool      `query:"deleted"`
+	Score    float64    `query:"score,omitempty"`
+	Ratio    float32    `query:"ratio"`
+	Since    time.Time  `query:"since"`
+	Until    *time.Time `query:"until" layout:"2006-01-02"`
+	Ignored  string     `query:"-"`
+	internal string
+}
+
+func TestRequestSetQueryStruct(t *testing.T) {
+	active := true
+	until := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	filter := queryFilter{
+		QueryPage: &QueryPage{Limit: 10, Sort: "shadowed"},
+		Sort:      "name",
+		Tags:      []string{"a", "b"},
+		IDs:       [2]uint8{1, 2},
+		Active:    &active,
+		Ratio:     0.5,
+		Since:     until,
+		Until:     &until,
+		Ignored:   "ignored",
+		internal:  "internal",
+	}
+
+	req := NewClient(nil).AddQuery("limit", "100").AddQuery("keep", "1").
+		Get("http://127.0.0.1").SetQueryStruct(&filter)
+	if req.err != nil {
+		t.Fatal(req.err)
+	}
+
+	expect := url.Values{
+		"keep":   []string{"1"},
+		"limit":  []string{"10"},
+		"sort":   []string{"name"},
+		"tag":    []string{"a", "b"},
+		"id":     []string{"1", "2"},
+		"active": []string{"true"},
+		"ratio":  []string{"0.5"},
+		"since":  []string{"2024-01-02T03:04:05Z"},
+		"until":  []string{"2024-01-02"},
+	}
+	if !reflect.DeepEqual(expect, req.query) {
+		t.Errorf("expect %v, but got %v", expect, req.query)
+	}
+
+	// Embedded nil pointer
+	req = NewClient(nil).Get("http://127.0.0.1").SetQueryStruct(queryFilter{})
+	if req.err != nil {
+		t.Fatal(req.err)
+	} else if _, ok := req.query["limit"]; ok {
+		t.Errorf("unexpected query limit")
+	}
+}
+
+func TestRequestSetQueryStructError(t *testing.T) {
+	type duplicate struct {
+		Name  string `query:"name"`
+		Alias string `url:"name"`
+	}
+
+	type unsupported struct {
+		Map map[string]string `query:"map"`
+	}
+
+	client := NewClient(nil).SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	for _, v := range []interface{}{duplicate{}, unsupported{}, "string"} {
+		err := client.Get("http://127.0.0.1").SetQueryStruct(v).Do(context.Background(), nil).Unwrap()
+		if err == nil {
+			t.Errorf("%T: expect an error, but got nil", v)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect a panic, but got nil")
+		}
+	}()
+	client.SetQueryStruct(duplicate{})
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/url"
+	"sort"
+)
+
+// QueryArrayStyle is the style to encode the query key with multiple values.
+type QueryArrayStyle int
+
+// Pre-define some query array styles.
+const (
+	QueryArrayRepeat   QueryArrayStyle = iota // ids=1&ids=2
+	QueryArrayBrackets                        // ids%5B%5D=1&ids%5B%5D=2 (ids[]=1&ids[]=2)
+	QueryArrayComma                           // ids=1,2
+	QueryArrayPipes                           // ids=1|2
+)
+
+// SetQueryArrayStyle sets the style to encode the query key
+// with multiple values, which is applied when building the request url.
+//
+// The key with a single value is always encoded as "key=value".
+// For QueryArrayComma and QueryArrayPipes, the separator is not escaped,
+// but the commas or pipes in the values are percent-encoded.
+//
+// Default: QueryArrayRepeat
+func (c *Client) SetQueryArrayStyle(style QueryArrayStyle) *Client {
+	c.querystyle = style
+	return c
+}
+
+// SetQueryArrayStyle sets the style to encode the query key
+// with multiple values.
+//
+// See Client.SetQueryArrayStyle.
+//
+// Default: inherit from the client
+func (r *Request) SetQueryArrayStyle(style QueryArrayStyle) *Request {
+	r.querystyle = style
+	return r
+}
+
+// encodeQuery is the same as url.Values.Encode, but encodes the key
+// with multiple values by the style.
+//
+// If order is not nil, the keys are encoded in the order, and the rest keys
+// not in order are encoded in the sorted order after them.
+func encodeQuery(query url.Values, style QueryArrayStyle, order []string) string {
+	if style == QueryArrayRepeat && order == nil {
+		return query.Encode()
+	}
+
+	keys := make([]string, 0, len(query))
+	seen := make(map[string]struct{}, len(order))
+	for _, key := range order {
+		if _, ok := seen[key]; !ok {
+			if _, ok = query[key]; ok {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	start := len(keys)
+	for key := range query {
+		if _, ok := seen[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys[start:])
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	for _, key := range keys {
+		values := query[key]
+		if len(values) == 0 {
+			continue
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+
+		switch {
+		case len(values) == 1:
+			buf.WriteString(url.QueryEscape(key))
+			buf.WriteByte('=')
+			buf.WriteString(url.QueryEscape(values[0]))
+
+		case style == QueryArrayRepeat, style == QueryArrayBrackets:
+			if style == QueryArrayBrackets {
+				key += "[]"
+			}
+
+			key = url.QueryEscape(key)
+			for i, value := range values {
+				if i > 0 {
+					buf.WriteByte('&')
+				}
+				buf.WriteString(key)
+				buf.WriteByte('=')
+				buf.WriteString(url.QueryEscape(value))
+			}
+
+		default:
+			sep := byte(',')
+			if style == QueryArrayPipes {
+				sep = '|'
+			}
+
+			buf.WriteString(url.QueryEscape(key))
+			buf.WriteByte('=')
+			for i, value := range values {
+				if i > 0 {
+					buf.WriteByte(sep)
+				}
+				buf.WriteString(url.QueryEscape(value))
+			}
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestQueryArrayStyle(t *testing.T) {
+	var rawquery string
+	client := NewClient(nil).OnResponse(nil).AddQuery("ids", "1").AddQuery("ids", "2,3")
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		rawquery = r.URL.RawQuery
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	for _, c := range []struct {
+		style  QueryArrayStyle
+		expect string
+	}{
+		{QueryArrayRepeat, "a=x&ids=1&ids=2%2C3&name=a+b%7Cc"},
+		{QueryArrayBrackets, "a=x&ids%5B%5D=1&ids%5B%5D=2%2C3&name=a+b%7Cc"},
+		{QueryArrayComma, "a=x&ids=1,2%2C3&name=a+b%7Cc"},
+		{QueryArrayPipes, "a=x&ids=1|2%2C3&name=a+b%7Cc"},
+	} {
+		err := client.Get("http://127.0.0.1/path?a=x").
+			AddQuery("name", "a b|c").
+			SetQueryArrayStyle(c.style).
+			Do(context.Background(), nil).Unwrap()
+		if err != nil {
+			t.Fatal(err)
+		} else if rawquery != c.expect {
+			t.Errorf("%d: expect raw query '%s', but got '%s'", c.style, c.expect, rawquery)
+		}
+	}
+
+	client.SetQueryArrayStyle(QueryArrayComma)
+	req := client.Get("http://127.0.0.1/path")
+	if expect := "http://127.0.0.1/path?ids=1,2%2C3"; req.URLString() != expect {
+		t.Errorf("expect url '%s', but got '%s'", expect, req.URLString())
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pre-define some headers about the byte range.
+const (
+	HeaderRange        = "Range"
+	HeaderContentRange = "Content-Range"
+)
+
+// SetRange sets the header "Range" to request the bytes
+// from start to end inclusively, such as "bytes=0-1023".
+func (r *Request) SetRange(start, end int64) *Request {
+	if start < 0 || end < start {
+		r.err = fmt.Errorf("invalid byte range %d-%d", start, end)
+		return r
+	}
+	return r.SetHeader(HeaderRange, fmt.Sprintf("bytes=%d-%d", start, end))
+}
+
+// SetRangeFrom sets the header "Range" to request the bytes
+// from start to the end, such as "bytes=1024-".
+func (r *Request) SetRangeFrom(start int64) *Request {
+	if start < 0 {
+		r.err = fmt.Errorf("invalid byte range start %d", start)
+		return r
+	}
+	return r.SetHeader(HeaderRange, fmt.Sprintf("bytes=%d-", start))
+}
+
+// IsPartialContent reports whether the response status code is 206.
+func (r *Response) IsPartialContent() bool { return r.StatusCode() == 206 }
+
+// ContentRange parses the response header "Content-Range", such as
+// "bytes 0-1023/4096", and returns the byte range and the total length.
+//
+// For the form "bytes */4096", start and end are -1.
+// For the form "bytes 0-1023/*", total is -1.
+//
+// ok is false if the header does not exist or is invalid.
+func (r *Response) ContentRange() (start, end, total int64, ok bool) {
+	if r.resp == nil {
+		return
+	}
+	return parseContentRange(r.resp.Header.Get(HeaderContentRange))
+}
+
+func parseContentRange(value string) (start, end, total int64, ok bool) {
+	const prefix = "bytes "
+	if !strings.HasPrefix(value, prefix) {
+		return
+	}
+
+	value = strings.TrimSpace(value[len(prefix):])
+	index := strings.IndexByte(value, '/')
+	if index < 0 {
+		return
+	}
+
+	var err error
+	brange, size := value[:index], value[index+1:]
+	if size == "*" {
+		total = -1
+	} else if total, err = strconv.ParseInt(size, 10, 64); err != nil || total < 0 {
+		return
+	}
+
+	if brange == "*" {
+		if total < 0 { // "*/*" is invalid.
+			return
+		}
+		return -1, -1, total, true
+	}
+
+	if index = strings.IndexByte(brange, '-'); index < 0 {
+		return
+	}
+
+	if start, err = strconv.ParseInt(brange[:index], 10, 64); err != nil || start < 0 {
+		return
+	}
+	if end, err = strconv.ParseInt(brange[index+1:], 10, 64); err != nil || end < start {
+		return
+	}
+	if total >= 0 && end >= total {
+		return
+	}
+
+	ok = true
+	return
+}
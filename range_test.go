@@ -0,0 +1,87 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		Value             string
+		Start, End, Total int64
+		Ok                bool
+	}{
+		{Value: "bytes 0-1023/4096", Start: 0, End: 1023, Total: 4096, Ok: true},
+		{Value: "bytes 1024-4095/*", Start: 1024, End: 4095, Total: -1, Ok: true},
+		{Value: "bytes */4096", Start: -1, End: -1, Total: 4096, Ok: true},
+		{Value: "bytes */*"},
+		{Value: "bytes 10-5/100"},
+		{Value: "bytes 0-100/100"},
+		{Value: "bytes 0-a/100"},
+		{Value: "items 0-1/2"},
+		{Value: ""},
+	}
+
+	for _, test := range tests {
+		start, end, total, ok := parseContentRange(test.Value)
+		if ok != test.Ok {
+			t.Errorf("%s: expect ok %v, but got %v", test.Value, test.Ok, ok)
+		} else if ok && (start != test.Start || end != test.End || total != test.Total) {
+			t.Errorf("%s: expect %d-%d/%d, but got %d-%d/%d", test.Value,
+				test.Start, test.End, test.Total, start, end, total)
+		}
+	}
+}
+
+func TestRequestRange(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "data", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		SetResponseHandler(func(interface{}, *http.Response) error { return nil })
+
+	resp := client.Get(server.URL).SetRange(10, 14).Do(context.Background(), nil)
+	if body, err := resp.ReadBody(); err != nil {
+		t.Fatal(err)
+	} else if body != "01234" {
+		t.Errorf("expect body '%s', but got '%s'", "01234", body)
+	}
+
+	if !resp.IsPartialContent() {
+		t.Errorf("expect the partial content")
+	} else if start, end, total, ok := resp.ContentRange(); !ok || start != 10 || end != 14 || total != 100 {
+		t.Errorf("unexpected content range: %d-%d/%d", start, end, total)
+	}
+
+	resp = client.Get(server.URL).SetRangeFrom(95).Do(context.Background(), nil)
+	if body, err := resp.ReadBody(); err != nil {
+		t.Fatal(err)
+	} else if body != "56789" {
+		t.Errorf("expect body '%s', but got '%s'", "56789", body)
+	}
+
+	if err := client.Get(server.URL).SetRange(10, 5).Do(context.Background(), nil).Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdaptiveLimiter is used to limit the rate of the requests adaptively
+// by the feedback of the responses, such as the quota state of the server.
+type AdaptiveLimiter interface {
+	// Wait is called before sending each request, which blocks
+	// until the request is allowed to be sent or ctx is done.
+	Wait(ctx context.Context) error
+
+	// Observe is called with each received response.
+	Observe(resp *http.Response)
+}
+
+// SetAdaptiveLimiter sets the adaptive limiter of the requests.
+//
+// If limiter is nil, no limit.
+//
+// Default: nil
+func (c *Client) SetAdaptiveLimiter(limiter AdaptiveLimiter) *Client {
+	c.limiter = limiter
+	return c
+}
+
+// Pre-define some headers about the rate limit.
+const (
+	HeaderXRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderXRateLimitReset     = "X-RateLimit-Reset"
+)
+
+var _ AdaptiveLimiter = new(QuotaLimiter)
+
+// QuotaLimiter is an adaptive limiter based on the quota state
+// returned by the server, which spreads the remaining requests evenly
+// until the quota is reset to keep the remaining quota above the floor.
+type QuotaLimiter struct {
+	// RemainingHeader is the response header of the number
+	// of the remaining requests in the current window.
+	//
+	// Default: "X-RateLimit-Remaining"
+	RemainingHeader string
+
+	// ResetHeader is the response header of the time when the quota
+	// is reset, which is either the number of seconds until the reset
+	// or the unix timestamp in seconds.
+	//
+	// Default: "X-RateLimit-Reset"
+	ResetHeader string
+
+	// Floor is the number of the remaining requests to be reserved.
+	Floor int
+
+	lock  sync.Mutex
+	next  time.Time
+	delay time.Duration
+}
+
+// NewQuotaLimiter returns a new quota limiter with the floor
+// of the remaining requests.
+func NewQuotaLimiter(floor int) *QuotaLimiter {
+	return &QuotaLimiter{
+		RemainingHeader: HeaderXRateLimitRemaining,
+		ResetHeader:     HeaderXRateLimitReset,
+		Floor:           floor,
+	}
+}
+
+// Delay returns the current delay between two requests.
+func (l *QuotaLimiter) Delay() time.Duration {
+	l.lock.Lock()
+	delay := l.delay
+	l.lock.Unlock()
+	return delay
+}
+
+// Wait implements the interface AdaptiveLimiter.
+func (l *QuotaLimiter) Wait(ctx context.Context) error {
+	now := time.Now()
+	l.lock.Lock()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.delay)
+	l.lock.Unlock()
+
+	wait := start.Sub(now)
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Observe implements the interface AdaptiveLimiter.
+func (l *QuotaLimiter) Observe(resp *http.Response) {
+	remainingHeader, resetHeader := l.RemainingHeader, l.ResetHeader
+	if remainingHeader == "" {
+		remainingHeader = HeaderXRateLimitRemaining
+	}
+	if resetHeader == "" {
+		resetHeader = HeaderXRateLimitReset
+	}
+
+	remaining, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get(remainingHeader)))
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	reset, ok := parseQuotaReset(resp.Header.Get(resetHeader), now)
+	if !ok {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if budget := remaining - l.Floor; budget > 0 {
+		l.delay = reset / time.Duration(budget)
+	} else { // Wait for the quota to be reset.
+		l.delay = 0
+		if next := now.Add(reset); next.After(l.next) {
+			l.next = next
+		}
+	}
+}
+
+// parseQuotaReset parses the duration until the quota is reset.
+func parseQuotaReset(value string, now time.Time) (time.Duration, bool) {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	// Regard the large number as the unix timestamp.
+	if n > 1000000000 {
+		if reset := time.Unix(n, 0).Sub(now); reset > 0 {
+			return reset, true
+		}
+		return 0, true
+	}
+	return time.Duration(n) * time.Second, true
+}
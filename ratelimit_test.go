@@ -0,0 +1,104 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type testLimiter struct {
+	waits    int
+	observed []int
+}
+
+func (l *testLimiter) Wait(ctx context.Context) error { l.waits++; return nil }
+func (l *testLimiter) Observe(resp *http.Response) {
+	l.observed = append(l.observed, resp.StatusCode)
+}
+
+func TestAdaptiveLimiter(t *testing.T) {
+	limiter := new(testLimiter)
+	client := NewClient(nil).OnResponse(nil).SetAdaptiveLimiter(limiter)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	for i := 0; i < 3; i++ {
+		if err := client.Get("http://127.0.0.1").Do(context.Background(), nil).Unwrap(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if limiter.waits != 3 || len(limiter.observed) != 3 || limiter.observed[0] != 204 {
+		t.Errorf("unexpected limiter: waits=%d, observed=%v", limiter.waits, limiter.observed)
+	}
+}
+
+func TestQuotaLimiter(t *testing.T) {
+	newResponse := func(remaining, reset string) *http.Response {
+		resp := &http.Response{Header: make(http.Header)}
+		resp.Header.Set(HeaderXRateLimitRemaining, remaining)
+		resp.Header.Set(HeaderXRateLimitReset, reset)
+		return resp
+	}
+
+	limiter := NewQuotaLimiter(1)
+	limiter.Observe(newResponse("11", "1"))
+	if delay := limiter.Delay(); delay != time.Millisecond*100 {
+		t.Errorf("expect delay %s, but got %s", time.Millisecond*100, delay)
+	}
+
+	reset := strconv.FormatInt(time.Now().Add(time.Second*20).Unix(), 10)
+	limiter.Observe(newResponse("41", reset))
+	if delay := limiter.Delay(); delay < time.Millisecond*400 || delay > time.Millisecond*500 {
+		t.Errorf("expect delay about %s, but got %s", time.Millisecond*500, delay)
+	}
+
+	// The zero value uses the default headers.
+	var zero QuotaLimiter
+	zero.Observe(newResponse("10", "1"))
+	if delay := zero.Delay(); delay != time.Millisecond*100 {
+		t.Errorf("expect delay %s, but got %s", time.Millisecond*100, delay)
+	}
+
+	limiter.Observe(newResponse("abc", "1")) // Ignore the invalid headers.
+	if delay := limiter.Delay(); delay < time.Millisecond*400 {
+		t.Errorf("expect the delay unchanged, but got %s", delay)
+	}
+
+	// Exhausted
+	limiter.Observe(newResponse("1", "10"))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expect error DeadlineExceeded, but got %v", err)
+	}
+
+	limiter = NewQuotaLimiter(0)
+	limiter.Observe(newResponse("100", "1"))
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cost := time.Since(start); cost < time.Millisecond*20 {
+		t.Errorf("expect to wait at least %s, but got %s", time.Millisecond*20, cost)
+	}
+}
@@ -0,0 +1,296 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"unicode/utf8"
+)
+
+// RecorderMode is the mode of RecorderDoer.
+type RecorderMode int
+
+// Pre-define the modes of RecorderDoer.
+const (
+	// RecorderRecord sends the requests by the real doer
+	// and records the interactions.
+	RecorderRecord RecorderMode = iota
+
+	// RecorderReplay replays the recorded interactions
+	// without sending the requests.
+	RecorderReplay
+)
+
+// RecordedBody is the recorded body of the request or response,
+// which is encoded by base64 if it is not valid UTF-8.
+type RecordedBody struct {
+	Data     string `json:"data,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+func newRecordedBody(data []byte) RecordedBody {
+	if utf8.Valid(data) {
+		return RecordedBody{Data: string(data)}
+	}
+	return RecordedBody{Data: base64.StdEncoding.EncodeToString(data), Encoding: "base64"}
+}
+
+// Bytes decodes and returns the body data.
+func (b RecordedBody) Bytes() ([]byte, error) {
+	switch b.Encoding {
+	case "":
+		return []byte(b.Data), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(b.Data)
+	default:
+		return nil, fmt.Errorf("unknown recorded body encoding '%s'", b.Encoding)
+	}
+}
+
+// RecordedRequest is the recorded request of an interaction.
+type RecordedRequest struct {
+	Method   string       `json:"method"`
+	URL      string       `json:"url"`
+	Header   http.Header  `json:"header,omitempty"`
+	Body     RecordedBody `json:"body"`
+	BodyHash string       `json:"body_hash"` // The hex sha256 of the body
+}
+
+// RecordedResponse is the recorded response of an interaction.
+type RecordedResponse struct {
+	StatusCode int          `json:"status_code"`
+	Header     http.Header  `json:"header,omitempty"`
+	Body       RecordedBody `json:"body"`
+}
+
+// Interaction is a recorded pair of the request and response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecorderDoer is a doer to record the interactions with the real doer
+// into the JSON cassette file, and replay them later without the network,
+// which is used to build the fixtures of the integration tests.
+//
+// In the replay mode, the request is matched by the method, url
+// and the sha256 hash of the body, and the unused interaction is preferred
+// if there are multiple matched ones.
+type RecorderDoer struct {
+	doer   Doer
+	mode   RecorderMode
+	path   string
+	redact map[string]struct{}
+
+	lock         sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorderDoer returns a new recorder doer with the cassette file path.
+//
+// In the record mode, doer is used to send the requests, and Save must be
+// called to write the recorded interactions into the cassette file.
+// In the replay mode, the cassette file is loaded, and doer is ignored.
+//
+// The headers "Authorization" and "Proxy-Authorization" are redacted
+// by default before being recorded. See RedactHeaders.
+func NewRecorderDoer(path string, mode RecorderMode, doer Doer) (*RecorderDoer, error) {
+	r := &RecorderDoer{doer: doer, mode: mode, path: path, redact: make(map[string]struct{}, 4)}
+	r.RedactHeaders("Authorization", "Proxy-Authorization")
+
+	switch mode {
+	case RecorderRecord:
+		if doer == nil {
+			panic("NewRecorderDoer: the doer must not be nil in the record mode")
+		}
+
+	case RecorderReplay:
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		} else if err = json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("invalid cassette file '%s': %s", path, err)
+		}
+		r.used = make([]bool, len(r.interactions))
+
+	default:
+		panic(fmt.Errorf("NewRecorderDoer: unknown recorder mode %d", mode))
+	}
+
+	return r, nil
+}
+
+// RedactHeaders appends the headers to be redacted before being recorded.
+func (r *RecorderDoer) RedactHeaders(keys ...string) *RecorderDoer {
+	for _, key := range keys {
+		r.redact[http.CanonicalHeaderKey(key)] = struct{}{}
+	}
+	return r
+}
+
+// Interactions returns the recorded or loaded interactions.
+func (r *RecorderDoer) Interactions() []Interaction {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions into the cassette file as JSON.
+func (r *RecorderDoer) Save() error {
+	r.lock.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// Do implements the interface Doer.
+func (r *RecorderDoer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hash := sha256.Sum256(body)
+	bodyhash := hex.EncodeToString(hash[:])
+	if r.mode == RecorderReplay {
+		return r.replay(req, bodyhash)
+	}
+
+	// Restore the buffered request body to be sent.
+	if body != nil {
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respbody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respbody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method:   req.Method,
+			URL:      req.URL.String(),
+			Header:   r.redactHeader(req.Header),
+			Body:     newRecordedBody(body),
+			BodyHash: bodyhash,
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactHeader(resp.Header),
+			Body:       newRecordedBody(respbody),
+		},
+	}
+
+	r.lock.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.lock.Unlock()
+
+	return resp, nil
+}
+
+func (r *RecorderDoer) redactHeader(header http.Header) http.Header {
+	header = cloneHeader(header)
+	for key := range header {
+		if _, ok := r.redact[key]; ok {
+			header[key] = []string{RedactedValue}
+		}
+	}
+	return header
+}
+
+func (r *RecorderDoer) replay(req *http.Request, bodyhash string) (*http.Response, error) {
+	url := req.URL.String()
+	match := func(i Interaction) bool {
+		return i.Request.Method == req.Method && i.Request.URL == url && i.Request.BodyHash == bodyhash
+	}
+
+	r.lock.Lock()
+	index := -1
+	for i, interaction := range r.interactions {
+		if match(interaction) {
+			if index < 0 {
+				index = i
+			}
+			if !r.used[i] {
+				index = i
+				break
+			}
+		}
+	}
+	if index >= 0 {
+		r.used[index] = true
+	}
+	r.lock.Unlock()
+
+	if index < 0 {
+		return nil, r.noInteractionError(req.Method, url, bodyhash)
+	}
+
+	recorded := r.interactions[index].Response
+	body, err := recorded.Body.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	header := cloneHeader(recorded.Header)
+	if header == nil {
+		header = make(http.Header, 1)
+	}
+	return newStaticResponse(req, recorded.StatusCode, header, body), nil
+}
+
+func (r *RecorderDoer) noInteractionError(method, url, bodyhash string) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	fmt.Fprintf(buf, "no recorded interaction in '%s' matches the request '%s %s' with the body sha256 %s",
+		r.path, method, url, bodyhash)
+
+	if len(r.interactions) == 0 {
+		buf.WriteString(", and no interactions are recorded")
+	} else {
+		buf.WriteString(", recorded:")
+		for _, i := range r.interactions {
+			fmt.Fprintf(buf, "\n  %s %s (body sha256 %s)", i.Request.Method, i.Request.URL, i.Request.BodyHash)
+		}
+	}
+
+	return errors.New(buf.String())
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderDoer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		if r.Method == http.MethodPost {
+			_, _ = w.Write([]byte(`{"echo":` + string(body) + `}`))
+		} else {
+			_, _ = w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+		}
+	}))
+
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cassette := filepath.Join(dir, "cassette.json")
+
+	type Result struct {
+		Path string                 `json:"path"`
+		Echo map[string]interface{} `json:"echo"`
+	}
+
+	doRequests := func(client *Client) (results []Result) {
+		t.Helper()
+
+		var result Result
+		err := client.Get(server.URL+"/users").SetHeader("Authorization", "Bearer secret").
+			Do(context.Background(), &result).Unwrap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, result)
+
+		result = Result{}
+		err = client.Post(server.URL+"/users").SetBody(map[string]interface{}{"name": "Aaron"}).
+			Do(context.Background(), &result).Unwrap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, result)
+		return
+	}
+
+	// Record
+	recorder, err := NewRecorderDoer(cassette, RecorderRecord, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := doRequests(NewClient(nil).OnResponse(nil).SetDoer(recorder))
+	if recorded[0].Path != "/users" || recorded[1].Echo["name"] != "Aaron" {
+		t.Errorf("unexpected results: %+v", recorded)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	if data, err := ioutil.ReadFile(cassette); err != nil {
+		t.Fatal(err)
+	} else if strings.Contains(string(data), "secret") {
+		t.Errorf("expect the header Authorization to be redacted, but got '%s'", data)
+	} else if !strings.Contains(string(data), `"`+RedactedValue+`"`) {
+		t.Errorf("expect the redacted header, but got '%s'", data)
+	}
+
+	// Replay without the network.
+	replayer, err := NewRecorderDoer(cassette, RecorderReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(nil).OnResponse(nil).SetDoer(replayer)
+	if replayed := doRequests(client); replayed[0].Path != recorded[0].Path ||
+		replayed[1].Echo["name"] != recorded[1].Echo["name"] {
+		t.Errorf("expect the replayed results %+v, but got %+v", recorded, replayed)
+	}
+
+	// The body does not match.
+	err = client.Post(server.URL+"/users").SetBody(map[string]interface{}{"name": "Bob"}).
+		Do(context.Background(), nil).Unwrap()
+	if err == nil {
+		t.Fatal("expect an error, but got nil")
+	} else if msg := err.Error(); !strings.Contains(msg, "no recorded interaction") ||
+		!strings.Contains(msg, "POST "+server.URL+"/users (body sha256") {
+		t.Errorf("unexpected error: %s", msg)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedactedQueryKeys is the list of the query keys, which are case-insensitive,
+// whose values will be redacted by DefaultURLRedactor.
+var RedactedQueryKeys = []string{"token", "access_token", "key", "api_key", "signature"}
+
+// DefaultURLRedactor is the default url redactor, which redacts
+// the password in the userinfo and the values of the query keys
+// in RedactedQueryKeys.
+func DefaultURLRedactor(u *url.URL) string { return redactURL(u.String()) }
+
+// NewURLRedactor returns a new url redactor, which redacts the password
+// in the userinfo and the values of the given query keys.
+func NewURLRedactor(keys ...string) func(u *url.URL) string {
+	keys = append([]string(nil), keys...)
+	return func(u *url.URL) string {
+		return redactQuery(redactUserinfo(u.String()), keys)
+	}
+}
+
+// SetURLRedactor sets the url redactor to render the request url for humans,
+// such as the logs, Error.URL and Response.FinalURL, which won't affect
+// the request to be sent.
+//
+// If redactor is nil, use DefaultURLRedactor instead.
+//
+// Default: nil
+func (c *Client) SetURLRedactor(redactor func(u *url.URL) string) *Client {
+	c.redactor = redactor
+	return c
+}
+
+// redactURL redacts the url by the default rules.
+func redactURL(rawurl string) string {
+	return redactQuery(redactUserinfo(rawurl), RedactedQueryKeys)
+}
+
+// redactQuery redacts the values of the query keys in rawurl,
+// which keeps the rest of the url unchanged.
+func redactQuery(rawurl string, keys []string) string {
+	start := strings.IndexByte(rawurl, '?')
+	if start < 0 || len(keys) == 0 {
+		return rawurl
+	}
+
+	start++
+	end := strings.IndexByte(rawurl[start:], '#')
+	if end < 0 {
+		end = len(rawurl)
+	} else {
+		end += start
+	}
+
+	var redacted bool
+	parts := strings.Split(rawurl[start:end], "&")
+	for i, part := range parts {
+		key := part
+		if index := strings.IndexByte(part, '='); index >= 0 {
+			key = part[:index]
+		}
+
+		name := key
+		if s, err := url.QueryUnescape(key); err == nil {
+			name = s
+		}
+
+		for _, k := range keys {
+			if strings.EqualFold(name, k) {
+				parts[i] = key + "=" + RedactedValue
+				redacted = true
+				break
+			}
+		}
+	}
+
+	if !redacted {
+		return rawurl
+	}
+	return rawurl[:start] + strings.Join(parts, "&") + rawurl[end:]
+}
+
+type urlRedactorKey struct{}
+
+// redactWith renders the url by the redactor, or DefaultURLRedactor if nil.
+func redactWith(redactor func(*url.URL) string, u *url.URL) string {
+	if redactor == nil {
+		return DefaultURLRedactor(u)
+	}
+	return redactor(u)
+}
+
+// redactRawURL is the same as redactWith, but parses the raw url first.
+func redactRawURL(redactor func(*url.URL) string, rawurl string) string {
+	if u, err := url.Parse(rawurl); err == nil {
+		return redactWith(redactor, u)
+	}
+	return redactURL(rawurl)
+}
+
+// redactRequestURL renders the url of the request by the url redactor
+// carried by the request context.
+func redactRequestURL(req *http.Request) string {
+	redactor, _ := req.Context().Value(urlRedactorKey{}).(func(*url.URL) string)
+	return redactWith(redactor, req.URL)
+}
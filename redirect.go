@@ -0,0 +1,142 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+type redirectPassThroughKey struct{}
+
+// EnableRedirectPassThrough sets whether ReadResponseBodyAsError regards
+// the 3xx response, which is not followed by the http client, as success
+// instead of an error.
+//
+// If the 3xx response handler is set, it is used instead, and this option
+// does not take effect. And 304 Not Modified is always regarded as success.
+//
+// Default: false
+func (c *Client) EnableRedirectPassThrough(enable bool) *Client {
+	c.passthrough3xx = enable
+	return c
+}
+
+// EnableRedirectPassThrough overrides the setting of the client.
+//
+// See Client.EnableRedirectPassThrough.
+func (r *Request) EnableRedirectPassThrough(enable bool) *Request {
+	r.passthrough3xx = enable
+	return r
+}
+
+func isRedirectPassThrough(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusNotModified {
+		return true
+	} else if resp.Request == nil {
+		return false
+	}
+
+	enabled, _ := resp.Request.Context().Value(redirectPassThroughKey{}).(bool)
+	return enabled
+}
+
+// getRedirectLocation returns the redacted url of the header "Location"
+// of the 3xx response, which is resolved relative to the request url.
+func getRedirectLocation(resp *http.Response) string {
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return ""
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" || resp.Request == nil {
+		return redactURL(location)
+	}
+
+	u, err := resp.Location()
+	if err != nil {
+		return redactURL(location)
+	}
+
+	redactor, _ := resp.Request.Context().Value(urlRedactorKey{}).(func(*url.URL) string)
+	return redactWith(redactor, u)
+}
+
+// RedirectHop is a hop of the redirect chain.
+type RedirectHop struct {
+	// URL is the url of the request redirected.
+	URL string `json:"url" xml:"url"`
+
+	// StatusCode is the status code of the redirect response, such as 302.
+	StatusCode int `json:"code" xml:"code"`
+}
+
+// getRedirects returns the redirect chain which leads to the response,
+// which is built from the field Response of the request of each hop.
+func getRedirects(resp *http.Response) (hops []RedirectHop) {
+	if resp == nil || resp.Request == nil {
+		return
+	}
+
+	for req := resp.Request; req.Response != nil && req.Response.Request != nil; {
+		prev := req.Response
+		hops = append(hops, RedirectHop{
+			URL:        redactRequestURL(prev.Request),
+			StatusCode: prev.StatusCode,
+		})
+		req = prev.Request
+	}
+
+	// Reverse the hops to start from the first request.
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+	return
+}
+
+// getStoppedRedirects returns the redirect chain when the client stops
+// following the redirects, which includes the last redirect response.
+func getStoppedRedirects(resp *http.Response) (hops []RedirectHop) {
+	if resp == nil || resp.Request == nil || resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return
+	}
+
+	return append(getRedirects(resp), RedirectHop{
+		URL:        redactRequestURL(resp.Request),
+		StatusCode: resp.StatusCode,
+	})
+}
+
+// Redirects returns the redirect chain followed by the http client,
+// which starts from the original request.
+//
+// If the redirects are stopped by the client, such as too many redirects,
+// the chain is partial and also included in the error as *RedirectError.
+//
+// Return nil if no redirects.
+func (r *Response) Redirects() []RedirectHop { return getRedirects(r.resp) }
+
+// RedirectError is the error occurring when following the redirects,
+// such as too many redirects, which contains the partial redirect chain.
+type RedirectError struct {
+	Redirects []RedirectHop
+	Err       error
+}
+
+func (e *RedirectError) Unwrap() error { return e.Err }
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("redirects=%d, err=%s", len(e.Redirects), e.Err.Error())
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResponseRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/a":
+			http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+		case "/b":
+			http.Redirect(w, r, "/c", http.StatusFound)
+		case "/c":
+			w.WriteHeader(204)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	resp := client.Get(server.URL+"/a").Do(context.Background(), nil)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	if u := resp.FinalURL(); u != server.URL+"/c" {
+		t.Errorf("expect final url '%s', but got '%s'", server.URL+"/c", u)
+	}
+
+	expects := []RedirectHop{{URL: server.URL + "/a", StatusCode: 301}, {URL: server.URL + "/b", StatusCode: 302}}
+	if hops := resp.Redirects(); len(hops) != len(expects) {
+		t.Errorf("expect %d hops, but got %d", len(expects), len(hops))
+	} else {
+		for i, hop := range hops {
+			if hop != expects[i] {
+				t.Errorf("%d: expect hop %+v, but got %+v", i, expects[i], hop)
+			}
+		}
+	}
+
+	if hops := client.Get(server.URL+"/c").Do(context.Background(), nil).Redirects(); len(hops) != 0 {
+		t.Errorf("expect no redirects, but got %+v", hops)
+	}
+
+	err := client.Get(server.URL+"/loop").Do(context.Background(), nil).Unwrap()
+	if err == nil {
+		t.Fatal("expect an error, but got nil")
+	}
+
+	if re, ok := err.(Error).Err.(*RedirectError); !ok {
+		t.Errorf("expect a RedirectError, but got %T", err.(Error).Err)
+	} else if len(re.Redirects) != 10 {
+		t.Errorf("expect %d hops, but got %d", 10, len(re.Redirects))
+	}
+}
+
+func TestReadResponseBodyAsError3xx(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("code"))
+		resp := &http.Response{StatusCode: code, Header: http.Header{}, Body: http.NoBody, Request: r}
+		if code != 304 {
+			resp.Header.Set("Location", "/new?token=secret")
+		}
+		return resp, nil
+	}))
+
+	for _, code := range []int{301, 302, 307} {
+		rawurl := fmt.Sprintf("http://127.0.0.1/old?code=%d", code)
+
+		// Without the 3xx handler
+		err := client.Get(rawurl).Do(context.Background(), nil).Unwrap()
+		if e, ok := err.(Error); !ok {
+			t.Errorf("%d: expect an Error, but got %v", code, err)
+		} else if e.Code != code {
+			t.Errorf("%d: expect code %d, but got %d", code, code, e.Code)
+		} else if s := e.Err.Error(); !strings.Contains(s, "http://127.0.0.1/new?token=***") {
+			t.Errorf("%d: expect the redacted location in the error, but got '%s'", code, s)
+		}
+
+		// With the pass-through
+		err = client.Get(rawurl).EnableRedirectPassThrough(true).Do(context.Background(), nil).Unwrap()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", code, err)
+		}
+
+		// With the 3xx handler
+		var location string
+		err = client.Get(rawurl).SetResponseHandler3xx(func(dst interface{}, resp *http.Response) error {
+			location = resp.Header.Get("Location")
+			return nil
+		}).Do(context.Background(), nil).Unwrap()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", code, err)
+		} else if location != "/new?token=secret" {
+			t.Errorf("%d: expect location '%s', but got '%s'", code, "/new?token=secret", location)
+		}
+	}
+
+	if err := client.Get("http://127.0.0.1/old?code=304").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Errorf("304: unexpected error: %v", err)
+	}
+}
+
+type seekReader struct{ io.ReadSeeker }
+
+func TestRedirectReplayBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/307":
+			_, _ = ioutil.ReadAll(r.Body)
+			http.Redirect(w, r, "/308", http.StatusTemporaryRedirect)
+		case "/308":
+			_, _ = ioutil.ReadAll(r.Body)
+			http.Redirect(w, r, "/final", http.StatusPermanentRedirect)
+		case "/final":
+			data, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+			w.Header().Set("X-Length", strconv.FormatInt(r.ContentLength, 10))
+			_, _ = w.Write(data)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+
+	var result map[string]interface{}
+	resp := client.Post(server.URL+"/307").SetBody(map[string]interface{}{"a": 1}).
+		Do(context.Background(), &result)
+	if err := resp.Result(); err != nil {
+		t.Fatal(err)
+	} else if result["a"] != float64(1) {
+		t.Errorf("expect the body '{\"a\":1}', but got %v", result)
+	} else if hops := resp.Redirects(); len(hops) != 2 {
+		t.Errorf("expect 2 redirects, but got %d", len(hops))
+	}
+
+	// The body is recycled, but the sent request can still replay it.
+	if body, err := resp.Request().GetBody(); err != nil {
+		t.Error(err)
+	} else if data, _ := ioutil.ReadAll(body); string(data) != `{"a":1}`+"\n" {
+		t.Errorf("expect the replayed body '{\"a\":1}', but got '%s'", data)
+	}
+
+	// The custom seekable body, which is started from the current position.
+	seeker := seekReader{strings.NewReader(`xx"abcdef"`)}
+	_, _ = seeker.Seek(2, io.SeekStart)
+
+	var body string
+	resp = client.Post(server.URL+"/307").SetBody(seeker).
+		SetContentType(MIMEApplicationJSON).Do(context.Background(), &body)
+	if err := resp.Result(); err != nil {
+		t.Fatal(err)
+	} else if body != "abcdef" {
+		t.Errorf("expect the body '%s', but got '%s'", "abcdef", body)
+	} else if length := resp.Response().Header.Get("X-Length"); length != "8" {
+		t.Errorf("expect the content length %s, but got %s", "8", length)
+	}
+}
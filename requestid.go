@@ -0,0 +1,47 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import "net/http"
+
+// ServerRequestIDHeaders is the ordered list of the response headers
+// carrying the request id assigned or echoed by the server or gateway,
+// the first non-empty one of which is used by Response.ServerRequestID.
+var ServerRequestIDHeaders = []string{
+	"X-Request-Id",
+	"X-Amzn-RequestId",
+	"X-Correlation-Id",
+}
+
+func getServerRequestID(header http.Header) string {
+	for _, key := range ServerRequestIDHeaders {
+		if id := header.Get(key); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// ServerRequestID returns the request id assigned or echoed by the server
+// from the response headers, which are looked up by ServerRequestIDHeaders
+// in turn.
+//
+// Return "" if there is no response or no request id header.
+func (r *Response) ServerRequestID() string {
+	if r.resp == nil {
+		return ""
+	}
+	return getServerRequestID(r.resp.Header)
+}
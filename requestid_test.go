@@ -0,0 +1,78 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseServerRequestID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/amzn":
+			w.Header().Set("X-Amzn-RequestId", "amzn-id")
+			w.Header().Set("X-Correlation-Id", "corr-id")
+		case "/error":
+			w.Header().Set("X-Request-Id", "error-id")
+			w.WriteHeader(500)
+			return
+		}
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+
+	resp := client.Get(server.URL+"/none").Do(context.Background(), nil)
+	if id := resp.ServerRequestID(); id != "" {
+		t.Errorf("expect no request id, but got '%s'", id)
+	}
+	resp.Close()
+
+	resp = client.Get(server.URL+"/amzn").Do(context.Background(), nil)
+	if id := resp.ServerRequestID(); id != "amzn-id" {
+		t.Errorf("expect request id '%s', but got '%s'", "amzn-id", id)
+	}
+	resp.Close()
+
+	err := client.Get(server.URL+"/error").Do(context.Background(), nil).Unwrap()
+	if err == nil {
+		t.Fatal("expect an error, but got nil")
+	}
+
+	e := err.(Error)
+	if e.RequestID != "error-id" {
+		t.Errorf("expect request id '%s', but got '%s'", "error-id", e.RequestID)
+	}
+	if s := e.Error(); !strings.Contains(s, "requestid=error-id") {
+		t.Errorf("expect the error containing the request id, but got '%s'", s)
+	}
+	if data, _ := json.Marshal(e); !strings.Contains(string(data), `"requestid":"error-id"`) {
+		t.Errorf("expect the json error containing the request id, but got '%s'", data)
+	}
+
+	resp = client.Get("http://127.0.0.1:0").Do(context.Background(), nil)
+	if id := resp.ServerRequestID(); id != "" {
+		t.Errorf("expect no request id, but got '%s'", id)
+	}
+	if e := resp.ToError(nil); e.RequestID != "" {
+		t.Errorf("expect no request id in the error, but got '%s'", e.RequestID)
+	}
+}
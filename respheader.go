@@ -0,0 +1,268 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pre-define some response headers about the caching and the content.
+const (
+	HeaderDate               = "Date"
+	HeaderAge                = "Age"
+	HeaderExpires            = "Expires"
+	HeaderCacheControl       = "Cache-Control"
+	HeaderContentDisposition = "Content-Disposition"
+	HeaderVary               = "Vary"
+)
+
+// CacheControl is the parsed directives of the header "Cache-Control".
+type CacheControl struct {
+	NoCache         bool
+	NoStore         bool
+	NoTransform     bool
+	MustRevalidate  bool
+	ProxyRevalidate bool
+	MustUnderstand  bool
+	Immutable       bool
+	Public          bool
+	Private         bool
+
+	// The durations are negative if the directives are missing or invalid.
+	MaxAge               time.Duration
+	SMaxAge              time.Duration
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+
+	// Directives contains all the directives, including the extensions,
+	// whose names are lower-case and the quoted values are unquoted.
+	Directives map[string]string
+}
+
+// ParseCacheControl parses the values of the header "Cache-Control",
+// which reports false if no valid directive is found.
+func ParseCacheControl(values ...string) (cc CacheControl, ok bool) {
+	cc.MaxAge = -1
+	cc.SMaxAge = -1
+	cc.StaleWhileRevalidate = -1
+	cc.StaleIfError = -1
+
+	for _, value := range values {
+		for _, directive := range splitPreferences(value) {
+			name, value := parsePreference(directive)
+			if name == "" {
+				continue
+			}
+
+			if cc.Directives == nil {
+				cc.Directives = make(map[string]string, 4)
+			}
+			if _, exist := cc.Directives[name]; exist {
+				continue
+			}
+			cc.Directives[name] = value
+
+			switch name {
+			case "no-cache":
+				cc.NoCache = true
+			case "no-store":
+				cc.NoStore = true
+			case "no-transform":
+				cc.NoTransform = true
+			case "must-revalidate":
+				cc.MustRevalidate = true
+			case "proxy-revalidate":
+				cc.ProxyRevalidate = true
+			case "must-understand":
+				cc.MustUnderstand = true
+			case "immutable":
+				cc.Immutable = true
+			case "public":
+				cc.Public = true
+			case "private":
+				cc.Private = true
+			case "max-age":
+				cc.MaxAge = parseDeltaSeconds(value)
+			case "s-maxage":
+				cc.SMaxAge = parseDeltaSeconds(value)
+			case "stale-while-revalidate":
+				cc.StaleWhileRevalidate = parseDeltaSeconds(value)
+			case "stale-if-error":
+				cc.StaleIfError = parseDeltaSeconds(value)
+			}
+		}
+	}
+
+	ok = len(cc.Directives) > 0
+	return
+}
+
+// parseDeltaSeconds parses the delta seconds of RFC 9111,
+// which returns -1 if it is invalid.
+func parseDeltaSeconds(s string) time.Duration {
+	if s == "" || s[0] == '+' {
+		return -1
+	}
+
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		if e, ok := err.(*strconv.NumError); !ok || e.Err != strconv.ErrRange {
+			return -1
+		}
+		n = 1<<31 - 1 // Use the greatest integer as RFC 9111.
+	}
+	return time.Duration(n) * time.Second
+}
+
+// ParseContentDisposition parses the value of the header "Content-Disposition",
+// which returns the lower-case disposition type and the parameters,
+// whose names are lower-case.
+//
+// The extended parameters of RFC 5987, such as "filename*", are decoded
+// and take precedence over the regular ones, such as "filename",
+// and the charsets "UTF-8" and "ISO-8859-1" are supported.
+func ParseContentDisposition(value string) (typ string, params map[string]string, ok bool) {
+	typ, params, err := mime.ParseMediaType(value)
+	if err != nil || typ == "" {
+		return "", nil, false
+	}
+
+	// mime.ParseMediaType does not support the charset ISO-8859-1.
+	for s := value; s != ""; {
+		index := indexUnquoted(s, ';')
+		if index < 0 {
+			index = len(s)
+		}
+
+		param := strings.TrimSpace(s[:index])
+		if index < len(s) {
+			index++
+		}
+		s = s[index:]
+
+		if index := strings.IndexByte(param, '='); index > 1 && param[index-1] == '*' {
+			name := strings.ToLower(strings.TrimSpace(param[:index-1]))
+			if strings.IndexByte(name, '*') >= 0 { // Ignore the continuations.
+				continue
+			}
+
+			if v, ok := decodeLatin1ExtValue(strings.TrimSpace(param[index+1:])); ok {
+				params[name] = v
+			}
+		}
+	}
+
+	return typ, params, true
+}
+
+// decodeLatin1ExtValue decodes the ext-value of RFC 5987
+// with the charset ISO-8859-1, such as "iso-8859-1'en'%A3%20rates".
+func decodeLatin1ExtValue(s string) (string, bool) {
+	parts := strings.SplitN(s, "'", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[0], "iso-8859-1") {
+		return "", false
+	}
+
+	v, err := url.PathUnescape(parts[2])
+	if err != nil {
+		return "", false
+	}
+
+	runes := make([]rune, len(v))
+	for i := 0; i < len(v); i++ {
+		runes[i] = rune(v[i])
+	}
+	return string(runes), true
+}
+
+func (r *Response) headerTime(key string) (t time.Time, ok bool) {
+	if r.resp == nil {
+		return
+	}
+
+	if v := r.resp.Header.Get(key); v != "" {
+		var err error
+		t, err = http.ParseTime(v)
+		ok = err == nil
+	}
+	return
+}
+
+// Date parses the response header "Date"
+// and reports whether it exists and is valid.
+func (r *Response) Date() (time.Time, bool) { return r.headerTime(HeaderDate) }
+
+// Expires parses the response header "Expires"
+// and reports whether it exists and is valid.
+//
+// Notice: the invalid value, such as "0", means that it has already expired
+// by RFC 9111, but it reports false and the caller should decide it.
+func (r *Response) Expires() (time.Time, bool) { return r.headerTime(HeaderExpires) }
+
+// Age parses the response header "Age"
+// and reports whether it exists and is valid.
+func (r *Response) Age() (age time.Duration, ok bool) {
+	if r.resp == nil {
+		return
+	}
+
+	if v := strings.TrimSpace(r.resp.Header.Get(HeaderAge)); v != "" {
+		if age = parseDeltaSeconds(v); age < 0 {
+			age = 0
+		} else {
+			ok = true
+		}
+	}
+	return
+}
+
+// CacheControl parses the response header "Cache-Control"
+// and reports whether it exists and contains the valid directives.
+func (r *Response) CacheControl() (cc CacheControl, ok bool) {
+	if r.resp == nil {
+		return
+	}
+
+	if values := r.resp.Header[HeaderCacheControl]; len(values) > 0 {
+		cc, ok = ParseCacheControl(values...)
+	}
+	return
+}
+
+// ContentDisposition parses the response header "Content-Disposition"
+// and reports whether it exists and is valid. See ParseContentDisposition.
+func (r *Response) ContentDisposition() (typ string, params map[string]string, ok bool) {
+	if r.resp == nil {
+		return
+	}
+
+	if v := r.resp.Header.Get(HeaderContentDisposition); v != "" {
+		typ, params, ok = ParseContentDisposition(v)
+	}
+	return
+}
+
+// Vary parses the response header "Vary" and returns the list of the header
+// names, which are canonicalized and deduplicated, or "*".
+//
+// Return nil if the header is missing.
+func (r *Response) Vary() []string {
+	return r.headerList(HeaderVary, http.CanonicalHeaderKey)
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResponseHeaderAccessors(t *testing.T) {
+	var resp Response
+	if _, ok := resp.Date(); ok {
+		t.Errorf("expect no Date, but got one")
+	}
+	if _, ok := resp.CacheControl(); ok {
+		t.Errorf("expect no Cache-Control, but got one")
+	}
+	if _, _, ok := resp.ContentDisposition(); ok {
+		t.Errorf("expect no Content-Disposition, but got one")
+	}
+	if vary := resp.Vary(); vary != nil {
+		t.Errorf("expect no Vary, but got %v", vary)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	resp.resp = &http.Response{Header: http.Header{
+		"Date":          {now.Format(http.TimeFormat)},
+		"Expires":       {"0"},
+		"Age":           {"120"},
+		"Cache-Control": {`max-age=60, private="Set-Cookie, X-Token"`, "no-cache, s-maxage=abc, ext=1"},
+		"Vary":          {"accept-encoding, Accept", "accept-encoding"},
+	}}
+
+	if date, ok := resp.Date(); !ok || !date.Equal(now) {
+		t.Errorf("expect Date '%s', but got '%s'", now, date)
+	}
+	if _, ok := resp.Expires(); ok {
+		t.Errorf("expect an invalid Expires, but got a valid one")
+	}
+	if age, ok := resp.Age(); !ok || age != 2*time.Minute {
+		t.Errorf("expect Age '%s', but got '%s'", 2*time.Minute, age)
+	}
+
+	cc, ok := resp.CacheControl()
+	if !ok {
+		t.Errorf("expect Cache-Control, but got none")
+	} else if cc.MaxAge != time.Minute || cc.SMaxAge != -1 || cc.StaleIfError != -1 {
+		t.Errorf("unexpected durations: %+v", cc)
+	} else if !cc.Private || !cc.NoCache || cc.NoStore || cc.Public {
+		t.Errorf("unexpected flags: %+v", cc)
+	} else if v := cc.Directives["private"]; v != "Set-Cookie, X-Token" {
+		t.Errorf("expect private fields '%s', but got '%s'", "Set-Cookie, X-Token", v)
+	} else if v := cc.Directives["ext"]; v != "1" {
+		t.Errorf("expect extension '%s', but got '%s'", "1", v)
+	}
+
+	if vary := resp.Vary(); len(vary) != 2 || vary[0] != "Accept-Encoding" || vary[1] != "Accept" {
+		t.Errorf("unexpected Vary: %v", vary)
+	}
+
+	resp.resp.Header.Set("Age", "-1")
+	if _, ok := resp.Age(); ok {
+		t.Errorf("expect an invalid Age, but got a valid one")
+	}
+}
+
+func TestParseContentDisposition(t *testing.T) {
+	tests := []struct {
+		Value    string
+		Type     string
+		Filename string
+		OK       bool
+	}{
+		{Value: `attachment; filename="a.txt"`, Type: "attachment", Filename: "a.txt", OK: true},
+		{Value: `Attachment; filename="a.txt"; filename*=UTF-8''%E2%82%AC%20rates.txt`,
+			Type: "attachment", Filename: "€ rates.txt", OK: true},
+		{Value: `attachment; filename*=iso-8859-1'en'%A3%20rates.txt; filename="rates.txt"`,
+			Type: "attachment", Filename: "£ rates.txt", OK: true},
+		{Value: `inline`, Type: "inline", OK: true},
+		{Value: `; filename=a.txt`},
+		{Value: `attachment; filename="a.txt`},
+	}
+
+	for _, test := range tests {
+		typ, params, ok := ParseContentDisposition(test.Value)
+		if ok != test.OK {
+			t.Errorf("%s: expect ok %v, but got %v", test.Value, test.OK, ok)
+		} else if typ != test.Type {
+			t.Errorf("%s: expect type '%s', but got '%s'", test.Value, test.Type, typ)
+		} else if filename := params["filename"]; filename != test.Filename {
+			t.Errorf("%s: expect filename '%s', but got '%s'", test.Value, test.Filename, filename)
+		}
+	}
+}
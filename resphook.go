@@ -0,0 +1,137 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrNilHookResponse is returned when a response hook returns
+// neither a response nor an error.
+var ErrNilHookResponse = errors.New("the response hook returns a nil response without error")
+
+// ResponseHook is a hook to inspect and modify the http response,
+// which is symmetric to the request Hook.
+//
+// It is called after sending the request and before handling the response,
+// with the sent request and the result of the http client, in which the error
+// may be not nil, such as the network error.
+//
+// The hook may return a new response, or an error to short-circuit
+// the response handlers, which becomes the error of the response.
+// If replacing the response, the hook should close the old response body.
+type ResponseHook interface {
+	Response(*http.Request, *http.Response, error) (*http.Response, error)
+}
+
+// ResponseHooks is a set of response hooks, which are called in turn.
+type ResponseHooks []ResponseHook
+
+// Response implements the interface ResponseHook.
+func (hs ResponseHooks) Response(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+	for _, hook := range hs {
+		resp, err = hook.Response(req, resp, err)
+	}
+	return resp, err
+}
+
+func cloneResponseHook(hook ResponseHook) ResponseHook {
+	if hooks, ok := hook.(ResponseHooks); ok && len(hooks) > 0 {
+		hook = append(ResponseHooks{}, hooks...)
+	}
+	return hook
+}
+
+// ResponseHookFunc is a response hook function.
+type ResponseHookFunc func(*http.Request, *http.Response, error) (*http.Response, error)
+
+// Response implements the interface ResponseHook.
+func (f ResponseHookFunc) Response(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+	return f(req, resp, err)
+}
+
+// SetResponseHook resets the response hook.
+func (c *Client) SetResponseHook(hook ResponseHook) *Client {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.resphook = hook
+	return c
+}
+
+// AddResponseHook appends the response hook.
+func (c *Client) AddResponseHook(hook ResponseHook) *Client {
+	if hook == nil {
+		panic("Client.AddResponseHook: the hook must not be nil")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	switch hooks := c.resphook.(type) {
+	case nil:
+		c.resphook = hook
+	case ResponseHooks:
+		c.resphook = append(hooks, hook)
+	default:
+		c.resphook = ResponseHooks{c.resphook, hook}
+	}
+
+	return c
+}
+
+// SetResponseHook resets the response hook.
+func (r *Request) SetResponseHook(hook ResponseHook) *Request {
+	r.resphookset = true
+	r.resphook = hook
+	return r
+}
+
+// AddResponseHook appends the response hook.
+func (r *Request) AddResponseHook(hook ResponseHook) *Request {
+	if hook == nil {
+		panic("Request.AddResponseHook: the hook must not be nil")
+	}
+
+	switch hooks := r.resphook.(type) {
+	case nil:
+		r.resphook = hook
+	case ResponseHooks:
+		if r.resphookset {
+			r.resphook = append(hooks, hook)
+		} else {
+			// The hooks are shared with the client, so copy them on write.
+			r.resphook = append(append(make(ResponseHooks, 0, len(hooks)+1), hooks...), hook)
+		}
+	default:
+		r.resphook = ResponseHooks{r.resphook, hook}
+	}
+
+	r.resphookset = true
+	return r
+}
+
+// runResponseHook calls the response hook with the sent request
+// and the result of the http client.
+func (r *Request) runResponseHook(resp *Response) {
+	failed := resp.err != nil
+	resp.resp, resp.err = r.resphook.Response(resp.req, resp.resp, resp.err)
+	switch {
+	case resp.err != nil:
+	case resp.resp == nil:
+		resp.err = ErrNilHookResponse
+	case failed: // The hook recovers from the failure.
+		resp.phase = ""
+	}
+}
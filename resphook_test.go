@@ -0,0 +1,131 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestResponseHook(t *testing.T) {
+	var calls []string
+	newHook := func(name string) ResponseHook {
+		return ResponseHookFunc(func(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+			calls = append(calls, name)
+			return resp, err
+		})
+	}
+
+	client := NewClient(nil).OnResponse(nil).AddResponseHook(newHook("c1")).AddResponseHook(newHook("c2"))
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/fail" {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": []string{"text/json"}},
+			Body:       readCloser{strings.NewReader(`"ok"`), http.NoBody},
+			Request:    r,
+		}, nil
+	}))
+
+	// Rewrite the legacy content type.
+	var result string
+	err := client.Get("http://localhost").AddResponseHook(ResponseHookFunc(
+		func(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+			calls = append(calls, "r1")
+			if resp.Header.Get("Content-Type") == "text/json" {
+				resp.Header.Set("Content-Type", MIMEApplicationJSON)
+			}
+			return resp, err
+		})).Do(context.Background(), &result).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	} else if result != "ok" {
+		t.Errorf("expect result '%s', but got '%s'", "ok", result)
+	}
+
+	if expect := "c1,c2,r1"; strings.Join(calls, ",") != expect {
+		t.Errorf("expect calls '%s', but got '%s'", expect, strings.Join(calls, ","))
+	}
+
+	// The hooks added by the request must not affect the client.
+	if hooks := client.resphook.(ResponseHooks); len(hooks) != 2 {
+		t.Errorf("expect %d client response hooks, but got %d", 2, len(hooks))
+	}
+
+	// The error short-circuits the handlers.
+	calls = calls[:0]
+	errRejected := errors.New("rejected")
+	resp := client.Get("http://localhost").AddResponseHook(ResponseHookFunc(
+		func(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+			return resp, errRejected
+		})).Do(context.Background(), func(*http.Response) error {
+		t.Error("unexpected the handler is called")
+		return nil
+	})
+	if err := resp.Unwrap(); err == nil {
+		t.Error("expect an error, but got nil")
+	} else if e, ok := err.(Error); !ok || e.Err != errRejected {
+		t.Errorf("expect the error '%v', but got '%v'", errRejected, err)
+	} else if code := resp.StatusCode(); code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, code)
+	}
+
+	// Translate the network error into the response.
+	err = client.Get("http://localhost/fail").AddResponseHook(ResponseHookFunc(
+		func(req *http.Request, resp *http.Response, err error) (*http.Response, error) {
+			if err == nil {
+				t.Error("expect an error, but got nil")
+			}
+			return &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": []string{MIMEApplicationJSON}},
+				Body:       readCloser{strings.NewReader(`"fallback"`), http.NoBody},
+				Request:    req,
+			}, nil
+		})).Do(context.Background(), &result).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if result != "fallback" {
+		t.Errorf("expect result '%s', but got '%s'", "fallback", result)
+	}
+
+	// The hook returns neither a response nor an error.
+	err = client.Get("http://localhost").SetResponseHook(ResponseHookFunc(
+		func(*http.Request, *http.Response, error) (*http.Response, error) { return nil, nil })).
+		Do(context.Background(), nil).Unwrap()
+	if e, ok := err.(Error); !ok || e.Err != ErrNilHookResponse {
+		t.Errorf("expect the error '%v', but got '%v'", ErrNilHookResponse, err)
+	}
+}
+
+func TestResponseHookClone(t *testing.T) {
+	hook := ResponseHookFunc(func(_ *http.Request, resp *http.Response, err error) (*http.Response, error) {
+		return resp, err
+	})
+
+	client := NewClient(nil).AddResponseHook(hook).AddResponseHook(hook)
+	clone := client.Clone().AddResponseHook(hook)
+	if hooks := client.resphook.(ResponseHooks); len(hooks) != 2 {
+		t.Errorf("expect %d hooks, but got %d", 2, len(hooks))
+	}
+	if hooks := clone.resphook.(ResponseHooks); len(hooks) != 3 {
+		t.Errorf("expect %d hooks, but got %d", 3, len(hooks))
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Result is the typed result of the response, which contains
+// the success payload T for 2xx, or the error payload E for non-2xx.
+type Result[T, E any] struct {
+	code int
+	ok   T
+	err  E
+
+	hasOk  bool
+	hasErr bool
+}
+
+// Ok returns the success payload decoded from the 2xx response body,
+// and reports whether it exists.
+func (r Result[T, E]) Ok() (T, bool) { return r.ok, r.hasOk }
+
+// Err returns the error payload decoded from the non-2xx response body,
+// and reports whether it exists.
+func (r Result[T, E]) Err() (E, bool) { return r.err, r.hasErr }
+
+// StatusCode returns the status code of the response.
+//
+// Return 0 if failing to send the request.
+func (r Result[T, E]) StatusCode() int { return r.code }
+
+// DoResult sends the request, decodes the 2xx response body into T,
+// or the non-2xx response body into E.
+//
+// For the non-2xx response, the returned error is always the http error
+// containing the status code and the response body, even if failing
+// to decode the response body into E.
+func DoResult[T, E any](ctx context.Context, r *Request) (result Result[T, E], err error) {
+	err = r.Do(ctx, func(resp *http.Response) error {
+		result.code = resp.StatusCode
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			if err := DecodeResponseBody(&result.ok, resp); err != nil {
+				return err
+			}
+			result.hasOk = true
+			return nil
+		}
+
+		err := readResponseError(resp)
+		ct := GetContentType(resp.Header)
+		if derr := DecodeFromReader(&result.err, ct, strings.NewReader(err.Data)); derr == nil {
+			result.hasErr = true
+		}
+		return err
+	}).Unwrap()
+	return
+}
+
+// readResponseError is the same as ReadResponseBodyAsError,
+// but also for the 1xx and 3xx responses.
+func readResponseError(resp *http.Response) Error {
+	err := Error{Code: resp.StatusCode, Kind: KindStatus, RequestID: getServerRequestID(resp.Header)}
+	err.Err = fmt.Errorf("got status code %d", resp.StatusCode)
+
+	if req := resp.Request; req != nil {
+		err.Method = req.Method
+		err.URL = redactRequestURL(req)
+	}
+
+	if data, rerr := io.ReadAll(resp.Body); rerr == nil {
+		err.Data = string(data)
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+// +build go1.21
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDoResult(t *testing.T) {
+	type User struct {
+		Name string `json:"name"`
+	}
+	type APIError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = w.Write([]byte(`{"name":"alice"}`))
+		case "/error":
+			w.WriteHeader(404)
+			_, _ = w.Write([]byte(`{"code":"NotFound","message":"no user"}`))
+		default:
+			w.WriteHeader(500)
+			_, _ = w.Write([]byte(`internal error`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+
+	result, err := DoResult[User, APIError](context.Background(), client.Get(server.URL+"/ok"))
+	if err != nil {
+		t.Fatal(err)
+	} else if user, ok := result.Ok(); !ok || user.Name != "alice" {
+		t.Errorf("unexpected user: %v, %v", user, ok)
+	} else if _, ok := result.Err(); ok {
+		t.Errorf("unexpected the error payload")
+	} else if code := result.StatusCode(); code != 200 {
+		t.Errorf("expect status code %d, but got %d", 200, code)
+	}
+
+	result, err = DoResult[User, APIError](context.Background(), client.Get(server.URL+"/error?token=secret"))
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if e, ok := err.(Error); !ok || e.Code != 404 {
+		t.Errorf("expect a 404 error, but got %v", err)
+	} else if strings.Contains(e.URL, "secret") {
+		t.Errorf("expect the redacted url, but got '%s'", e.URL)
+	}
+	if apierr, ok := result.Err(); !ok || apierr.Code != "NotFound" {
+		t.Errorf("unexpected error payload: %v, %v", apierr, ok)
+	} else if _, ok := result.Ok(); ok {
+		t.Errorf("unexpected the success payload")
+	}
+
+	// The decoding failure of the error payload does not mask the http error.
+	result, err = DoResult[User, APIError](context.Background(), client.Get(server.URL+"/invalid"))
+	if e, ok := err.(Error); !ok || e.Code != 500 || e.Data != "internal error" {
+		t.Errorf("expect a 500 error, but got %v", err)
+	} else if _, ok := result.Err(); ok {
+		t.Errorf("unexpected the error payload")
+	} else if code := result.StatusCode(); code != 500 {
+		t.Errorf("expect status code %d, but got %d", 500, code)
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HeaderRetryAfter is the response header "Retry-After".
+const HeaderRetryAfter = "Retry-After"
+
+// SetRetry sets the maximum number of the retries and the backoff
+// to re-send the failed request, which is inherited by the requests.
+//
+// The request is retried when the retry policy, DefaultRetryPolicy
+// for the default, decides to retry it, or the response handler
+// returns ErrRetryRequested. But the request with the streaming body,
+// such as io.Reader, cannot be retried since it cannot be replayed.
+//
+// backoff returns the duration to wait before the next attempt,
+// where attempt is the sequence number of the failed attempt
+// starting with 1. If nil, retry immediately. But the wait hint of
+// ErrRetryRequested and the response header "Retry-After" take precedence.
+// If the wait exceeds the deadline of the request context, stop retrying.
+//
+// The response handler is called for the response of each attempt,
+// and the returned response is that of the final attempt.
+//
+// Default: 0, that's, no retry
+func (c *Client) SetRetry(max int, backoff func(attempt int) time.Duration) *Client {
+	c.retrymax, c.backoff = max, backoff
+	return c
+}
+
+// SetRetry overrides the retry setting of the client.
+//
+// See Client.SetRetry.
+func (r *Request) SetRetry(max int, backoff func(attempt int) time.Duration) *Request {
+	r.retrymax, r.backoff = max, backoff
+	return r
+}
+
+// RetryPolicy is used to decide whether to retry the failed attempt,
+// where attempt is the sequence number of the attempt starting with 1.
+//
+// If the request fails without the response, such as a network error,
+// resp is nil and err is not nil. Or resp is the response, whose body
+// may be read or closed by the policy, which is buffered and restored
+// for the response handler.
+type RetryPolicy func(attempt int, req *http.Request, resp *http.Response, err error) bool
+
+// DefaultRetryPolicy is the default retry policy, which only retries
+// the idempotent requests, that's, the methods GET, HEAD, OPTIONS, TRACE,
+// PUT and DELETE, failing with a network error or the response status code
+// 429 or 5xx.
+func DefaultRetryPolicy(attempt int, req *http.Request, resp *http.Response, err error) bool {
+	switch {
+	case req == nil || !isIdempotentMethod(req.Method):
+		return false
+	case resp == nil:
+		return err != nil
+	default:
+		return resp.StatusCode == 429 || resp.StatusCode >= 500
+	}
+}
+
+// SetRetryPolicy sets the retry policy, which is inherited by the requests.
+//
+// If policy is nil, use DefaultRetryPolicy.
+//
+// Default: nil
+func (c *Client) SetRetryPolicy(policy RetryPolicy) *Client {
+	c.retrypolicy = policy
+	return c
+}
+
+// SetRetryPolicy overrides the retry policy of the client.
+//
+// See Client.SetRetryPolicy.
+func (r *Request) SetRetryPolicy(policy RetryPolicy) *Request {
+	r.retrypolicy = policy
+	return r
+}
+
+func (r *Request) canRetry(c context.Context, attempt int) bool {
+	if attempt > r.retrymax || c.Err() != nil {
+		return false
+	}
+
+	_, replayable := r.replayBody()
+	return replayable
+}
+
+// checkRetryPolicy reports whether the retry policy decides to retry
+// the sent request, which is called before the response handler.
+func (r *Request) checkRetryPolicy(c context.Context, resp *Response, attempt int) (retry bool) {
+	if resp.req == nil || !r.canRetry(c, attempt) {
+		return false
+	}
+
+	policy := r.retrypolicy
+	if policy == nil {
+		policy = DefaultRetryPolicy
+	}
+
+	if resp.resp == nil || resp.resp.Body == nil {
+		return policy(attempt, resp.req, resp.resp, resp.err)
+	}
+
+	body := &peekBody{ReadCloser: resp.resp.Body}
+	resp.resp.Body = body
+	retry = policy(attempt, resp.req, resp.resp, resp.err)
+	resp.resp.Body = body.restore()
+	return
+}
+
+// peekBody records the data read from the body to be restored.
+type peekBody struct {
+	io.ReadCloser
+	buf bytes.Buffer
+}
+
+func (b *peekBody) Close() error { return nil }
+func (b *peekBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return
+}
+
+func (b *peekBody) restore() io.ReadCloser {
+	if b.buf.Len() == 0 {
+		return b.ReadCloser
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(bytes.NewReader(b.buf.Bytes()), b.ReadCloser),
+		Closer: b.ReadCloser,
+	}
+}
+
+// retryWait reports whether to retry the request after the attempt,
+// and returns the duration to wait before the next attempt.
+func (r *Request) retryWait(c context.Context, resp *Response, attempt int, retry bool) (wait time.Duration, ok bool) {
+	if !r.canRetry(c, attempt) {
+		return
+	}
+
+	wait, ok = isRetryRequested(resp.err)
+	if !ok {
+		if ok = retry; !ok {
+			return
+		}
+
+		if after, has := resp.RetryAfter(); has {
+			if wait = after; r.maxretryafter > 0 && wait > r.maxretryafter {
+				wait = r.maxretryafter
+			}
+		} else if r.backoff != nil {
+			wait = r.backoff(attempt)
+		}
+	}
+
+	// It is pointless to wait beyond the deadline.
+	if deadline, has := c.Deadline(); has && time.Now().Add(wait).After(deadline) {
+		return 0, false
+	}
+
+	return
+}
+
+// SetMaxRetryAfter sets the maximum duration to wait for the response header
+// "Retry-After" before the next attempt of the retry.
+//
+// Default: 0, that's, no limit
+func (c *Client) SetMaxRetryAfter(max time.Duration) *Client {
+	c.maxretryafter = max
+	return c
+}
+
+// RetryAfter parses the response header "Retry-After", which is either
+// the delta seconds or the HTTP date, and returns the duration to wait,
+// which is 0 if the date has passed.
+//
+// Return false if the header is missing or invalid.
+func (r *Response) RetryAfter() (wait time.Duration, ok bool) {
+	if r.resp == nil {
+		return
+	}
+
+	value := strings.TrimSpace(r.resp.Header.Get(HeaderRetryAfter))
+	if value == "" {
+		return
+	}
+
+	if wait = parseDeltaSeconds(value); wait >= 0 {
+		return wait, true
+	}
+
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if wait = time.Until(t); wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// sleepContext waits for the duration and reports whether c is not done.
+func sleepContext(c context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return c.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-c.Done():
+		return false
+	}
+}
+
+// resetAttempt resets the response to be reused by the next attempt.
+func (r *Response) resetAttempt() {
+	r.req, r.resp, r.err = nil, nil, nil
+	r.cost, r.phase, r.kind, r.closed = 0, "", "", false
+	r.hints, r.reused, r.raddr, r.connwait = nil, false, "", 0
+	r.upload, r.bodydata = UploadInfo{}, nil
+}
+
+// ErrRetryRequested is returned by the response handler to request
+// to retry the request, such as the decoded body indicates that
+// the failure is transient.
+//
+// The retry layer retries the request subject to the retry limits,
+// but it is a normal error if the retry is not enabled.
+//
+// Notice: the handler must fully consume and close the response body
+// before returning it.
+var ErrRetryRequested = errors.New("the retry of the request is requested")
+
+// RetryRequestedError is the error of ErrRetryRequested with a wait hint,
+// which overrides the backoff duration before the next attempt.
+type RetryRequestedError struct {
+	Wait time.Duration
+}
+
+func (e RetryRequestedError) Unwrap() error { return ErrRetryRequested }
+func (e RetryRequestedError) Error() string {
+	return fmt.Sprintf("%s after %s", ErrRetryRequested.Error(), e.Wait)
+}
+
+// RequestRetry returns the error to request to retry the request
+// after waiting for the duration.
+//
+// If wait is not positive, return ErrRetryRequested.
+func RequestRetry(wait time.Duration) error {
+	if wait <= 0 {
+		return ErrRetryRequested
+	}
+	return RetryRequestedError{Wait: wait}
+}
+
+// isRetryRequested reports whether err is or wraps ErrRetryRequested,
+// and returns the wait hint if it has.
+func isRetryRequested(err error) (wait time.Duration, ok bool) {
+	for err != nil {
+		if e, ok := err.(RetryRequestedError); ok {
+			return e.Wait, true
+		} else if err == ErrRetryRequested {
+			return 0, true
+		}
+
+		if u, ok := err.(interface{ Unwrap() error }); ok {
+			err = u.Unwrap()
+		} else {
+			break
+		}
+	}
+	return
+}
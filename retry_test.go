@@ -0,0 +1,340 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// retryableHandler requests to retry the request if the json body
+// contains the field "retryable" with true.
+func retryableHandler(dst interface{}, resp *http.Response) error {
+	defer resp.Body.Close()
+
+	var body struct {
+		Retryable bool   `json:"retryable"`
+		Data      string `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	} else if body.Retryable {
+		return RequestRetry(10 * time.Millisecond)
+	}
+
+	*dst.(*string) = body.Data
+	return nil
+}
+
+func TestRequestRetry(t *testing.T) {
+	if err := RequestRetry(0); err != ErrRetryRequested {
+		t.Errorf("expect ErrRetryRequested, but got %v", err)
+	}
+
+	err := DecodeError{Err: RequestRetry(time.Second)}
+	if wait, ok := isRetryRequested(err); !ok || wait != time.Second {
+		t.Errorf("expect the retry request with wait %s, but got %v %s", time.Second, ok, wait)
+	}
+
+	if _, ok := isRetryRequested(errors.New("test")); ok {
+		t.Errorf("unexpected the retry request")
+	}
+}
+
+func TestRetryRequestedWithoutRetry(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil).SetResponseHandler2xx(retryableHandler)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"retryable":true}`
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": {MIMEApplicationJSON}},
+			Body:       readCloser{strings.NewReader(body), http.NoBody},
+		}, nil
+	}))
+
+	var result string
+	err := client.Get("http://127.0.0.1").Do(context.Background(), &result).Unwrap()
+	if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %T", err)
+	} else if _, ok := isRetryRequested(e.Err); !ok {
+		t.Errorf("expect the retry request, but got %v", e.Err)
+	}
+}
+
+func TestRequestRetry2xxHandler(t *testing.T) {
+	var calls int
+	client := NewClient(nil).OnResponse(nil).SetResponseHandler2xx(retryableHandler).SetRetry(3, nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		body := `{"retryable":true}`
+		if calls == 3 {
+			body = `{"data":"ok"}`
+		}
+
+		return &http.Response{
+			StatusCode: 200,
+			Header:     http.Header{"Content-Type": {MIMEApplicationJSON}},
+			Body:       readCloser{strings.NewReader(body), http.NoBody},
+		}, nil
+	}))
+
+	var result string
+	start := time.Now()
+	resp := client.Get("http://127.0.0.1").Do(context.Background(), &result)
+	if err := resp.Unwrap(); err != nil {
+		t.Error(err)
+	} else if result != "ok" {
+		t.Errorf("expect result '%s', but got '%s'", "ok", result)
+	} else if attempts := resp.Attempts(); attempts != 3 {
+		t.Errorf("expect %d attempts, but got %d", 3, attempts)
+	} else if cost := time.Since(start); cost < 20*time.Millisecond {
+		t.Errorf("expect to wait for the hint, but got cost %s", cost)
+	}
+}
+
+func TestClientSetRetry(t *testing.T) {
+	var calls int
+	var bodies []string
+	var fails int
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if r.Body != nil {
+			data, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(data))
+		}
+
+		switch {
+		case calls > fails:
+			return &http.Response{StatusCode: 204, Header: http.Header{}, Body: http.NoBody}, nil
+		case calls%2 == 0:
+			return &http.Response{StatusCode: 503, Header: http.Header{}, Body: http.NoBody}, nil
+		default:
+			return nil, errors.New("connection reset")
+		}
+	}))
+
+	var backoffs []int
+	client.SetRetry(3, func(attempt int) time.Duration {
+		backoffs = append(backoffs, attempt)
+		return time.Millisecond
+	})
+
+	// Fail twice, then succeed.
+	fails = 2
+	resp := client.Put("http://127.0.0.1").SetBody(map[string]int{"a": 1}).Do(context.Background(), nil)
+	if err := resp.Unwrap(); err != nil {
+		t.Error(err)
+	} else if resp.StatusCode() != 204 {
+		t.Errorf("expect status code %d, but got %d", 204, resp.StatusCode())
+	} else if calls != 3 || resp.Attempts() != 3 {
+		t.Errorf("expect %d attempts, but got %d calls and %d attempts", 3, calls, resp.Attempts())
+	} else if len(backoffs) != 2 || backoffs[0] != 1 || backoffs[1] != 2 {
+		t.Errorf("unexpected backoff attempts: %v", backoffs)
+	}
+
+	for i, body := range bodies {
+		if body != "{\"a\":1}\n" {
+			t.Errorf("%d: expect body '%s', but got '%s'", i, `{"a":1}`, body)
+		}
+	}
+
+	// Exceed the maximum retries, and return the final attempt.
+	calls, fails = 0, 10
+	resp = client.Get("http://127.0.0.1").Do(context.Background(), nil)
+	if resp.StatusCode() != 503 || resp.Unwrap() == nil {
+		t.Errorf("expect the final 503 error, but got %d %v", resp.StatusCode(), resp.Unwrap())
+	} else if calls != 4 {
+		t.Errorf("expect %d attempts, but got %d", 4, calls)
+	}
+
+	// Override the retry by the request.
+	calls = 0
+	err := client.Get("http://127.0.0.1").SetRetry(0, nil).Do(context.Background(), nil).Unwrap()
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("expect the connection error, but got %v", err)
+	} else if calls != 1 {
+		t.Errorf("expect %d attempt, but got %d", 1, calls)
+	}
+
+	// The streaming body cannot be replayed.
+	calls = 0
+	err = client.Put("http://127.0.0.1").SetBody(strings.NewReader("abc")).Do(context.Background(), nil).Unwrap()
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if calls != 1 {
+		t.Errorf("expect %d attempt, but got %d", 1, calls)
+	}
+
+	// The context is canceled while waiting for the next attempt.
+	calls = 0
+	client.SetRetry(3, func(int) time.Duration { return time.Minute })
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = client.Get("http://127.0.0.1").Do(ctx, nil).Unwrap()
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("expect the connection error, but got %v", err)
+	} else if calls != 1 {
+		t.Errorf("expect %d attempt, but got %d", 1, calls)
+	}
+}
+
+func TestResponseRetryAfter(t *testing.T) {
+	tests := []struct {
+		Value string
+		Min   time.Duration
+		Max   time.Duration
+		OK    bool
+	}{
+		{Value: "", OK: false},
+		{Value: "abc", OK: false},
+		{Value: "-1", OK: false},
+		{Value: "120", Min: 2 * time.Minute, Max: 2 * time.Minute, OK: true},
+		{Value: time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), OK: true},
+		{Value: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat),
+			Min: 59 * time.Minute, Max: time.Hour, OK: true},
+	}
+
+	for _, test := range tests {
+		resp := NewTestResponse(ResponseOptions{StatusCode: 503, Header: http.Header{"Retry-After": {test.Value}}})
+		if wait, ok := resp.RetryAfter(); ok != test.OK {
+			t.Errorf("%s: expect ok %v, but got %v", test.Value, test.OK, ok)
+		} else if wait < test.Min || wait > test.Max {
+			t.Errorf("%s: expect wait in [%s, %s], but got %s", test.Value, test.Min, test.Max, wait)
+		}
+	}
+
+	if _, ok := new(Response).RetryAfter(); ok {
+		t.Errorf("expect no Retry-After, but got one")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var calls int
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		if calls++; calls > 1 {
+			return &http.Response{StatusCode: 204, Header: http.Header{}, Body: http.NoBody}, nil
+		}
+
+		header := http.Header{"Retry-After": {"120"}}
+		return &http.Response{StatusCode: 429, Header: header, Body: http.NoBody}, nil
+	}))
+	client.SetRetry(1, func(int) time.Duration { return time.Hour })
+
+	// Retry-After exceeds the context deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	resp := client.Get("http://127.0.0.1").Do(ctx, nil)
+	if resp.StatusCode() != 429 || resp.Unwrap() == nil {
+		t.Errorf("expect the 429 error, but got %d %v", resp.StatusCode(), resp.Unwrap())
+	} else if calls != 1 {
+		t.Errorf("expect %d attempt, but got %d", 1, calls)
+	} else if cost := time.Since(start); cost > 100*time.Millisecond {
+		t.Errorf("expect to stop retrying immediately, but got cost %s", cost)
+	}
+
+	// Retry-After is bounded by the maximum wait.
+	calls = 0
+	client.SetMaxRetryAfter(10 * time.Millisecond)
+	start = time.Now()
+	resp = client.Get("http://127.0.0.1").Do(ctx, nil)
+	if err := resp.Unwrap(); err != nil {
+		t.Error(err)
+	} else if calls != 2 {
+		t.Errorf("expect %d attempts, but got %d", 2, calls)
+	} else if cost := time.Since(start); cost < 10*time.Millisecond || cost > 500*time.Millisecond {
+		t.Errorf("expect to wait for the maximum, but got cost %s", cost)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var calls int
+	client := NewClient(nil).OnResponse(nil).SetRetry(2, nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		switch calls++; {
+		case calls == 1 && r.Method == http.MethodPost:
+			return nil, io.ErrUnexpectedEOF
+		case calls == 1:
+			body := `{"error":"lock contention"}`
+			return &http.Response{StatusCode: 409, Header: http.Header{}, Body: readCloser{strings.NewReader(body), http.NoBody}}, nil
+		default:
+			body := `{"error":"conflict"}`
+			return &http.Response{StatusCode: 409, Header: http.Header{}, Body: readCloser{strings.NewReader(body), http.NoBody}}, nil
+		}
+	}))
+
+	// The default policy does not retry POST and 409.
+	_ = client.Post("http://127.0.0.1").Do(context.Background(), nil)
+	if calls != 1 {
+		t.Errorf("expect %d attempt, but got %d", 1, calls)
+	}
+
+	calls = 0
+	_ = client.Get("http://127.0.0.1").Do(context.Background(), nil)
+	if calls != 1 {
+		t.Errorf("expect %d attempt, but got %d", 1, calls)
+	}
+
+	client.SetRetryPolicy(func(attempt int, req *http.Request, resp *http.Response, err error) bool {
+		if resp == nil {
+			return err == io.ErrUnexpectedEOF
+		} else if resp.StatusCode != 409 {
+			return false
+		}
+
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		return strings.Contains(string(data), "lock contention")
+	})
+
+	// Retry the POST request by the custom policy.
+	calls = 0
+	_ = client.Post("http://127.0.0.1").Do(context.Background(), nil)
+	if calls != 2 {
+		t.Errorf("expect %d attempts, but got %d", 2, calls)
+	}
+
+	// The policy peeks the response body, which is restored for the handler.
+	calls = 0
+	var bodies []string
+	err := client.Get("http://127.0.0.1").SetResponseHandler4xx(func(dst interface{}, resp *http.Response) error {
+		data, err := ioutil.ReadAll(resp.Body)
+		bodies = append(bodies, string(data))
+		return err
+	}).Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if calls != 2 {
+		t.Errorf("expect %d attempts, but got %d", 2, calls)
+	} else if len(bodies) != 2 || bodies[0] != `{"error":"lock contention"}` || bodies[1] != `{"error":"conflict"}` {
+		t.Errorf("unexpected bodies: %v", bodies)
+	}
+
+	// The request policy overrides the client policy.
+	calls = 0
+	_ = client.Get("http://127.0.0.1").SetRetryPolicy(DefaultRetryPolicy).Do(context.Background(), nil)
+	if calls != 1 {
+		t.Errorf("expect %d attempt, but got %d", 1, calls)
+	}
+}
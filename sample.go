@@ -0,0 +1,86 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// LogSampler is used to sample the responses to be logged.
+type LogSampler struct {
+	rate   float64
+	always func(*Response) bool
+
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+// NewLogSampler returns a new log sampler, which samples the responses
+// by the rate between 0 and 1 with the random source seeded by seed,
+// so the sample is deterministic for the same seed.
+//
+// If always is not nil and returns true, such as the errors
+// or the slow requests, the response bypasses the sampling.
+func NewLogSampler(rate float64, always func(*Response) bool, seed int64) *LogSampler {
+	return &LogSampler{rate: rate, always: always, rand: rand.New(rand.NewSource(seed))}
+}
+
+// Rate returns the sample rate.
+func (s *LogSampler) Rate() float64 { return s.rate }
+
+// Sample reports whether the response should be logged, and whether
+// it is chosen by the sampling instead of the always-predicate.
+func (s *LogSampler) Sample(r *Response) (log, sampled bool) {
+	if s.always != nil && s.always(r) {
+		return true, false
+	}
+
+	switch {
+	case s.rate <= 0:
+		return false, false
+	case s.rate >= 1:
+		return true, true
+	}
+
+	s.lock.Lock()
+	sampled = s.rand.Float64() < s.rate
+	s.lock.Unlock()
+	return sampled, sampled
+}
+
+// SetLogSampling sets the log sampling, which is equal to
+// SetLogSampler(NewLogSampler(rate, always, time.Now().UnixNano())).
+func (c *Client) SetLogSampling(rate float64, always func(*Response) bool) *Client {
+	return c.SetLogSampler(NewLogSampler(rate, always, time.Now().UnixNano()))
+}
+
+// SetLogSampler sets the log sampler, so that the callback set by OnResponse
+// is called only for the sampled responses, and the logged records carry
+// the attribute "sampled" to indicate whether it is chosen by the sampling,
+// which can be used to re-scale the counts by the rate.
+//
+// If sampler is nil, clear it and call the callback for all the responses.
+//
+// Default: nil
+func (c *Client) SetLogSampler(sampler *LogSampler) *Client {
+	c.sampler = sampler
+	return c
+}
+
+// Sampled reports whether the response is chosen by the log sampling,
+// and ok is false if the log sampling is not enabled.
+func (r *Response) Sampled() (sampled, ok bool) { return r.sampled, r.sampling }
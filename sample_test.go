@@ -0,0 +1,112 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLogSampler(t *testing.T) {
+	s1 := NewLogSampler(0.3, nil, 123)
+	s2 := NewLogSampler(0.3, nil, 123)
+
+	var num int
+	for i := 0; i < 1000; i++ {
+		log1, _ := s1.Sample(nil)
+		log2, _ := s2.Sample(nil)
+		if log1 != log2 {
+			t.Fatalf("the sample is not deterministic")
+		}
+		if log1 {
+			num++
+		}
+	}
+
+	if num < 200 || num > 400 {
+		t.Errorf("expect about 300 sampled responses, but got %d", num)
+	}
+
+	always := func(r *Response) bool { return r.Result() != nil }
+	if log, sampled := NewLogSampler(0, always, 0).Sample(NewTestResponse(ResponseOptions{Err: ErrClientClosed})); !log || sampled {
+		t.Errorf("expect to log the error response without sampling")
+	}
+	if log, _ := NewLogSampler(0, always, 0).Sample(NewTestResponse(ResponseOptions{StatusCode: 200})); log {
+		t.Errorf("unexpect to log the response")
+	}
+	if log, sampled := NewLogSampler(1, always, 0).Sample(NewTestResponse(ResponseOptions{StatusCode: 200})); !log || !sampled {
+		t.Errorf("expect to log the sampled response")
+	}
+}
+
+func TestClientLogSampling(t *testing.T) {
+	doer := DoFunc(func(req *http.Request) (*http.Response, error) {
+		code := 204
+		if req.URL.Path == "/error" {
+			code = 500
+		}
+		return &http.Response{
+			StatusCode: code,
+			Header:     make(http.Header),
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	})
+
+	var lock sync.Mutex
+	var logged, sampled, errors int
+	client := NewClient(nil).SetDoer(doer).OnResponse(func(r *Response) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		logged++
+		if s, ok := r.Sampled(); !ok {
+			t.Errorf("expect the sampling is enabled")
+		} else if s {
+			sampled++
+		} else {
+			errors++
+		}
+	})
+
+	client.SetLogSampler(NewLogSampler(0.5, func(r *Response) bool { return r.StatusCode() >= 500 }, 1))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				client.Get("http://127.0.0.1/").Do(context.Background(), nil).Close()
+				client.Get("http://127.0.0.1/error").Do(context.Background(), nil).Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if errors != 400 {
+		t.Errorf("expect %d error responses, but got %d", 400, errors)
+	}
+	if sampled < 150 || sampled > 250 {
+		t.Errorf("expect about 200 sampled responses, but got %d", sampled)
+	}
+	if logged != errors+sampled {
+		t.Errorf("expect %d logged responses, but got %d", errors+sampled, logged)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SingletonHeaderPolicy is the policy to handle the duplicated values
+// of the response headers which should be singletons.
+type SingletonHeaderPolicy int
+
+// Pre-define some policies of the singleton response headers.
+const (
+	// SingletonFirst keeps the first value, which is the default.
+	SingletonFirst SingletonHeaderPolicy = iota
+
+	// SingletonLast keeps the last value.
+	SingletonLast
+
+	// SingletonError fails the request with MalformedResponseError.
+	SingletonError
+)
+
+// SingletonHeaders is the list of the response headers
+// which should be singletons.
+var SingletonHeaders = []string{HeaderContentType, "Content-Length", "Location"}
+
+// Issue is an issue of the malformed response.
+type Issue struct {
+	Header string
+	Values []string
+	Reason string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s %q", i.Header, i.Reason, i.Values)
+}
+
+// MalformedResponseError is returned when the response is malformed
+// with the policy SingletonError.
+type MalformedResponseError struct {
+	Issues []Issue
+}
+
+func (e MalformedResponseError) Error() string {
+	issues := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		issues[i] = issue.String()
+	}
+	return "malformed response: " + strings.Join(issues, "; ")
+}
+
+// SetSingletonHeaderPolicy sets the policy to handle the duplicated values
+// of the singleton response headers in SingletonHeaders.
+//
+// Default: SingletonFirst
+func (c *Client) SetSingletonHeaderPolicy(policy SingletonHeaderPolicy) *Client {
+	c.singleton = policy
+	return c
+}
+
+// OnMalformedResponse sets a callback function to observe the issues
+// of the malformed response, which is called before applying the policy
+// of the singleton response headers.
+//
+// Default: nil
+func (c *Client) OnMalformedResponse(f func(resp *http.Response, issues []Issue)) *Client {
+	c.onmalformed = f
+	return c
+}
+
+// sanitizeResponse checks the singleton response headers and applies
+// the policy to them.
+func (r *Request) sanitizeResponse(resp *http.Response) error {
+	if r.singleton == SingletonFirst && r.onmalformed == nil {
+		return nil
+	}
+
+	var issues []Issue
+	for _, key := range SingletonHeaders {
+		values := resp.Header[key]
+		if len(values) > 1 {
+			issues = append(issues, Issue{Header: key, Values: values, Reason: "duplicated values"})
+		}
+
+		if key == "Content-Length" && len(values) > 0 && resp.ContentLength >= 0 {
+			for _, v := range values {
+				if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err != nil || n != resp.ContentLength {
+					issues = append(issues, Issue{Header: key, Values: values, Reason: "mismatched body length"})
+					break
+				}
+			}
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+
+	if r.onmalformed != nil {
+		r.onmalformed(resp, issues)
+	}
+
+	switch r.singleton {
+	case SingletonError:
+		return MalformedResponseError{Issues: issues}
+
+	case SingletonLast:
+		for _, key := range SingletonHeaders {
+			if values := resp.Header[key]; len(values) > 1 {
+				resp.Header[key] = values[len(values)-1:]
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSingletonHeaderPolicy(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		const body = `<v>xml</v>`
+		resp := &http.Response{
+			StatusCode:    200,
+			Header:        make(http.Header),
+			Body:          readCloser{strings.NewReader(body), http.NoBody},
+			ContentLength: int64(len(body)),
+			Request:       r,
+		}
+		resp.Header["Content-Type"] = []string{MIMEApplicationJSON, MIMEApplicationXML}
+		resp.Header["Content-Length"] = []string{"10", "11"}
+		return resp, nil
+	}))
+
+	var issues []Issue
+	client.OnMalformedResponse(func(resp *http.Response, _issues []Issue) { issues = _issues })
+
+	// First: the current behavior
+	var result string
+	err := client.Get("http://127.0.0.1").Do(context.Background(), &result).Unwrap()
+	if err == nil {
+		t.Errorf("expect a decode error, but got nil")
+	} else if len(issues) != 3 {
+		t.Errorf("expect 3 issues, but got %v", issues)
+	} else if issues[0].Header != "Content-Type" || issues[1].Header != "Content-Length" ||
+		issues[2].Reason != "mismatched body length" {
+		t.Errorf("unexpected issues: %v", issues)
+	}
+
+	// Last
+	issues = nil
+	client.SetSingletonHeaderPolicy(SingletonLast)
+	if err := client.Get("http://127.0.0.1").Do(context.Background(), &result).Unwrap(); err != nil {
+		t.Error(err)
+	} else if result != "xml" {
+		t.Errorf("expect result '%s', but got '%s'", "xml", result)
+	} else if len(issues) != 3 {
+		t.Errorf("expect 3 issues, but got %v", issues)
+	}
+
+	// Error
+	client.SetSingletonHeaderPolicy(SingletonError)
+	err = client.Get("http://127.0.0.1").Do(context.Background(), &result).Unwrap()
+	if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %T", err)
+	} else if me, ok := e.Err.(MalformedResponseError); !ok {
+		t.Errorf("expect a MalformedResponseError, but got %T", e.Err)
+	} else if len(me.Issues) != 3 || !strings.Contains(me.Error(), "Content-Type: duplicated values") {
+		t.Errorf("unexpected error: %v", me)
+	}
+}
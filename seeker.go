@@ -0,0 +1,77 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"io"
+)
+
+// bufferedBody is the response body buffered in the memory.
+type bufferedBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+func newBufferedBody(data []byte) *bufferedBody {
+	return &bufferedBody{Reader: bytes.NewReader(data), data: data}
+}
+
+func (b *bufferedBody) Close() error { return nil }
+
+// unwrapBody returns the buffered data of the response body
+// through the inner wrappers, and reports whether it is buffered.
+func unwrapBody(body io.ReadCloser) ([]byte, bool) {
+	for {
+		switch b := body.(type) {
+		case *bufferedBody:
+			return b.data, true
+		case *cancelBody:
+			body = b.ReadCloser
+		case *inflightBody:
+			body = b.ReadCloser
+		case *concurrencyBody:
+			body = b.ReadCloser
+		case *limitResponseBody:
+			body = b.ReadCloser
+		case *readErrorBody:
+			body = b.ReadCloser
+		default:
+			return nil, false
+		}
+	}
+}
+
+// BodySeeker returns a new seekable reader of the response body
+// if it has been buffered in the memory, such as captured by the last known
+// good body, or replaced by ReplaceResponseBody like the signature verifier,
+// which reports false if the body is streamed.
+//
+// The returned reader is independent of the response body, so it is still
+// valid after the response is closed, and reads the whole body from the start
+// whether or not the response body has been read.
+func (r *Response) BodySeeker() (io.ReadSeeker, bool) {
+	if r.bodydata != nil {
+		return bytes.NewReader(r.bodydata), true
+	} else if r.resp == nil || r.resp.Body == nil {
+		return nil, false
+	}
+
+	data, ok := unwrapBody(r.resp.Body)
+	if !ok {
+		return nil, false
+	}
+	return bytes.NewReader(data), true
+}
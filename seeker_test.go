@@ -0,0 +1,97 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResponseBodySeeker(t *testing.T) {
+	var failed int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failed) == 1 {
+			w.WriteHeader(503)
+			return
+		}
+		w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		_, _ = w.Write([]byte(`"abcdef"`))
+	}))
+	defer server.Close()
+
+	expectSeeker := func(resp *Response, expect string) {
+		t.Helper()
+		seeker, ok := resp.BodySeeker()
+		if !ok {
+			t.Errorf("expect the buffered body, but got none")
+			return
+		}
+
+		if _, err := seeker.Seek(1, io.SeekStart); err != nil {
+			t.Error(err)
+		} else if data, _ := ioutil.ReadAll(seeker); string(data) != expect[1:] {
+			t.Errorf("expect body '%s', but got '%s'", expect[1:], data)
+		}
+	}
+
+	// The streamed body
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	var result string
+	resp := client.Get(server.URL).Do(context.Background(), &result)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := resp.BodySeeker(); ok {
+		t.Errorf("expect no buffered body for the streamed body")
+	}
+
+	// The body replaced by ReplaceResponseBody
+	client = NewClient(http.DefaultClient).OnResponse(nil).
+		AddResponseTransformer(func(resp *http.Response) (*http.Response, error) {
+			data, err := ioutil.ReadAll(resp.Body)
+			if err == nil {
+				ReplaceResponseBody(resp, data, "")
+			}
+			return resp, err
+		})
+	resp = client.Get(server.URL).SetTimeout(time.Minute).Do(context.Background(), &result)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+	expectSeeker(resp, `"abcdef"`)
+
+	// The body captured and served by the last known good body
+	client = NewClient(http.DefaultClient).OnResponse(nil).
+		EnableLastGood(NewMemoryCacheStore(0), time.Minute)
+	resp = client.Get(server.URL).Do(context.Background(), &result)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+	expectSeeker(resp, `"abcdef"`)
+
+	atomic.StoreInt32(&failed, 1)
+	resp = client.Get(server.URL).Do(context.Background(), &result)
+	if err := resp.Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if _, ok := resp.Stale(); !ok {
+		t.Fatal("expect the stale response")
+	}
+	expectSeeker(resp, `"abcdef"`)
+}
@@ -0,0 +1,291 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Session is a client layered on Client, which owns a cookie jar
+// and a set of the default headers acquired from the responses,
+// and can be saved into and loaded from a file to be reused across runs.
+//
+// All the requests are built by the embedded Client.
+type Session struct {
+	*Client
+
+	jar     *sessionJar
+	lock    sync.Mutex
+	headers http.Header
+}
+
+// NewSession returns a new session based on the clone of the client.
+//
+// If the doer of the client is *http.Client, it is copied with the cookie
+// jar of the session. Or, the doer is wrapped to send and store the cookies,
+// which only sees the cookies of the final response if the doer follows
+// the redirects by itself.
+//
+// If client is nil, use DefaultClient instead.
+func NewSession(client *Client) *Session {
+	if client == nil {
+		client = DefaultClient
+	}
+
+	jar, _ := cookiejar.New(nil)
+	s := &Session{Client: client.Clone(), jar: &sessionJar{jar: jar}, headers: make(http.Header)}
+	if c := s.Client.GetHTTPClient(); c != nil {
+		hclient := *c
+		hclient.Jar = s.jar
+		s.Client.SetHTTPClient(&hclient)
+	} else if doer := s.Client.GetDoer(); doer != nil {
+		s.Client.SetDoer(s.jar.wrap(doer))
+	} else {
+		s.Client.SetHTTPClient(&http.Client{Jar: s.jar})
+	}
+	s.Client.AddHook(HookFunc(s.setHeaders))
+	return s
+}
+
+// setHeaders sets the session headers into the request
+// if they are not set by the request.
+func (s *Session) setHeaders(req *http.Request) *http.Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var header http.Header
+	for key, values := range s.headers {
+		if _, ok := req.Header[key]; !ok {
+			if header == nil {
+				header = cloneHeaderForWrite(req)
+			}
+			header[key] = append([]string(nil), values...)
+		}
+	}
+	return req
+}
+
+// SetHeaderExtractor sets the extractor to extract the headers
+// from each response, such as the session token from the login response,
+// which will be set as the default headers of the session.
+//
+// If extract returns the empty headers, nothing is changed.
+func (s *Session) SetHeaderExtractor(extract func(*http.Response) http.Header) *Session {
+	s.Client.AddResponseTransformer(func(resp *http.Response) (*http.Response, error) {
+		if headers := extract(resp); len(headers) > 0 {
+			s.SetSessionHeaders(headers)
+		}
+		return resp, nil
+	})
+	return s
+}
+
+// SetSessionHeaders sets the session headers, which will be added into
+// each request if not set and saved into the session file.
+func (s *Session) SetSessionHeaders(headers http.Header) *Session {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key, values := range headers {
+		s.headers[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// SessionHeaders returns the copy of the session headers.
+func (s *Session) SessionHeaders() http.Header {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return cloneHeader(s.headers)
+}
+
+// Cookies returns the cookies of the session to be sent to the url.
+func (s *Session) Cookies(u *url.URL) []*http.Cookie { return s.jar.Cookies(u) }
+
+type sessionFile struct {
+	Headers http.Header     `json:"headers,omitempty"`
+	Cookies []sessionCookie `json:"cookies,omitempty"`
+}
+
+type sessionCookie struct {
+	URL      string    `json:"url"`
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Path     string    `json:"path,omitempty"`
+	Domain   string    `json:"domain,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"`
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"httponly,omitempty"`
+}
+
+func (c sessionCookie) Expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// Save saves the session cookies and headers into the file as JSON
+// with the permission 0600.
+func (s *Session) Save(path string) (err error) {
+	sf := sessionFile{Headers: s.SessionHeaders(), Cookies: s.jar.Records()}
+	data, err := json.MarshalIndent(sf, "", "  ")
+	if err != nil {
+		return
+	}
+
+	if err = ioutil.WriteFile(path, data, 0600); err == nil {
+		err = os.Chmod(path, 0600) // The file may exist with other permissions.
+	}
+	return
+}
+
+// Load loads the session cookies and headers from the file,
+// which prunes the expired cookies.
+func (s *Session) Load(path string) (err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var sf sessionFile
+	if err = json.Unmarshal(data, &sf); err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, c := range sf.Cookies {
+		if c.Expired(now) {
+			continue
+		}
+
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			continue
+		}
+
+		s.jar.SetCookies(u, []*http.Cookie{{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}})
+	}
+
+	s.SetSessionHeaders(sf.Headers)
+	return
+}
+
+// sessionJar is a cookie jar recording the cookies to be saved.
+type sessionJar struct {
+	jar     http.CookieJar
+	lock    sync.Mutex
+	records []sessionCookie
+}
+
+// wrap wraps the doer, which is not *http.Client, to send the cookies
+// of the jar and store the cookies of the response into the jar.
+func (j *sessionJar) wrap(next Doer) Doer {
+	return DoFunc(func(req *http.Request) (*http.Response, error) {
+		if cookies := j.Cookies(req.URL); len(cookies) > 0 {
+			cloneHeaderForWrite(req)
+			for _, cookie := range cookies {
+				req.AddCookie(cookie)
+			}
+		}
+
+		resp, err := next.Do(req)
+		if err == nil {
+			if cookies := resp.Cookies(); len(cookies) > 0 {
+				u := req.URL
+				if resp.Request != nil && resp.Request.URL != nil {
+					u = resp.Request.URL
+				}
+				j.SetCookies(u, cookies)
+			}
+		}
+		return resp, err
+	})
+}
+
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie { return j.jar.Cookies(u) }
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	origin := (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	for _, c := range cookies {
+		record := sessionCookie{
+			URL:      origin,
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+
+		switch {
+		case c.MaxAge < 0:
+			record.Expires = now
+		case c.MaxAge > 0:
+			record.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+
+		j.set(record, now)
+	}
+}
+
+func (j *sessionJar) set(record sessionCookie, now time.Time) {
+	for i, r := range j.records {
+		if r.URL == record.URL && r.Name == record.Name &&
+			r.Path == record.Path && r.Domain == record.Domain {
+			if record.Expired(now) {
+				j.records = append(j.records[:i], j.records[i+1:]...)
+			} else {
+				j.records[i] = record
+			}
+			return
+		}
+	}
+
+	if !record.Expired(now) {
+		j.records = append(j.records, record)
+	}
+}
+
+func (j *sessionJar) Records() []sessionCookie {
+	now := time.Now()
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	records := make([]sessionCookie, 0, len(j.records))
+	for _, r := range j.records {
+		if !r.Expired(now) {
+			records = append(records, r)
+		}
+	}
+	return records
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc", Path: "/"})
+			http.SetCookie(w, &http.Cookie{Name: "old", Value: "x", Path: "/",
+				Expires: time.Now().Add(time.Second)})
+			w.Header().Set("X-Session-Token", "token")
+
+		case "/me":
+			if c, err := r.Cookie("sid"); err != nil || c.Value != "abc" {
+				w.WriteHeader(401)
+				return
+			}
+			if r.Header.Get("X-Session-Token") != "token" {
+				w.WriteHeader(403)
+				return
+			}
+			if _, err := r.Cookie("old"); err == nil {
+				w.WriteHeader(400)
+				return
+			}
+			w.Header().Set(HeaderContentType, MIMEApplicationJSON)
+			_, _ = w.Write([]byte(`"me"`))
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	extract := func(resp *http.Response) http.Header {
+		if token := resp.Header.Get("X-Session-Token"); token != "" {
+			return http.Header{"X-Session-Token": []string{token}}
+		}
+		return nil
+	}
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	session := NewSession(client).SetHeaderExtractor(extract)
+	if err := session.Get(server.URL+"/login").Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if fi, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if mode := fi.Mode().Perm(); mode != 0600 {
+		t.Errorf("expect file mode 0600, but got %o", mode)
+	}
+
+	if client.GetHTTPClient().Jar != nil {
+		t.Errorf("the original http client is changed")
+	}
+	if err := client.Get(server.URL+"/me").Do(context.Background(), nil).Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+
+	time.Sleep(time.Second + time.Millisecond*100)
+
+	session = NewSession(client)
+	if err := session.Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var body string
+	if err := session.Get(server.URL+"/me").Do(context.Background(), &body).Unwrap(); err != nil {
+		t.Error(err)
+	} else if body != "me" {
+		t.Errorf("expect body '%s', but got '%s'", "me", body)
+	}
+
+	if token := session.SessionHeaders().Get("X-Session-Token"); token != "token" {
+		t.Errorf("expect session token '%s', but got '%s'", "token", token)
+	}
+}
+
+func TestSessionHeadersNotShared(t *testing.T) {
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Token")
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	session := NewSession(client)
+	for _, expect := range []string{"old", "new"} {
+		session.SetSessionHeaders(http.Header{"X-Token": []string{expect}})
+		if err := session.Get(server.URL).Do(context.Background(), nil).Unwrap(); err != nil {
+			t.Fatal(err)
+		} else if token != expect {
+			t.Errorf("expect the token '%s', but got '%s'", expect, token)
+		}
+	}
+
+	if _, ok := session.Client.header["X-Token"]; ok {
+		t.Errorf("the session header leaks into the client: %v", session.Client.header)
+	}
+	if _, ok := client.header["X-Token"]; ok {
+		t.Errorf("the session header leaks into the client: %v", client.header)
+	}
+}
+
+func TestSessionWrapDoer(t *testing.T) {
+	var cookie, mw string
+	client := NewClient(nil).OnResponse(nil).Use(func(next Doer) Doer {
+		return DoFunc(func(r *http.Request) (*http.Response, error) {
+			cloneHeaderForWrite(r).Set("X-Middleware", "1")
+			return next.Do(r)
+		})
+	})
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		cookie, mw = r.Header.Get("Cookie"), r.Header.Get("X-Middleware")
+		header := http.Header{"Set-Cookie": []string{"sid=abc; Path=/"}}
+		return &http.Response{StatusCode: 204, Header: header, Body: http.NoBody, Request: r}, nil
+	}))
+
+	session := NewSession(client)
+	for _, expect := range []string{"", "sid=abc"} {
+		if err := session.Get("http://127.0.0.1/").Do(context.Background(), nil).Unwrap(); err != nil {
+			t.Fatal(err)
+		} else if cookie != expect {
+			t.Errorf("expect the cookie '%s', but got '%s'", expect, cookie)
+		} else if mw != "1" {
+			t.Errorf("expect the middleware to be kept")
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrShadowDropped is reported when the shadow request is dropped
+// because there are too many in-flight shadow requests.
+var ErrShadowDropped = errors.New("the shadow request is dropped")
+
+// ShadowOptions is the options of the shadow traffic.
+type ShadowOptions struct {
+	// Timeout is the timeout of each shadow request.
+	//
+	// Default: 10s
+	Timeout time.Duration
+
+	// MaxConcurrent is the maximum number of the in-flight shadow requests,
+	// and the more shadow requests are dropped.
+	//
+	// Default: 16
+	MaxConcurrent int
+
+	// Collector collects the status and latency of each shadow request
+	// against the primary request, such as the metrics or the logs.
+	//
+	// Default: nil, that's, discard the results
+	Collector ShadowCollector
+}
+
+// ShadowCollector is used to collect the results of the shadow requests.
+type ShadowCollector interface {
+	CollectShadow(ShadowResult)
+}
+
+// ShadowCollectorFunc is a function to collect the shadow results.
+type ShadowCollectorFunc func(ShadowResult)
+
+// CollectShadow implements the interface ShadowCollector.
+func (f ShadowCollectorFunc) CollectShadow(r ShadowResult) { f(r) }
+
+// ShadowResult is the result of the shadow request.
+type ShadowResult struct {
+	Method string
+	URL    string // The redacted url of the shadow request.
+
+	StatusCode int // 0 if failing to send the request.
+	Latency    time.Duration
+	Err        error
+
+	PrimaryStatusCode int
+	PrimaryLatency    time.Duration
+}
+
+// LatencyDelta returns the latency difference of the shadow request
+// against the primary request.
+func (r ShadowResult) LatencyDelta() time.Duration { return r.Latency - r.PrimaryLatency }
+
+type shadowState struct {
+	target *url.URL
+	rate   float64
+	opts   ShadowOptions
+	sem    chan struct{}
+}
+
+// SetShadow sets the shadow traffic, which replays the same method, headers
+// and body of a sampled request against the target base url asynchronously
+// after the primary request succeeds, and discards the shadow response.
+//
+// sampleRate is the percentage of the mirrored requests in [0, 1].
+// The shadow request uses a detached context with its own timeout,
+// and never affects the primary request, including its failure.
+//
+// The shadow requests are tracked as the in-flight requests of the client,
+// so Shutdown waits for them, and no request is mirrored after Shutdown.
+//
+// Notice: the request with the streaming body or BodyProvider is not mirrored.
+//
+// If target is empty, disable the shadow traffic.
+func (c *Client) SetShadow(target string, sampleRate float64, opts ShadowOptions) *Client {
+	if target == "" {
+		c.shadow = nil
+		return c
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		panic(fmt.Errorf("Client.SetShadow: invalid shadow target '%s'", redactURL(target)))
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second * 10
+	}
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = 16
+	}
+
+	c.shadow = &shadowState{
+		target: u,
+		rate:   sampleRate,
+		opts:   opts,
+		sem:    make(chan struct{}, opts.MaxConcurrent),
+	}
+	return c
+}
+
+// mirror sends the shadow request of the succeeded primary request.
+func (s *shadowState) mirror(r *Request, resp *Response) {
+	if resp.err != nil || resp.req == nil || resp.resp == nil || s.rate <= 0 ||
+		(s.rate < 1 && rand.Float64() >= s.rate) {
+		return
+	}
+
+	var body []byte
+	switch {
+	case r.bodybuf != nil:
+		body = append([]byte(nil), r.bodybuf.Bytes()...)
+	case r.reqbody != nil, r.bodyp != nil: // Not replayable
+		return
+	}
+
+	u := *resp.req.URL
+	u.Scheme, u.Host, u.User = s.target.Scheme, s.target.Host, s.target.User
+	u.Path, u.RawPath = s.target.Path+u.Path, ""
+
+	result := ShadowResult{
+		Method:            resp.req.Method,
+		URL:               redactWith(r.redactor, &u),
+		PrimaryStatusCode: resp.resp.StatusCode,
+		PrimaryLatency:    resp.cost,
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+	default:
+		result.Err = ErrShadowDropped
+		s.report(result)
+		return
+	}
+
+	header := cloneHeader(resp.req.Header)
+	if r.header.Get(HeaderContentEncoding) == "" { // Compressed by CompressBody
+		header.Del(HeaderContentEncoding)
+	}
+
+	if !r.inflight.Add() { // The client is shut down.
+		<-s.sem
+		return
+	}
+
+	go func() {
+		defer func() { <-s.sem; r.inflight.Done() }()
+		s.send(r.client, &u, header, body, result)
+	}()
+}
+
+func (s *shadowState) send(doer Doer, u *url.URL, header http.Header, body []byte, result ShadowResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+	defer cancel()
+
+	var req *http.Request
+	req, result.Err = NewRequestWithContext(ctx, result.Method, u.String(), bytes.NewReader(body))
+	if result.Err != nil {
+		s.report(result)
+		return
+	}
+	req.Header = header
+
+	start := time.Now()
+	resp, err := doer.Do(req)
+	if err == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+		result.StatusCode = resp.StatusCode
+	}
+	result.Latency, result.Err = time.Since(start), err
+	s.report(result)
+}
+
+func (s *shadowState) report(result ShadowResult) {
+	if s.opts.Collector != nil {
+		s.opts.Collector.CollectShadow(result)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientShadow(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(500)
+			return
+		}
+		w.WriteHeader(204)
+	}))
+	defer primary.Close()
+
+	type shadowRequest struct {
+		Method string
+		URL    string
+		Header string
+		Body   string
+	}
+
+	requests := make(chan shadowRequest, 4)
+	release := make(chan struct{})
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- shadowRequest{
+			Method: r.Method,
+			URL:    r.URL.String(),
+			Header: r.Header.Get("X-Test"),
+			Body:   string(body),
+		}
+
+		if r.URL.Query().Get("block") != "" {
+			<-release
+		}
+		w.WriteHeader(500)
+	}))
+	defer shadow.Close()
+
+	results := make(chan ShadowResult, 4)
+	client := NewClient(http.DefaultClient).OnResponse(nil).SetURLRedactor(NewURLRedactor("a"))
+	client.SetShadow(shadow.URL+"/v2/", 1, ShadowOptions{
+		MaxConcurrent: 1,
+		Collector:     ShadowCollectorFunc(func(r ShadowResult) { results <- r }),
+	})
+
+	err := client.Post(primary.URL+"/path").AddQuery("a", "1").AddHeader("X-Test", "abc").
+		SetBody(map[string]string{"k": "v"}).Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case req := <-requests:
+		if req.Method != http.MethodPost || req.URL != "/v2/path?a=1" ||
+			req.Header != "abc" || req.Body != "{\"k\":\"v\"}\n" {
+			t.Errorf("unexpected shadow request: %+v", req)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout to wait for the shadow request")
+	}
+
+	result := <-results
+	if result.StatusCode != 500 || result.PrimaryStatusCode != 204 || result.Err != nil {
+		t.Errorf("unexpected shadow result: %+v", result)
+	} else if expect := shadow.URL + "/v2/path?a=***"; result.URL != expect {
+		t.Errorf("expect the redacted url '%s', but got '%s'", expect, result.URL)
+	}
+
+	// The failed primary request is not mirrored.
+	if err := client.Get(primary.URL+"/error").Do(context.Background(), nil).Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+
+	// Bounded
+	for i := 0; i < 2; i++ {
+		err := client.Get(primary.URL+"/block").AddQuery("block", "1").Do(context.Background(), nil).Unwrap()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if result := <-results; result.Err != ErrShadowDropped {
+		t.Errorf("expect error ErrShadowDropped, but got %v", result.Err)
+	}
+	close(release)
+	<-requests
+	if result := <-results; result.Err != nil || result.StatusCode != 500 {
+		t.Errorf("unexpected shadow result: %+v", result)
+	}
+
+	select {
+	case req := <-requests:
+		t.Errorf("unexpected shadow request: %+v", req)
+	default:
+	}
+
+	// Shadow failures are invisible.
+	client.SetShadow("http://127.0.0.1:1", 1, ShadowOptions{
+		Collector: ShadowCollectorFunc(func(r ShadowResult) { results <- r }),
+	})
+	if err := client.Get(primary.URL).Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if result := <-results; result.Err == nil {
+		t.Errorf("expect an error of the shadow request, but got nil")
+	}
+}
+
+func TestShadowShutdown(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(204)
+	}))
+	defer primary.Close()
+
+	var requests int32
+	release := make(chan struct{})
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		w.WriteHeader(204)
+	}))
+	defer shadow.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).SetShadow(shadow.URL, 1, ShadowOptions{})
+	if err := client.Get(primary.URL).Do(context.Background(), nil).Unwrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Shutdown waits for the in-flight shadow request.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expect the deadline error, but got %v", err)
+	}
+
+	close(release)
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expect %d shadow request, but got %d", 1, n)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// QuerySigningOptions is the options of the query signing hook.
+type QuerySigningOptions struct {
+	// If true, the empty query values won't be signed.
+	SkipEmpty bool
+
+	// If true, the query keys and values are not escaped.
+	Unescaped bool
+
+	// If true, the request method and path are prepended
+	// to the canonical string.
+	IncludeMethod bool
+	IncludePath   bool
+
+	// If not nil, the result of the request body is appended
+	// to the canonical string.
+	BodyHash func(body []byte) string
+
+	// Separator is used to join the method, path, query and body hash.
+	//
+	// Default: "\n"
+	Separator string
+}
+
+// NewQuerySigningHook returns a new hook to sign the final request query,
+// which canonicalizes the query sorted by the key, computes the signature
+// by sign, and appends it as the query parameter named paramName.
+//
+// The canonical query is like "k1=v1&k2=v2a&k2=v2b", which may be prefixed
+// with the method and the path, and suffixed with the body hash, by opts.
+//
+// The signature parameter is appended to the raw query of the request url,
+// which keeps the other parameters as they are, such as their order
+// and the array style, and replaces the existing signature parameter.
+//
+// Notice: the hook is always called after the queries of the client and
+// the request have been merged into the request url, so it signs
+// the final query. If there are other hooks to modify the query,
+// the signing hook should be added at last.
+func NewQuerySigningHook(paramName string, sign func(canonical string) string, opts QuerySigningOptions) Hook {
+	if opts.Separator == "" {
+		opts.Separator = "\n"
+	}
+
+	return HookFunc(func(r *http.Request) *http.Request {
+		query := r.URL.Query()
+		query.Del(paramName)
+
+		segments := make([]string, 0, 4)
+		if opts.IncludeMethod {
+			segments = append(segments, r.Method)
+		}
+		if opts.IncludePath {
+			segments = append(segments, r.URL.EscapedPath())
+		}
+		segments = append(segments, canonicalQuery(query, opts))
+		if opts.BodyHash != nil {
+			segments = append(segments, opts.BodyHash(readRequestBody(r)))
+		}
+
+		signature := url.QueryEscape(paramName) + "=" + url.QueryEscape(sign(strings.Join(segments, opts.Separator)))
+		if rawquery := removeRawQueryParam(r.URL.RawQuery, paramName); rawquery == "" {
+			r.URL.RawQuery = signature
+		} else {
+			r.URL.RawQuery = rawquery + "&" + signature
+		}
+		return r
+	})
+}
+
+// removeRawQueryParam removes the parameters named name from the raw query,
+// and keeps the others as they are.
+func removeRawQueryParam(rawquery, name string) string {
+	pairs := strings.Split(rawquery, "&")
+	kept := pairs[:0]
+	for _, pair := range pairs {
+		key := pair
+		if i := strings.IndexByte(key, '='); i >= 0 {
+			key = key[:i]
+		}
+
+		if key, err := url.QueryUnescape(key); pair != "" && (err != nil || key != name) {
+			kept = append(kept, pair)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+func canonicalQuery(query url.Values, opts QuerySigningOptions) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	escape := url.QueryEscape
+	if opts.Unescaped {
+		escape = func(s string) string { return s }
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	for _, key := range keys {
+		for _, value := range query[key] {
+			if value == "" && opts.SkipEmpty {
+				continue
+			}
+
+			if buf.Len() > 0 {
+				buf.WriteByte('&')
+			}
+			buf.WriteString(escape(key))
+			buf.WriteByte('=')
+			buf.WriteString(escape(value))
+		}
+	}
+	return buf.String()
+}
+
+// readRequestBody reads the request body without consuming it.
+func readRequestBody(r *http.Request) []byte {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	if r.GetBody != nil {
+		if body, err := r.GetBody(); err == nil {
+			data, _ := ioutil.ReadAll(body)
+			_ = body.Close()
+			return data
+		}
+	}
+
+	data, _ := ioutil.ReadAll(r.Body)
+	_ = r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestQuerySigningHookWeChatPay(t *testing.T) {
+	// The example of the signature algorithm of WeChat Pay.
+	const key = "192006250b4c09247ec02edce69f6a2d"
+	md5sign := func(canonical string) string {
+		sum := md5.Sum([]byte(canonical + "&key=" + key))
+		return strings.ToUpper(hex.EncodeToString(sum[:]))
+	}
+	hmacsign := func(canonical string) string {
+		h := hmac.New(sha256.New, []byte(key))
+		h.Write([]byte(canonical + "&key=" + key))
+		return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+	}
+
+	tests := []struct {
+		Sign   func(string) string
+		Expect string
+	}{
+		{md5sign, "9A0A8659F005D6984697E2CA0A9CF3B7"},
+		{hmacsign, "6A9AE1657590FD6257D693A078E1C3E4BB6BA4DC30B23E0EE2496E54170DACD6"},
+	}
+
+	client := NewClient(nil).AddQueryMap(map[string]string{
+		"appid":  "wxd930ea5d5a258f4f",
+		"mch_id": "10000100",
+	})
+
+	for i, test := range tests {
+		hook := NewQuerySigningHook("sign", test.Sign, QuerySigningOptions{SkipEmpty: true})
+		summary, err := client.Get("http://127.0.0.1/pay").
+			AddQuery("device_info", "1000").
+			AddQuery("body", "test").
+			AddQuery("nonce_str", "ibuaiVcKdpRxkhJA").
+			AddQuery("empty", "").
+			AddHook(hook).
+			DryRun(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(summary.URL, "&sign="+test.Expect) {
+			t.Errorf("%d: expect sign '%s', but got url '%s'", i, test.Expect, summary.URL)
+		}
+	}
+}
+
+func TestQuerySigningHookWithPathAndBody(t *testing.T) {
+	var canonical string
+	hook := NewQuerySigningHook("signature", func(s string) string {
+		canonical = s
+		return "sig"
+	}, QuerySigningOptions{
+		IncludeMethod: true,
+		IncludePath:   true,
+		BodyHash: func(body []byte) string {
+			sum := sha256.Sum256(body)
+			return hex.EncodeToString(sum[:])
+		},
+	})
+
+	summary, err := NewClient(nil).Post("http://127.0.0.1/v1/path").
+		AddQuery("b", "2 3").
+		AddQuery("a", "1").
+		AddQuery("signature", "old").
+		AddHook(hook).
+		SetBody("body").
+		DryRun(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256([]byte("body"))
+	expect := "POST\n/v1/path\na=1&b=2+3\n" + hex.EncodeToString(sum[:])
+	if canonical != expect {
+		t.Errorf("expect canonical '%s', but got '%s'", expect, canonical)
+	}
+
+	if expect := "http://127.0.0.1/v1/path?a=1&b=2+3&signature=sig"; summary.URL != expect {
+		t.Errorf("expect url '%s', but got '%s'", expect, summary.URL)
+	}
+
+	if summary.BodyPreview != "body" {
+		t.Errorf("the body is consumed: '%s'", summary.BodyPreview)
+	}
+}
+
+func TestQuerySigningHookKeepRawQuery(t *testing.T) {
+	var canonical string
+	hook := NewQuerySigningHook("sig", func(s string) string {
+		canonical = s
+		return "a b"
+	}, QuerySigningOptions{})
+
+	req, err := NewClient(nil).PreserveQueryOrder(true).
+		Get("http://127.0.0.1/path?sig=old&z=1").
+		SetQueryArrayStyle(QueryArrayComma).
+		AddQuery("b", "2").AddQuery("a", "x").AddQuery("a", "y").
+		AddHook(hook).
+		Build(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := "z=1&b=2&a=x,y&sig=a+b"; req.URL.RawQuery != expect {
+		t.Errorf("expect raw query '%s', but got '%s'", expect, req.URL.RawQuery)
+	}
+	if expect := "a=x%2Cy&b=2&z=1"; canonical != expect {
+		t.Errorf("expect canonical query '%s', but got '%s'", expect, canonical)
+	}
+}
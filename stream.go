@@ -0,0 +1,190 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+// +build go1.23
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"net/http"
+)
+
+var errStreamStopped = errors.New("the stream is stopped by the consumer")
+
+// Stream is a lazy sequence of the values decoded from the responses,
+// such as the NDJSON stream or the paginated list, which is produced
+// by StreamNDJSON, StreamPages or Concat.
+//
+// All the streams have the consistent semantics:
+//   - The request is not sent until the stream is iterated.
+//   - The context is checked before each value is yielded.
+//   - The error, including the context error, is yielded at most once
+//     with the zero value as the last element, which may be wrapped
+//     into Error containing the method and url by Request.Do.
+//   - The response body is always closed when the iteration stops,
+//     including stopping early by the consumer, such as break.
+//
+// A stream is intended to be iterated only once.
+type Stream[T any] iter.Seq2[T, error]
+
+// StreamNDJSON returns a stream to decode each value of type T
+// from the newline-delimited JSON response body of the request.
+//
+// The non-2xx response is yielded as the error by ReadResponseBodyAsError.
+func StreamNDJSON[T any](ctx context.Context, r *Request) Stream[T] {
+	return func(yield func(T, error) bool) {
+		var stopped bool
+		err := r.Do(ctx, func(resp *http.Response) error {
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				return ReadResponseBodyAsError(nil, resp)
+			}
+
+			dec := json.NewDecoder(resp.Body)
+			for {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				var v T
+				if err := dec.Decode(&v); err == io.EOF {
+					return nil
+				} else if err != nil {
+					return err
+				} else if !yield(v, nil) {
+					stopped = true
+					return errStreamStopped
+				}
+			}
+		}).Unwrap()
+
+		if err != nil && !stopped {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// StreamPages returns a stream to decode the response body of each page
+// as []T and yield its values one by one, which starts from the request r.
+//
+// next is called with the response, whose body has been consumed, and its
+// decoded page to return the request of the next page, such as by the header
+// "Link" or the cursor in the page. Return nil to end the stream.
+//
+// The non-2xx response is yielded as the error by ReadResponseBodyAsError.
+func StreamPages[T any](ctx context.Context, r *Request, next func(resp *http.Response, page []T) *Request) Stream[T] {
+	if next == nil {
+		panic("StreamPages: the next function must not be nil")
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+		for r != nil {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			var page []T
+			var nextr *Request
+			err := r.Do(ctx, func(resp *http.Response) error {
+				if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+					return ReadResponseBodyAsError(nil, resp)
+				} else if err := DecodeResponseBody(&page, resp); err != nil {
+					return err
+				}
+				nextr = next(resp, page)
+				return nil
+			}).Unwrap()
+
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, v := range page {
+				if err := ctx.Err(); err != nil {
+					yield(zero, err)
+					return
+				} else if !yield(v, nil) {
+					return
+				}
+			}
+			r = nextr
+		}
+	}
+}
+
+// Concat returns a stream to yield the values of the streams in turn,
+// which stops at the first error.
+func Concat[T any](streams ...Stream[T]) Stream[T] {
+	return func(yield func(T, error) bool) {
+		for _, stream := range streams {
+			for v, err := range stream {
+				if !yield(v, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Take returns a stream to yield at most n values of the stream,
+// which stops the stream and closes its response body after n values.
+func Take[T any](s Stream[T], n int) Stream[T] {
+	return func(yield func(T, error) bool) {
+		if n <= 0 {
+			return
+		}
+
+		var count int
+		for v, err := range s {
+			if !yield(v, err) || err != nil {
+				return
+			} else if count++; count >= n {
+				return
+			}
+		}
+	}
+}
+
+// Collect collects all the values of the stream, and returns the values
+// collected before the error if the stream fails.
+func Collect[T any](s Stream[T]) (values []T, err error) {
+	for v, err := range s {
+		if err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// First returns the first value of the stream and stops it,
+// which reports whether the value exists.
+func First[T any](s Stream[T]) (v T, ok bool, err error) {
+	for v, err = range s {
+		if err != nil {
+			return
+		}
+		return v, true, nil
+	}
+	return
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+// +build go1.23
+
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type streamBody struct {
+	*strings.Reader
+	closed *int32
+}
+
+func (b streamBody) Close() error { atomic.AddInt32(b.closed, 1); return nil }
+
+type streamServer struct {
+	requests int32
+	closed   int32
+}
+
+func (s *streamServer) Do(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&s.requests, 1)
+
+	code, ct, body := 200, MIMEApplicationJSON, ""
+	switch r.URL.Path {
+	case "/ndjson":
+		ct, body = "application/x-ndjson", "1\n2\n3\n4\n5\n6\n"
+	case "/pages":
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		body = fmt.Sprintf("[%d,%d]", page*2+1, page*2+2)
+	default:
+		code, body = 500, "internal error"
+	}
+
+	return &http.Response{
+		StatusCode: code,
+		Header:     http.Header{"Content-Type": []string{ct}},
+		Body:       streamBody{Reader: strings.NewReader(body), closed: &s.closed},
+		Request:    r,
+	}, nil
+}
+
+func (s *streamServer) checkClosed(t *testing.T) {
+	t.Helper()
+	if requests, closed := atomic.LoadInt32(&s.requests), atomic.LoadInt32(&s.closed); requests != closed {
+		t.Errorf("expect %d closed bodies, but got %d", requests, closed)
+	}
+}
+
+var streamFixtures = []struct {
+	Name   string
+	Stream func(ctx context.Context, c *Client, path string) Stream[int]
+}{
+	{
+		Name: "NDJSON",
+		Stream: func(ctx context.Context, c *Client, path string) Stream[int] {
+			return StreamNDJSON[int](ctx, c.Get("http://localhost/ndjson"+path))
+		},
+	},
+	{
+		Name: "Pages",
+		Stream: func(ctx context.Context, c *Client, path string) Stream[int] {
+			next := func(resp *http.Response, page []int) *Request {
+				n, _ := strconv.Atoi(resp.Request.URL.Query().Get("page"))
+				if n++; n >= 3 {
+					return nil
+				}
+				return c.Get("http://localhost/pages"+path).AddQuery("page", strconv.Itoa(n))
+			}
+			return StreamPages(ctx, c.Get("http://localhost/pages"+path).AddQuery("page", "0"), next)
+		},
+	},
+}
+
+func TestStreamConformance(t *testing.T) {
+	for _, fixture := range streamFixtures {
+		t.Run(fixture.Name, func(t *testing.T) {
+			newStream := func(ctx context.Context, path string) (*streamServer, Stream[int]) {
+				server := new(streamServer)
+				client := NewClient(nil).OnResponse(nil).SetDoer(server)
+				return server, fixture.Stream(ctx, client, path)
+			}
+
+			server, stream := newStream(context.Background(), "")
+			if n := atomic.LoadInt32(&server.requests); n != 0 {
+				t.Errorf("expect no request before iterating, but got %d", n)
+			}
+			if values, err := Collect(stream); err != nil {
+				t.Errorf("Collect: unexpected error: %v", err)
+			} else if expect := []int{1, 2, 3, 4, 5, 6}; !reflect.DeepEqual(values, expect) {
+				t.Errorf("Collect: expect %v, but got %v", expect, values)
+			}
+			server.checkClosed(t)
+
+			server, stream = newStream(context.Background(), "")
+			if values, err := Collect(Take(stream, 3)); err != nil {
+				t.Errorf("Take: unexpected error: %v", err)
+			} else if expect := []int{1, 2, 3}; !reflect.DeepEqual(values, expect) {
+				t.Errorf("Take: expect %v, but got %v", expect, values)
+			}
+			server.checkClosed(t)
+
+			server, stream = newStream(context.Background(), "")
+			if v, ok, err := First(stream); err != nil {
+				t.Errorf("First: unexpected error: %v", err)
+			} else if !ok || v != 1 {
+				t.Errorf("First: expect %v, but got %v", 1, v)
+			}
+			server.checkClosed(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			server, stream = newStream(ctx, "")
+			var values []int
+			var errs []error
+			for v, err := range stream {
+				if err != nil {
+					errs = append(errs, err)
+				} else {
+					values = append(values, v)
+					cancel()
+				}
+			}
+			if expect := []int{1}; !reflect.DeepEqual(values, expect) {
+				t.Errorf("Cancel: expect %v, but got %v", expect, values)
+			}
+			if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+				t.Errorf("Cancel: expect the error %v, but got %v", context.Canceled, errs)
+			}
+			server.checkClosed(t)
+
+			server, stream = newStream(context.Background(), "/fail")
+			errs = errs[:0]
+			for _, err := range stream {
+				errs = append(errs, err)
+			}
+			if len(errs) != 1 {
+				t.Errorf("Fail: expect one error, but got %v", errs)
+			} else if e, ok := errs[0].(Error); !ok || e.Code != 500 || e.Method != http.MethodGet {
+				t.Errorf("Fail: expect an Error with the code 500, but got %v", errs[0])
+			}
+			server.checkClosed(t)
+		})
+	}
+}
+
+func TestStreamConcat(t *testing.T) {
+	server := new(streamServer)
+	client := NewClient(nil).OnResponse(nil).SetDoer(server)
+
+	first := StreamPages(context.Background(), client.Get("http://localhost/pages?page=0"),
+		func(*http.Response, []int) *Request { return nil })
+	rest := StreamNDJSON[int](context.Background(), client.Get("http://localhost/ndjson"))
+
+	values, err := Collect(Take(Concat(first, rest), 4))
+	if err != nil {
+		t.Fatal(err)
+	} else if expect := []int{1, 2, 1, 2}; !reflect.DeepEqual(values, expect) {
+		t.Errorf("expect %v, but got %v", expect, values)
+	}
+	server.checkClosed(t)
+
+	if n := atomic.LoadInt32(&server.requests); n != 2 {
+		t.Errorf("expect %d requests, but got %d", 2, n)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// ResponseOptions is the options to construct a Response by NewTestResponse.
+type ResponseOptions struct {
+	// Method and URL are the original request method and url.
+	//
+	// If empty, they are derived from Request if set.
+	Method string
+	URL    string
+
+	// Request is the sent http request, which may be nil.
+	Request *http.Request
+
+	// ReqBody is the original request body, which may be nil.
+	ReqBody interface{}
+
+	// If StatusCode is equal to 0, no http response will be constructed,
+	// which is equal to that the request failed to be sent.
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	Cost time.Duration
+	Err  error
+}
+
+// NewTestResponse returns a new Response constructed from the recorded data
+// without sending the request, which is used to test the OnResponse callbacks,
+// the response handlers, and the code that consumes *Response.
+func NewTestResponse(opts ResponseOptions) *Response {
+	resp := &Response{
+		err:   opts.Err,
+		url:   opts.URL,
+		mhd:   opts.Method,
+		req:   opts.Request,
+		cost:  opts.Cost,
+		rbody: opts.ReqBody,
+	}
+
+	if opts.Request != nil {
+		if resp.mhd == "" {
+			resp.mhd = opts.Request.Method
+		}
+		if resp.url == "" && opts.Request.URL != nil {
+			resp.url = opts.Request.URL.String()
+		}
+	}
+
+	if opts.StatusCode > 0 {
+		header := opts.Header
+		if header == nil {
+			header = make(http.Header)
+		}
+
+		resp.resp = &http.Response{
+			Status:        fmt.Sprintf("%d %s", opts.StatusCode, http.StatusText(opts.StatusCode)),
+			StatusCode:    opts.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(opts.Body)),
+			ContentLength: int64(len(opts.Body)),
+			Request:       opts.Request,
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewTestResponse(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://127.0.0.1/path", nil)
+	resp := NewTestResponse(ResponseOptions{
+		Request:    req,
+		StatusCode: 200,
+		Header:     http.Header{HeaderContentType: []string{MIMEApplicationJSON}},
+		Body:       []byte(`{"name":"xgfone"}`),
+		Cost:       time.Second,
+	})
+
+	if resp.Method() != http.MethodGet || resp.Url() != "http://127.0.0.1/path" {
+		t.Errorf("unexpected method '%s' and url '%s'", resp.Method(), resp.Url())
+	}
+	if resp.StatusCode() != 200 || resp.Cost() != time.Second {
+		t.Errorf("unexpected status code %d and cost %s", resp.StatusCode(), resp.Cost())
+	}
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	if err := DecodeResponseBody(&result, resp.Response()); err != nil {
+		t.Error(err)
+	} else if result.Name != "xgfone" {
+		t.Errorf("expect name '%s', but got '%s'", "xgfone", result.Name)
+	}
+
+	resp = NewTestResponse(ResponseOptions{
+		Method: http.MethodPost,
+		URL:    "http://127.0.0.1/path",
+		Err:    errors.New("test"),
+	})
+	if code := resp.StatusCode(); code != 0 {
+		t.Errorf("expect status code 0, but got %d", code)
+	}
+	if err := resp.Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %T", err)
+	} else if e.Method != http.MethodPost {
+		t.Errorf("expect method '%s', but got '%s'", http.MethodPost, e.Method)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MaxTolerantBodySize is the maximum size of the response body
+// buffered to be normalized by the tolerance rules.
+const MaxTolerantBodySize = 16 << 20
+
+// ToleranceRule is used to normalize the json value decoded from
+// the response body before decoding it into the destination.
+//
+// value is one of nil, bool, json.Number, string, []interface{}
+// and map[string]interface{}, and typ is the type of the destination
+// corresponding to the value, which may be nil if unknown,
+// such as the value of interface{}. root reports whether the value
+// is the top-level json value.
+//
+// It is called for the value before its children.
+type ToleranceRule func(value interface{}, typ reflect.Type, root bool) interface{}
+
+// EmptyArrayAsNull is a tolerance rule to treat the top-level empty array
+// as null when the destination is not a slice or an array,
+// so the destination is left unchanged.
+func EmptyArrayAsNull(value interface{}, typ reflect.Type, root bool) interface{} {
+	if !root || typ == nil {
+		return value
+	}
+
+	if array, ok := value.([]interface{}); ok && len(array) == 0 {
+		switch typ.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Interface:
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// StringNumbers is a tolerance rule to accept the numbers quoted as strings,
+// such as "123", into the numeric destinations.
+func StringNumbers(value interface{}, typ reflect.Type, root bool) interface{} {
+	s, ok := value.(string)
+	if !ok || typ == nil {
+		return value
+	}
+
+	s = strings.TrimSpace(s)
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return json.Number(s)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return json.Number(s)
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return json.Number(s)
+		}
+	}
+	return value
+}
+
+// NullAsZero is a tolerance rule to treat null as the zero value
+// of the boolean, numeric and string destinations, so they are reset
+// to zero instead of being left unchanged.
+func NullAsZero(value interface{}, typ reflect.Type, root bool) interface{} {
+	if value != nil || typ == nil {
+		return value
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return false
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr, reflect.Float32, reflect.Float64:
+		return json.Number("0")
+	case reflect.String:
+		return ""
+	default:
+		return value
+	}
+}
+
+type toleranceKey struct{}
+
+// DecodeTolerant sets the tolerance rules to normalize the json response body
+// before decoding it into the result, which is buffered in memory
+// and must not be greater than MaxTolerantBodySize.
+//
+// It only takes effect for the response body decoded as json
+// by the default response handler DecodeResponseBody.
+//
+// Default: nil
+func (r *Request) DecodeTolerant(rules ...ToleranceRule) *Request {
+	for _, rule := range rules {
+		if rule == nil {
+			panic("Request.DecodeTolerant: the tolerance rule must not be nil")
+		}
+	}
+	r.tolerance = append([]ToleranceRule(nil), rules...)
+	return r
+}
+
+// decodeTolerant reads the json data from r, normalizes it by the rules,
+// and decodes it into dst.
+func decodeTolerant(dst interface{}, r io.Reader, rules []ToleranceRule) error {
+	data, err := ioutil.ReadAll(io.LimitReader(r, MaxTolerantBodySize+1))
+	if err != nil {
+		return err
+	} else if len(data) > MaxTolerantBodySize {
+		return fmt.Errorf("the response body exceeds the tolerant limit %d", MaxTolerantBodySize)
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&value); err != nil {
+		return wrapDecodeError(MIMEApplicationJSON, err)
+	}
+
+	value = normalizeJSON(value, reflect.TypeOf(dst), true, rules)
+	if data, err = json.Marshal(value); err != nil {
+		return wrapDecodeError(MIMEApplicationJSON, err)
+	}
+	return wrapDecodeError(MIMEApplicationJSON, json.Unmarshal(data, dst))
+}
+
+func normalizeJSON(value interface{}, typ reflect.Type, root bool, rules []ToleranceRule) interface{} {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	// The type is unknown for interface{}, and the type implementing
+	// json.Unmarshaler decodes the value by itself.
+	if typ != nil && (typ.Kind() == reflect.Interface ||
+		reflect.PtrTo(typ).Implements(unmarshalerType)) {
+		typ = nil
+	}
+
+	for _, rule := range rules {
+		value = rule(value, typ, root)
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		var elem reflect.Type
+		if typ != nil && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+			elem = typ.Elem()
+		}
+		for i := range v {
+			v[i] = normalizeJSON(v[i], elem, false, rules)
+		}
+
+	case map[string]interface{}:
+		var fields map[string]reflect.Type
+		var elem reflect.Type
+		if typ != nil {
+			switch typ.Kind() {
+			case reflect.Map:
+				elem = typ.Elem()
+			case reflect.Struct:
+				fields = make(map[string]reflect.Type, typ.NumField())
+				collectJSONFields(fields, typ)
+			}
+		}
+
+		for key, value := range v {
+			_elem := elem
+			if fields != nil {
+				_elem = lookupJSONField(fields, key)
+			}
+			v[key] = normalizeJSON(value, _elem, false, rules)
+		}
+	}
+
+	return value
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// collectJSONFields collects the json names and types of the exported fields
+// of the struct, including the fields of the embedded structs.
+func collectJSONFields(fields map[string]reflect.Type, typ reflect.Type) {
+	for i, _len := 0, typ.NumField(); i < _len; i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := tag
+		if index := strings.IndexByte(tag, ','); index >= 0 {
+			name = tag[:index]
+		}
+
+		ftype := field.Type
+		if field.Anonymous && name == "" {
+			if ftype.Kind() == reflect.Ptr {
+				ftype = ftype.Elem()
+			}
+			if ftype.Kind() == reflect.Struct {
+				collectJSONFields(fields, ftype)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" { // Unexported
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if _, exist := fields[name]; !exist {
+			fields[name] = field.Type
+		}
+	}
+}
+
+// lookupJSONField looks up the field type by the json key,
+// which prefers the exact match to the case-insensitive match,
+// like encoding/json.
+func lookupJSONField(fields map[string]reflect.Type, key string) reflect.Type {
+	if typ, ok := fields[key]; ok {
+		return typ
+	}
+
+	for name, typ := range fields {
+		if strings.EqualFold(name, key) {
+			return typ
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestEmptyArrayAsNull(t *testing.T) {
+	structType := reflect.TypeOf(struct{}{})
+	sliceType := reflect.TypeOf([]int(nil))
+	empty := []interface{}{}
+
+	if v := EmptyArrayAsNull(empty, structType, true); v != nil {
+		t.Errorf("expect nil, but got %v", v)
+	}
+	if v := EmptyArrayAsNull(empty, structType, false); v == nil {
+		t.Errorf("expect the empty array for the non-root, but got nil")
+	}
+	if v := EmptyArrayAsNull(empty, sliceType, true); v == nil {
+		t.Errorf("expect the empty array for the slice, but got nil")
+	}
+	if v := EmptyArrayAsNull([]interface{}{1}, structType, true); v == nil {
+		t.Errorf("expect the non-empty array, but got nil")
+	}
+}
+
+func TestStringNumbers(t *testing.T) {
+	intType := reflect.TypeOf(0)
+	if v := StringNumbers("123", intType, false); v != json.Number("123") {
+		t.Errorf("expect number 123, but got %v", v)
+	}
+	if v := StringNumbers("1.5", intType, false); v != "1.5" {
+		t.Errorf("expect string '1.5', but got %v", v)
+	}
+	if v := StringNumbers("1.5", reflect.TypeOf(0.0), false); v != json.Number("1.5") {
+		t.Errorf("expect number 1.5, but got %v", v)
+	}
+	if v := StringNumbers("-1", reflect.TypeOf(uint(0)), false); v != "-1" {
+		t.Errorf("expect string '-1', but got %v", v)
+	}
+	if v := StringNumbers("123", reflect.TypeOf(""), false); v != "123" {
+		t.Errorf("expect string '123', but got %v", v)
+	}
+}
+
+func TestNullAsZero(t *testing.T) {
+	if v := NullAsZero(nil, reflect.TypeOf(0), false); v != json.Number("0") {
+		t.Errorf("expect number 0, but got %v", v)
+	}
+	if v := NullAsZero(nil, reflect.TypeOf(""), false); v != "" {
+		t.Errorf("expect an empty string, but got %v", v)
+	}
+	if v := NullAsZero(nil, reflect.TypeOf(false), false); v != false {
+		t.Errorf("expect false, but got %v", v)
+	}
+	if v := NullAsZero(nil, reflect.TypeOf([]int(nil)), false); v != nil {
+		t.Errorf("expect nil for the slice, but got %v", v)
+	}
+	if v := NullAsZero(nil, nil, false); v != nil {
+		t.Errorf("expect nil for the unknown type, but got %v", v)
+	}
+}
+
+func TestRequestDecodeTolerant(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	type Item struct {
+		ID    int64   `json:"id"`
+		Price float64 `json:"price"`
+		Name  string
+	}
+	type Result struct {
+		Items []Item          `json:"items"`
+		Total uint            `json:"total"`
+		Extra json.RawMessage `json:"extra"`
+		Other interface{}     `json:"other"`
+	}
+
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	rules := []ToleranceRule{EmptyArrayAsNull, StringNumbers, NullAsZero}
+
+	body = `[]`
+	var result Result
+	err := client.Get(server.URL).DecodeTolerant(rules...).Do(context.Background(), &result).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(result, Result{}) {
+		t.Errorf("expect the zero result, but got %+v", result)
+	}
+
+	body = `{"items":[{"id":"1","price":"1.5","name":null}],"total":"1","extra":"2","other":"3"}`
+	result = Result{Items: []Item{{Name: "abc"}}}
+	err = client.Get(server.URL).DecodeTolerant(rules...).Do(context.Background(), &result).Unwrap()
+	if err != nil {
+		t.Error(err)
+	} else if len(result.Items) != 1 || result.Items[0] != (Item{ID: 1, Price: 1.5}) {
+		t.Errorf("unexpected items: %+v", result.Items)
+	} else if result.Total != 1 {
+		t.Errorf("expect total %d, but got %d", 1, result.Total)
+	} else if string(result.Extra) != `"2"` {
+		t.Errorf("expect extra '%s', but got '%s'", `"2"`, result.Extra)
+	} else if result.Other != "3" {
+		t.Errorf("expect other '%s', but got '%v'", "3", result.Other)
+	}
+
+	// No tolerance
+	body = `[]`
+	err = client.Get(server.URL).Do(context.Background(), &result).Unwrap()
+	if err == nil {
+		t.Errorf("expect a decode error, but got nil")
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type downloadOptions struct {
+	progress func(written, total int64)
+	checksum hash.Hash
+	expected string
+}
+
+// DownloadOption is used to configure the download.
+type DownloadOption func(*downloadOptions)
+
+// DownloadProgress returns a download option to report the progress,
+// which is called after each chunk of the data is written.
+//
+// total is the length of the response body, which is -1 if unknown.
+func DownloadProgress(f func(written, total int64)) DownloadOption {
+	return func(o *downloadOptions) { o.progress = f }
+}
+
+// DownloadChecksum returns a download option to verify the checksum
+// of the downloaded data, which is the hex-encoded sum of h.
+//
+// If the checksum does not match, the downloaded file will be removed.
+func DownloadChecksum(h hash.Hash, expected string) DownloadOption {
+	return func(o *downloadOptions) { o.checksum, o.expected = h, expected }
+}
+
+type progressWriter struct {
+	progress func(written, total int64)
+	written  int64
+	total    int64
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	w.progress(w.written, w.total)
+	return len(p), nil
+}
+
+// Download downloads the data from the url and saves it into the file destPath.
+//
+// The data is written into a temporary file in the same directory first,
+// which will be renamed to destPath only when the download succeeds.
+func (c *Client) Download(ctx context.Context, url, destPath string, options ...DownloadOption) error {
+	var opts downloadOptions
+	for _, o := range options {
+		o(&opts)
+	}
+
+	return c.Get(url).Do(ctx, func(resp *http.Response) error {
+		if resp.StatusCode >= 300 {
+			return ReadResponseBodyAsError(nil, resp)
+		}
+		return download(resp, destPath, opts)
+	}).Unwrap()
+}
+
+// createTempFile creates a new temporary file in dir like ioutil.TempFile,
+// but with the mode 0666 before umask like os.Create instead of 0600,
+// so that the file renamed from it has the same mode as created by os.Create.
+func createTempFile(dir, prefix string) (file *os.File, err error) {
+	for i := 0; i < 10000; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		file, err = os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if !os.IsExist(err) {
+			return
+		}
+	}
+	return
+}
+
+func download(resp *http.Response, destPath string, opts downloadOptions) (err error) {
+	dir, name := filepath.Split(destPath)
+	if dir == "" {
+		dir = "."
+	}
+
+	file, err := createTempFile(dir, "."+name+".download")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	writers := make([]io.Writer, 1, 3)
+	writers[0] = file
+	if opts.checksum != nil {
+		opts.checksum.Reset()
+		writers = append(writers, opts.checksum)
+	}
+	if opts.progress != nil {
+		writers = append(writers, &progressWriter{progress: opts.progress, total: resp.ContentLength})
+	}
+
+	var w io.Writer = file
+	if len(writers) > 1 {
+		w = io.MultiWriter(writers...)
+	}
+
+	if _, err = io.CopyBuffer(w, resp.Body, make([]byte, 32*1024)); err != nil {
+		return
+	}
+
+	if opts.checksum != nil {
+		sum := hex.EncodeToString(opts.checksum.Sum(nil))
+		if !strings.EqualFold(sum, opts.expected) {
+			return fmt.Errorf("checksum mismatch: expect '%s', but got '%s'", opts.expected, sum)
+		}
+	}
+
+	if err = file.Close(); err != nil {
+		return
+	}
+	return os.Rename(file.Name(), destPath)
+}
+
+// UploadFile uploads the file filePath to the url by the method POST
+// as the multipart form field named fieldName, with the extra form fields,
+// and decodes the response body into result by the response handlers.
+//
+// The multipart body is streamed instead of being buffered into the memory.
+func (c *Client) UploadFile(ctx context.Context, url, fieldName, filePath string,
+	extraFields map[string]string, result interface{}) error {
+	if _, err := os.Stat(filePath); err != nil {
+		return NewError(0, http.MethodPost, url, err)
+	}
+
+	body := NewMultipart()
+	for key, value := range extraFields {
+		body.AddField(key, value)
+	}
+	body.AddFile(fieldName, filePath, "")
+
+	return c.Post(url).SetBody(body).Do(ctx, result).Unwrap()
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClientDownload(t *testing.T) {
+	data := []byte("download data")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/notfound" {
+			w.WriteHeader(404)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "httpclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sum := sha256.Sum256(data)
+	expected := hex.EncodeToString(sum[:])
+
+	var written int64
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	path := filepath.Join(dir, "file")
+	err = client.Download(context.Background(), server.URL, path,
+		DownloadProgress(func(n, total int64) { written = n }),
+		DownloadChecksum(sha256.New(), expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if written != int64(len(data)) {
+		t.Errorf("expect written %d, but got %d", len(data), written)
+	}
+	if got, err := ioutil.ReadFile(path); err != nil {
+		t.Error(err)
+	} else if string(got) != string(data) {
+		t.Errorf("expect data '%s', but got '%s'", data, got)
+	}
+
+	// The file has the same mode as created by os.Create.
+	if file, err := os.Create(filepath.Join(dir, "create")); err != nil {
+		t.Error(err)
+	} else {
+		file.Close()
+		expect, _ := os.Stat(file.Name())
+		if got, err := os.Stat(path); err != nil {
+			t.Error(err)
+		} else if got.Mode() != expect.Mode() {
+			t.Errorf("expect the file mode '%s', but got '%s'", expect.Mode(), got.Mode())
+		}
+		os.Remove(file.Name())
+	}
+
+	path = filepath.Join(dir, "badsum")
+	err = client.Download(context.Background(), server.URL, path,
+		DownloadChecksum(sha256.New(), "abc"))
+	if err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expect the file is not created, but got %v", err)
+	}
+
+	err = client.Download(context.Background(), server.URL+"/notfound", filepath.Join(dir, "notfound"))
+	if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %v", err)
+	} else if e.Code != 404 || e.Method != http.MethodGet {
+		t.Errorf("unexpected error: %v", e)
+	}
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("expect only 1 file, but got %d", len(files))
+	}
+}
+
+func TestClientUploadFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(400)
+			return
+		}
+		defer file.Close()
+
+		data, _ := ioutil.ReadAll(file)
+		w.Header().Set("Content-Type", MIMEApplicationJSON)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"name":  header.Filename,
+			"data":  string(data),
+			"extra": r.FormValue("key"),
+		})
+	}))
+	defer server.Close()
+
+	file, err := ioutil.TempFile("", "httpclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	_, _ = file.WriteString("upload data")
+	file.Close()
+
+	var result map[string]string
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	err = client.UploadFile(context.Background(), server.URL, "file", file.Name(),
+		map[string]string{"key": "value"}, &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result["name"] != filepath.Base(file.Name()) {
+		t.Errorf("unexpected file name '%s'", result["name"])
+	}
+	if result["data"] != "upload data" {
+		t.Errorf("unexpected file data '%s'", result["data"])
+	}
+	if result["extra"] != "value" {
+		t.Errorf("unexpected extra field '%s'", result["extra"])
+	}
+
+	err = client.UploadFile(context.Background(), server.URL, "file", file.Name()+".notexist", nil, nil)
+	if e, ok := err.(Error); !ok {
+		t.Errorf("expect an Error, but got %v", err)
+	} else if e.Method != http.MethodPost || e.URL != server.URL {
+		t.Errorf("unexpected error: %v", e)
+	}
+}
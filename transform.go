@@ -0,0 +1,39 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// ReplaceResponseBody closes the original response body and replaces it
+// with the new body, which also fixes the Content-Length header
+// and sets the Content-Type header if contentType is not empty.
+func ReplaceResponseBody(resp *http.Response, body []byte, contentType string) {
+	_ = CloseBody(resp.Body)
+
+	resp.Body = newBufferedBody(body)
+	resp.ContentLength = int64(len(body))
+	resp.Uncompressed = false
+	if resp.Header == nil {
+		resp.Header = make(http.Header, 2)
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	if contentType != "" {
+		resp.Header.Set(HeaderContentType, contentType)
+	}
+}
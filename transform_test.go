@@ -0,0 +1,96 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// base64JSONTransformer unwraps the envelope {"payload": "<base64 json>"}.
+func base64JSONTransformer(resp *http.Response) (*http.Response, error) {
+	if GetContentType(resp.Header) != "application/vnd.envelope+json" {
+		return resp, nil
+	}
+
+	var envelope struct {
+		Payload string `json:"payload"`
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	} else if err = json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+
+	data, err = base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, errors.New("invalid envelope payload")
+	}
+
+	ReplaceResponseBody(resp, data, MIMEApplicationJSON)
+	return resp, nil
+}
+
+func TestClientResponseTransformer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := r.URL.Query().Get("payload")
+		if payload == "" {
+			payload = base64.StdEncoding.EncodeToString([]byte(`{"name":"xgfone"}`))
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.envelope+json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"payload": payload})
+	}))
+	defer server.Close()
+
+	var called bool
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		AddResponseTransformer(base64JSONTransformer).
+		AddResponseTransformer(func(resp *http.Response) (*http.Response, error) {
+			called = true
+			if resp.ContentLength != 17 || GetContentType(resp.Header) != MIMEApplicationJSON {
+				t.Errorf("unexpected response: %d, %s", resp.ContentLength, resp.Header)
+			}
+			return nil, nil
+		})
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	if err := client.Get(server.URL).Do(context.Background(), &result).Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if result.Name != "xgfone" {
+		t.Errorf("expect name '%s', but got '%s'", "xgfone", result.Name)
+	} else if !called {
+		t.Errorf("the second response transformer is not called")
+	}
+
+	err := client.Get(server.URL).AddQuery("payload", "#").Do(context.Background(), &result).Unwrap()
+	if err == nil || err.(Error).Unwrap().Error() != "invalid envelope payload" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if n := client.InFlight(); n != 0 {
+		t.Errorf("expect no in-flight requests, but got %d", n)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// UploadInfo is the diagnostics of sending the request body,
+// which is used to find out how far the upload went when it fails,
+// such as the connection is reset by the server mid-upload.
+type UploadInfo struct {
+	// Written is the number of the bytes of the request body
+	// which have been read by the transport to be written.
+	Written int64 `json:"written" xml:"written"`
+
+	// Length is the length of the request body, which is -1 if unknown.
+	Length int64 `json:"length" xml:"length"`
+
+	// GotResponse reports whether the response, including the 1xx response,
+	// has started to be received before the request fails.
+	GotResponse bool `json:"got_response" xml:"got_response"`
+
+	// StatusCode is the status code of the response if received, or 0.
+	StatusCode int `json:"status_code,omitempty" xml:"status_code,omitempty"`
+}
+
+func (i UploadInfo) String() string {
+	length := "unknown"
+	if i.Length >= 0 {
+		length = fmt.Sprint(i.Length)
+	}
+
+	s := fmt.Sprintf("wrote %d/%s bytes of the request body", i.Written, length)
+	switch {
+	case i.StatusCode > 0:
+		s = fmt.Sprintf("%s, got status code %d", s, i.StatusCode)
+	case i.GotResponse:
+		s += ", got the partial response"
+	default:
+		s += ", got no response"
+	}
+	return s
+}
+
+// UploadError is the error occurring when the request body is uploaded
+// partially, such as the server rejects it mid-upload.
+type UploadError struct {
+	UploadInfo
+	Err error
+}
+
+func (e UploadError) Unwrap() error { return e.Err }
+func (e UploadError) Error() string {
+	return fmt.Sprintf("upload interrupted: %s: %s", e.UploadInfo.String(), e.Err.Error())
+}
+
+// Upload returns the diagnostics of sending the request body.
+func (r *Response) Upload() UploadInfo { return r.upload }
+
+// countBody counts the bytes read from the request body.
+type countBody struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (b countBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	atomic.AddInt64(b.n, int64(n))
+	return
+}
+
+// countRequestBody wraps the request body to count the bytes read from it
+// by the phase tracker carried by the context.
+func countRequestBody(c context.Context, req *http.Request) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	if tracker, ok := c.Value(phaseTrackerKey{}).(*phaseTracker); ok {
+		atomic.StoreInt64(&tracker.written, 0)
+		req.Body = countBody{ReadCloser: req.Body, n: &tracker.written}
+	}
+}
+
+// setUpload records the upload diagnostics of the sent request,
+// and wraps the error if the request body is uploaded partially.
+func (resp *Response) setUpload(tracker *phaseTracker) {
+	if resp.req == nil || resp.req.Body == nil || resp.req.Body == http.NoBody {
+		return
+	}
+
+	resp.upload = UploadInfo{
+		Written:     atomic.LoadInt64(&tracker.written),
+		Length:      resp.req.ContentLength,
+		GotResponse: atomic.LoadInt32(&tracker.gotresp) == 1,
+	}
+
+	if resp.upload.Length == 0 {
+		resp.upload.Length = -1
+	}
+	if resp.resp != nil {
+		resp.upload.GotResponse = true
+		resp.upload.StatusCode = resp.resp.StatusCode
+	}
+
+	if resp.err != nil && resp.phase == PhaseWritingRequest {
+		resp.err = UploadError{UploadInfo: resp.upload, Err: resp.err}
+	}
+}
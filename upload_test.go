@@ -0,0 +1,119 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseUploadReset(t *testing.T) {
+	const total = 16 << 20
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.CopyN(ioutil.Discard, r.Body, total/2)
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			_ = tcp.SetLinger(0) // Reset the connection instead of FIN.
+		}
+		_ = conn.Close()
+	}))
+	defer server.Close()
+
+	body := bytes.NewReader(make([]byte, total))
+	resp := NewClient(http.DefaultClient).OnResponse(nil).Put(server.URL).
+		SetContentType("application/octet-stream").SetBody(body).
+		Do(context.Background(), nil)
+
+	err := resp.Result()
+	if err == nil {
+		t.Fatal("expect an error, but got nil")
+	}
+
+	info := resp.Upload()
+	if info.Written <= 0 || info.Written >= total {
+		t.Errorf("expect a partial upload, but got %d/%d bytes", info.Written, total)
+	}
+	if info.Length != total {
+		t.Errorf("expect length %d, but got %d", total, info.Length)
+	}
+	if info.GotResponse || info.StatusCode != 0 {
+		t.Errorf("expect no response, but got %+v", info)
+	}
+
+	// The transport may notice the reset when writing the body
+	// or waiting for the response headers.
+	switch phase := resp.FailurePhase(); phase {
+	case PhaseWritingRequest:
+		var uerr UploadError
+		if e, ok := err.(Error); !ok {
+			t.Errorf("expect Error, but got %T", err)
+		} else if uerr, ok = e.Err.(UploadError); !ok {
+			t.Errorf("expect UploadError, but got %T", e.Err)
+		} else if uerr.Written != info.Written {
+			t.Errorf("expect written %d, but got %d", info.Written, uerr.Written)
+		}
+	case PhaseWaitingHeaders:
+	default:
+		t.Errorf("unexpected failure phase '%s'", phase)
+	}
+}
+
+func TestResponseUploadSuccess(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(ioutil.Discard, r.Body)
+		w.WriteHeader(201)
+	}))
+	defer server.Close()
+
+	resp := NewClient(http.DefaultClient).OnResponse(nil).Post(server.URL).
+		SetBody("abcdef").Do(context.Background(), nil)
+	if err := resp.Result(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := UploadInfo{Written: 6, Length: 6, GotResponse: true, StatusCode: 201}
+	if info := resp.Upload(); info != expect {
+		t.Errorf("expect %+v, but got %+v", expect, info)
+	}
+
+	resp = NewClient(http.DefaultClient).OnResponse(nil).Get(server.URL).Do(context.Background(), nil)
+	if info := resp.Upload(); info != (UploadInfo{}) {
+		t.Errorf("expect no upload info, but got %+v", info)
+	}
+}
+
+func TestUploadInfoString(t *testing.T) {
+	info := UploadInfo{Written: 3, Length: -1}
+	if s, expect := info.String(), "wrote 3/unknown bytes of the request body, got no response"; s != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, s)
+	}
+
+	info = UploadInfo{Written: 3, Length: 10, GotResponse: true, StatusCode: 417}
+	if s, expect := info.String(), "wrote 3/10 bytes of the request body, got status code 417"; s != expect {
+		t.Errorf("expect '%s', but got '%s'", expect, s)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import "strings"
+
+// DefaultUserAgent is the default User-Agent set by NewClient, such as
+// "go-http-client/0.1.0 (+https://github.com/xgfone/go-http-client)",
+// whose version is that of the module in the build information,
+// or "devel" if unknown.
+//
+// If empty, NewClient does not set the User-Agent, and net/http
+// sends its own one.
+var DefaultUserAgent = "go-http-client/" + moduleVersion() +
+	" (+https://github.com/xgfone/go-http-client)"
+
+const modulePath = "github.com/xgfone/go-http-client"
+
+func formatModuleVersion(version string) string {
+	if version == "" || version == "(devel)" {
+		return "devel"
+	}
+	return strings.TrimPrefix(version, "v")
+}
+
+// SetUserAgent sets the default User-Agent of the requests,
+// which is equal to SetHeader("User-Agent", ua).
+//
+// If ua is empty, the header User-Agent is not sent, instead of
+// the default one of net/http. But the doer still sees the empty value.
+//
+// Default: DefaultUserAgent
+func (c *Client) SetUserAgent(ua string) *Client {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.header[HeaderUserAgent] = []string{ua}
+	return c
+}
+
+// SetUserAgent overrides the User-Agent of the client.
+//
+// See Client.SetUserAgent.
+func (r *Request) SetUserAgent(ua string) *Request {
+	r.cloneHeader()
+	r.header[HeaderUserAgent] = []string{ua}
+	return r
+}
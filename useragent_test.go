@@ -0,0 +1,95 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUserAgent(t *testing.T) {
+	var ua string
+	var hasUA bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasUA = r.Header[HeaderUserAgent]
+		ua = r.UserAgent()
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	do := func(req *Request) {
+		if err := req.Do(context.Background(), nil).Unwrap(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !strings.HasPrefix(DefaultUserAgent, "go-http-client/") ||
+		!strings.HasSuffix(DefaultUserAgent, " (+https://github.com/xgfone/go-http-client)") {
+		t.Errorf("unexpected default User-Agent '%s'", DefaultUserAgent)
+	}
+
+	// Default
+	client := NewClient(http.DefaultClient).OnResponse(nil)
+	if do(client.Get(server.URL)); ua != DefaultUserAgent {
+		t.Errorf("expect the User-Agent '%s', but got '%s'", DefaultUserAgent, ua)
+	}
+
+	// Override
+	if do(client.Get(server.URL).SetUserAgent("request/1.0")); ua != "request/1.0" {
+		t.Errorf("expect the User-Agent '%s', but got '%s'", "request/1.0", ua)
+	}
+	if do(client.SetUserAgent("client/1.0").Get(server.URL)); ua != "client/1.0" {
+		t.Errorf("expect the User-Agent '%s', but got '%s'", "client/1.0", ua)
+	}
+
+	// Suppress
+	if do(client.Get(server.URL).SetUserAgent("")); hasUA {
+		t.Errorf("expect no User-Agent, but got '%s'", ua)
+	}
+	if do(client.SetUserAgent("").Get(server.URL)); hasUA {
+		t.Errorf("expect no User-Agent, but got '%s'", ua)
+	}
+
+	// The request override does not change the client.
+	if do(client.Get(server.URL).SetUserAgent("request/1.0")); ua != "request/1.0" {
+		t.Errorf("expect the User-Agent '%s', but got '%s'", "request/1.0", ua)
+	}
+	if do(client.Get(server.URL)); hasUA {
+		t.Errorf("expect no User-Agent, but got '%s'", ua)
+	}
+
+	// Not set the default User-Agent, and use the one of net/http.
+	defer func(ua string) { DefaultUserAgent = ua }(DefaultUserAgent)
+	DefaultUserAgent = ""
+	if do(NewClient(http.DefaultClient).OnResponse(nil).Get(server.URL)); !strings.HasPrefix(ua, "Go-http-client/") {
+		t.Errorf("expect the User-Agent of net/http, but got '%s'", ua)
+	}
+}
+
+func TestFormatModuleVersion(t *testing.T) {
+	for version, expect := range map[string]string{
+		"":                                   "devel",
+		"(devel)":                            "devel",
+		"v1.2.3":                             "1.2.3",
+		"v0.0.0-20240102030405-abcdefabcdef": "0.0.0-20240102030405-abcdefabcdef",
+	} {
+		if s := formatModuleVersion(version); s != expect {
+			t.Errorf("%s: expect '%s', but got '%s'", version, expect, s)
+		}
+	}
+}
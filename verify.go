@@ -0,0 +1,133 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+var (
+	// ErrBadSignature is returned when the signature of the response is invalid.
+	ErrBadSignature = errors.New("bad response signature")
+
+	// ErrMissingSignature is returned when the signature of the response
+	// is missing and it is not allowed.
+	ErrMissingSignature = errors.New("missing response signature")
+)
+
+// SignatureVerifyError is returned when failing to verify the signature
+// of the response, but not because the signature is invalid.
+type SignatureVerifyError struct {
+	Err error
+}
+
+// Unwrap returns the inner error.
+func (e SignatureVerifyError) Unwrap() error { return e.Err }
+
+func (e SignatureVerifyError) Error() string {
+	return fmt.Sprintf("fail to verify the response signature: %s", e.Err)
+}
+
+// DefaultMaxSignedBodySize is the default maximum size of the response body
+// to be buffered to verify the signature.
+const DefaultMaxSignedBodySize = 10 * 1024 * 1024
+
+// ResponseSignatureVerifier is used to verify the signature of the response body.
+type ResponseSignatureVerifier struct {
+	// Header is the name of the response header containing the signature.
+	Header string
+
+	// Verify verifies the body with the signature, which returns
+	// ErrBadSignature if the signature is invalid, or other errors
+	// if failing to verify it, which are wrapped into SignatureVerifyError.
+	Verify func(body []byte, signature string) error
+
+	// If true, the response without the signature header is allowed.
+	// Or, fail with ErrMissingSignature.
+	AllowMissing bool
+
+	// MaxBodySize is the maximum size of the response body to be buffered.
+	//
+	// Default: DefaultMaxSignedBodySize
+	MaxBodySize int64
+}
+
+// Transform implements the interface ResponseTransformer, which buffers
+// the response body to verify it and replays it to the response handlers.
+func (v ResponseSignatureVerifier) Transform(resp *http.Response) (*http.Response, error) {
+	signature := resp.Header.Get(v.Header)
+	if signature == "" {
+		if v.AllowMissing {
+			return resp, nil
+		}
+		return nil, ErrMissingSignature
+	}
+
+	maxsize := v.MaxBodySize
+	if maxsize <= 0 {
+		maxsize = DefaultMaxSignedBodySize
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxsize+1))
+	if err != nil {
+		return nil, err
+	} else if int64(len(body)) > maxsize {
+		return nil, fmt.Errorf("the response body to be verified exceeds %d bytes", maxsize)
+	}
+
+	switch err := v.Verify(body, signature); err {
+	case nil:
+	case ErrBadSignature:
+		return nil, err
+	default:
+		return nil, SignatureVerifyError{Err: err}
+	}
+
+	ReplaceResponseBody(resp, body, "")
+	return resp, nil
+}
+
+// NewHMACVerifier returns a verify function used by ResponseSignatureVerifier,
+// which decodes the signature by decode, such as hex.DecodeString,
+// and compares it with the HMAC of the body in constant time.
+func NewHMACVerifier(h func() hash.Hash, key []byte, decode func(string) ([]byte, error)) func(body []byte, signature string) error {
+	return func(body []byte, signature string) error {
+		sig, err := decode(signature)
+		if err != nil { // The malformed signature
+			return ErrBadSignature
+		}
+
+		mac := hmac.New(h, key)
+		_, _ = mac.Write(body)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return ErrBadSignature
+		}
+		return nil
+	}
+}
+
+// VerifyResponseSignature is a convenient method to verify the signature
+// of the response body in the response header, which is equal to
+//
+//	c.AddResponseTransformer(ResponseSignatureVerifier{Header: header, Verify: verify}.Transform)
+func (c *Client) VerifyResponseSignature(header string, verify func(body []byte, signature string) error) *Client {
+	return c.AddResponseTransformer(ResponseSignatureVerifier{Header: header, Verify: verify}.Transform)
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifyResponseSignature(t *testing.T) {
+	key := []byte("secret")
+	body := `{"name":"xgfone"}`
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(body))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/good":
+			w.Header().Set("X-Signature", signature)
+		case "/bad":
+			w.Header().Set("X-Signature", hex.EncodeToString([]byte("bad")))
+		}
+
+		w.Header().Set("Content-Type", MIMEApplicationJSON)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	verify := NewHMACVerifier(sha256.New, key, hex.DecodeString)
+	client := NewClient(http.DefaultClient).OnResponse(nil).
+		VerifyResponseSignature("X-Signature", verify)
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	if err := client.Get(server.URL+"/good").Do(context.Background(), &result).Unwrap(); err != nil {
+		t.Error(err)
+	} else if result.Name != "xgfone" {
+		t.Errorf("expect name '%s', but got '%s'", "xgfone", result.Name)
+	}
+
+	err := client.Get(server.URL+"/bad").Do(context.Background(), &result).Unwrap()
+	if err == nil || err.(Error).Err != ErrBadSignature {
+		t.Errorf("expect error ErrBadSignature, but got %v", err)
+	}
+
+	err = client.Get(server.URL+"/missing").Do(context.Background(), &result).Unwrap()
+	if err == nil || err.(Error).Err != ErrMissingSignature {
+		t.Errorf("expect error ErrMissingSignature, but got %v", err)
+	}
+
+	// The failure to verify the signature is not a bad signature.
+	errVerify := errors.New("test verify error")
+	client = NewClient(http.DefaultClient).OnResponse(nil).
+		VerifyResponseSignature("X-Signature", func([]byte, string) error { return errVerify })
+	err = client.Get(server.URL+"/good").Do(context.Background(), &result).Unwrap()
+	if e, ok := err.(Error).Err.(SignatureVerifyError); !ok || e.Err != errVerify {
+		t.Errorf("expect SignatureVerifyError, but got %v", err)
+	} else if kind := Kind(err); kind == KindInvalidResponse {
+		t.Errorf("unexpected the error kind '%s'", kind)
+	}
+
+	client = NewClient(http.DefaultClient).OnResponse(nil).
+		AddResponseTransformer(ResponseSignatureVerifier{
+			Header:       "X-Signature",
+			Verify:       verify,
+			AllowMissing: true,
+			MaxBodySize:  int64(len(body)),
+		}.Transform)
+	if err := client.Get(server.URL+"/missing").Do(context.Background(), &result).Unwrap(); err != nil {
+		t.Error(err)
+	}
+	if err := client.Get(server.URL+"/good").Do(context.Background(), &result).Unwrap(); err != nil {
+		t.Error(err)
+	}
+
+	client = NewClient(http.DefaultClient).OnResponse(nil).
+		AddResponseTransformer(ResponseSignatureVerifier{
+			Header:      "X-Signature",
+			Verify:      verify,
+			MaxBodySize: 4,
+		}.Transform)
+	if err := client.Get(server.URL+"/good").Do(context.Background(), &result).Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// SetWarmupPath sets the path of the warmup requests, which is relative
+// to the base url, or an absolute url.
+//
+// Default: "/"
+func (c *Client) SetWarmupPath(path string) *Client {
+	c.warmup = path
+	return c
+}
+
+// Warmup sends n HEAD requests to the warmup path concurrently to populate
+// the connection pool, which ignores the response status codes and bodies,
+// but returns the first error, such as the TLS handshake error.
+//
+// It does nothing if n is equal to or less than 0, or the doer has not
+// the method CloseIdleConnections, which is regarded as not backed
+// by the connection pool.
+func (c *Client) Warmup(ctx context.Context, n int) error {
+	if n <= 0 || c.doer == nil {
+		return nil
+	}
+
+	// http.Client.CloseIdleConnections is added in go1.12.
+	if _, ok := c.doer.(interface{ CloseIdleConnections() }); !ok {
+		return nil
+	}
+
+	path := c.warmup
+	if path == "" {
+		path = "/"
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- c.Head(path).OnResponse(nil).Do(ctx, func(*http.Response) error {
+				return nil
+			}).Unwrap()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientWarmup(t *testing.T) {
+	var conns, requests int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/ping" {
+			w.WriteHeader(400)
+			return
+		}
+
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(time.Millisecond * 20)
+		w.WriteHeader(404)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	transport := &http.Transport{MaxIdleConnsPerHost: 4}
+	defer transport.CloseIdleConnections()
+
+	client := NewClient(&http.Client{Transport: transport}).SetBaseURL(server.URL).SetWarmupPath("/ping")
+	if err := client.Warmup(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("expect %d requests, but got %d", 3, n)
+	}
+	if n := atomic.LoadInt32(&conns); n != 3 {
+		t.Errorf("expect %d connections, but got %d", 3, n)
+	}
+
+	// The warmed connections are reused.
+	if err := client.Warmup(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	} else if n := atomic.LoadInt32(&conns); n != 3 {
+		t.Errorf("expect %d connections, but got %d", 3, n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := client.Warmup(ctx, 1); err == nil {
+		t.Errorf("expect an error, but got nil")
+	}
+
+	client.SetDoer(DoFunc(func(*http.Request) (*http.Response, error) {
+		t.Errorf("unexpected warmup request")
+		return nil, nil
+	}))
+	if err := client.Warmup(context.Background(), 3); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// WireLimits is the limits of the finalized request before sending it,
+// which is used to fail fast instead of being rejected by the proxies
+// with the opaque 4xx errors.
+//
+// The zero value of each field means no limit.
+type WireLimits struct {
+	MaxURLLength         int // Such as 8192
+	MaxHeaderValueLength int // Such as 16384
+	MaxHeaderCount       int // The total number of the header values
+}
+
+func (l WireLimits) check(req *http.Request) error {
+	if l.MaxURLLength > 0 {
+		if n := len(req.URL.String()); n > l.MaxURLLength {
+			return fmt.Errorf("url length %d exceeds the limit %d", n, l.MaxURLLength)
+		}
+	}
+
+	if l.MaxHeaderValueLength <= 0 && l.MaxHeaderCount <= 0 {
+		return nil
+	}
+
+	var count int
+	for key, values := range req.Header {
+		count += len(values)
+		if l.MaxHeaderValueLength <= 0 {
+			continue
+		}
+
+		for _, value := range values {
+			if n := len(value); n > l.MaxHeaderValueLength {
+				return fmt.Errorf("header '%s' value length %d exceeds the limit %d",
+					key, n, l.MaxHeaderValueLength)
+			}
+		}
+	}
+
+	if l.MaxHeaderCount > 0 && count > l.MaxHeaderCount {
+		return fmt.Errorf("header count %d exceeds the limit %d", count, l.MaxHeaderCount)
+	}
+
+	return nil
+}
+
+// SetWireLimits sets the limits of the finalized request, which is checked
+// after applying the hooks and before sending the request.
+//
+// Default: WireLimits{}, that's, no limits
+func (c *Client) SetWireLimits(limits WireLimits) *Client {
+	c.limits = limits
+	return c
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientWireLimits(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(204)
+	}))
+	defer server.Close()
+
+	client := NewClient(http.DefaultClient).OnResponse(nil).DelHeader(HeaderUserAgent).SetWireLimits(WireLimits{
+		MaxURLLength:         len(server.URL) + 10,
+		MaxHeaderValueLength: 32,
+		MaxHeaderCount:       4,
+	})
+
+	do := func(req *Request) error { return req.Do(context.Background(), nil).Unwrap() }
+
+	if err := do(client.Get(server.URL + "/" + strings.Repeat("a", 9))); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := do(client.Get(server.URL + "/" + strings.Repeat("a", 10))); err == nil ||
+		!strings.Contains(err.Error(), "url length") {
+		t.Errorf("expect an url length error, but got %v", err)
+	}
+
+	if err := do(client.Get(server.URL).SetHeader("X-Value", strings.Repeat("a", 32))); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := do(client.Get(server.URL).SetHeader("X-Value", strings.Repeat("a", 33))); err == nil ||
+		!strings.Contains(err.Error(), "header 'X-Value' value length 33") {
+		t.Errorf("expect a header value length error, but got %v", err)
+	}
+
+	// The header Content-Type is set by default.
+	req := client.Get(server.URL).AddHeader("X-Value", "1").AddHeader("X-Value", "2").AddHeader("X-Value", "3")
+	if err := do(req); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	req = client.Get(server.URL).AddHeader("X-Value", "1").AddHeader("X-Value", "2").
+		AddHeader("X-Value", "3").AddHeader("X-Value", "4")
+	if err := do(req); err == nil || !strings.Contains(err.Error(), "header count 5") {
+		t.Errorf("expect a header count error, but got %v", err)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("expect %d requests sent, but got %d", 3, n)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// WrapResponseHandlers appends the wrapper to decorate the response handler,
+// which is resolved in Do, such as the All, When, 1xx-5xx or Default handler.
+//
+// The wrappers are composed in the order of registration,
+// that's, the first registered is the outermost.
+//
+// Notice: it does not wrap the function result passed to Do.
+func (c *Client) WrapResponseHandlers(wrapper func(next Handler) Handler) *Client {
+	if wrapper == nil {
+		panic("Client.WrapResponseHandlers: the wrapper must not be nil")
+	}
+	c.wrappers = append(c.wrappers, wrapper)
+	return c
+}
+
+// WrapBodyEncoder appends the wrapper to decorate the encoder of the request
+// body, which may transform the data before delegating to the inner encoder,
+// such as encrypting the sensitive fields.
+//
+// The wrappers are composed in the order of registration,
+// that's, the first registered is the outermost. And they always wrap
+// the current encoder, which is set by SetBodyEncoder of the client
+// or the request.
+func (c *Client) WrapBodyEncoder(wrapper func(next Encoder) Encoder) *Client {
+	if wrapper == nil {
+		panic("Client.WrapBodyEncoder: the wrapper must not be nil")
+	}
+	c.encwrappers = append(c.encwrappers, wrapper)
+	return c
+}
+
+// bodyEncoder returns the encoder of the request body wrapped by the wrappers.
+func (r *Request) bodyEncoder() Encoder {
+	encoder := r.encoder
+	for i := len(r.encwrappers) - 1; i >= 0; i-- {
+		encoder = r.encwrappers[i](encoder)
+	}
+	return encoder
+}
+
+// TimingWrapper returns a response handler wrapper to record
+// the cost duration of the response handler, such as decoding the body.
+func TimingWrapper(record func(resp *http.Response, cost time.Duration)) func(next Handler) Handler {
+	if record == nil {
+		panic("TimingWrapper: the record function must not be nil")
+	}
+
+	return func(next Handler) Handler {
+		return func(dst interface{}, resp *http.Response) error {
+			start := time.Now()
+			defer func() { record(resp, time.Since(start)) }()
+			return next(dst, resp)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 xgfone
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpclient
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrapResponseHandlers(t *testing.T) {
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		code := 200
+		if r.URL.Path == "/error" {
+			code = 500
+		}
+
+		resp := &http.Response{StatusCode: code, Header: make(http.Header), Request: r}
+		resp.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		resp.Body = http.NoBody
+		if code == 200 {
+			resp.Body = readCloser{strings.NewReader(`"abc"`), http.NoBody}
+		}
+		return resp, nil
+	}))
+
+	var calls []string
+	var costs []time.Duration
+	var dsts []interface{}
+	client.WrapResponseHandlers(func(next Handler) Handler {
+		return func(dst interface{}, resp *http.Response) error {
+			calls = append(calls, "outer")
+			dsts = append(dsts, dst)
+			return next(dst, resp)
+		}
+	})
+	client.WrapResponseHandlers(func(next Handler) Handler {
+		return func(dst interface{}, resp *http.Response) error {
+			calls = append(calls, "inner")
+			return next(dst, resp)
+		}
+	})
+	client.WrapResponseHandlers(TimingWrapper(func(resp *http.Response, cost time.Duration) {
+		costs = append(costs, cost)
+	}))
+
+	var result string
+	if err := client.Get("http://127.0.0.1").Do(context.Background(), &result).Unwrap(); err != nil {
+		t.Fatal(err)
+	} else if result != "abc" {
+		t.Errorf("expect result '%s', but got '%s'", "abc", result)
+	}
+
+	if len(calls) != 2 || calls[0] != "outer" || calls[1] != "inner" {
+		t.Errorf("unexpected wrapper calls: %v", calls)
+	}
+	if len(dsts) != 1 || dsts[0] != &result {
+		t.Errorf("the wrapper does not see the same dst")
+	}
+	if len(costs) != 1 {
+		t.Errorf("expect 1 timing record, but got %d", len(costs))
+	}
+
+	// The default handler is wrapped as well.
+	calls = nil
+	if err := client.Get("http://127.0.0.1/error").Do(context.Background(), nil).Unwrap(); err == nil {
+		t.Errorf("expect an error, but got nil")
+	} else if len(calls) != 2 {
+		t.Errorf("unexpected wrapper calls: %v", calls)
+	}
+
+	// The function result is not wrapped.
+	calls = nil
+	_ = client.Get("http://127.0.0.1").Do(context.Background(), func(*http.Response) error { return nil })
+	if len(calls) != 0 {
+		t.Errorf("unexpected wrapper calls: %v", calls)
+	}
+}
+
+// encryptFields is an example wrapper to encrypt the string fields
+// of the struct with the tag `encrypt:"true"` by the encrypt callback.
+func encryptFields(encrypt func(string) string) func(next Encoder) Encoder {
+	return func(next Encoder) Encoder {
+		return func(w io.Writer, ct string, data interface{}) error {
+			v := reflect.ValueOf(data)
+			if v.Kind() != reflect.Struct {
+				return next(w, ct, data)
+			}
+
+			fields := make(map[string]interface{}, v.NumField())
+			for i := 0; i < v.NumField(); i++ {
+				field := v.Type().Field(i)
+				name := strings.Split(field.Tag.Get("json"), ",")[0]
+				if name == "" {
+					name = field.Name
+				}
+
+				if field.Tag.Get("encrypt") == "true" && field.Type.Kind() == reflect.String {
+					fields[name] = encrypt(v.Field(i).String())
+				} else {
+					fields[name] = v.Field(i).Interface()
+				}
+			}
+			return next(w, ct, fields)
+		}
+	}
+}
+
+func TestWrapBodyEncoder(t *testing.T) {
+	type User struct {
+		Name string `json:"name"`
+		SSN  string `json:"ssn" encrypt:"true"`
+	}
+
+	var body string
+	client := NewClient(nil).OnResponse(nil)
+	client.SetDoer(DoFunc(func(r *http.Request) (*http.Response, error) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		return &http.Response{StatusCode: 204, Body: http.NoBody, Request: r}, nil
+	}))
+
+	client.WrapBodyEncoder(encryptFields(func(s string) string {
+		return "enc(" + s + ")"
+	}))
+
+	var order []string
+	client.WrapBodyEncoder(func(next Encoder) Encoder {
+		return func(w io.Writer, ct string, data interface{}) error {
+			order = append(order, "inner")
+			return next(w, ct, data)
+		}
+	})
+
+	user := User{Name: "abc", SSN: "123"}
+	err := client.Post("http://127.0.0.1").SetBody(user).Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	} else if expect := `{"name":"abc","ssn":"enc(123)"}` + "\n"; body != expect {
+		t.Errorf("expect body '%s', but got '%s'", expect, body)
+	} else if len(order) != 1 {
+		t.Errorf("expect the inner wrapper to be called once, but got %d", len(order))
+	}
+
+	// Wrap the encoder set by the request.
+	err = client.Post("http://127.0.0.1").
+		SetBodyEncoder(func(w io.Writer, ct string, data interface{}) error {
+			_, err := io.WriteString(w, data.(map[string]interface{})["ssn"].(string))
+			return err
+		}).
+		SetBody(user).Do(context.Background(), nil).Unwrap()
+	if err != nil {
+		t.Fatal(err)
+	} else if expect := "enc(123)"; body != expect {
+		t.Errorf("expect body '%s', but got '%s'", expect, body)
+	}
+}